@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"go.uber.org/zap"
+)
+
+// validateAdmin checks the configured admin could register, before anything is opened
+func validateAdmin(cfg config.AdminConfig) error {
+	if cfg.Username == "" {
+		return nil
+	}
+	return request.ValidateRegisterUser(&request.RegisterUserRequest{Username: cfg.Username, Password: cfg.Password})
+}
+
+// createAdmin creates the configured admin unless a user already has its username, so restarts and replicas
+// starting together leave the existing one as it is
+func createAdmin(ctx context.Context, users repository.UserRepository, cfg config.AdminConfig) error {
+	if cfg.Username == "" {
+		return nil
+	}
+
+	logger := log.GetLogger().With(zap.String("username", cfg.Username))
+	hash, err := auth.HashPassword(cfg.Password)
+	if err != nil {
+		return err
+	}
+
+	admin := &model.User{Username: cfg.Username, DisplayName: cfg.Username, PasswordHash: hash, Role: model.RoleAdmin}
+	_, err = users.CreateUser(ctx, admin)
+	if !errors.Is(err, app_err.ErrConflict) {
+		if err == nil {
+			logger.Info("[Setup] admin created")
+		}
+		return err
+	}
+
+	existing, err := users.GetUserByUsername(ctx, cfg.Username)
+	if err != nil {
+		return err
+	}
+	if existing.Role != model.RoleAdmin {
+		logger.Error("[Setup] the admin username belongs to a user who is not an admin",
+			zap.String("role", string(existing.Role)),
+		)
+		return nil
+	}
+
+	logger.Info("[Setup] admin already exists")
+	return nil
+}
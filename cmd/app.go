@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	"github.com/aleszilagyi/prosig-blog/internal/handler"
+	"github.com/aleszilagyi/prosig-blog/internal/health"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/metrics"
+	"github.com/aleszilagyi/prosig-blog/internal/router"
+	"github.com/aleszilagyi/prosig-blog/internal/scheduler"
+	"github.com/aleszilagyi/prosig-blog/internal/storage"
+	"github.com/aleszilagyi/prosig-blog/internal/tracing"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// application is the service built over its open repositories, at startup or, in degraded mode,
+// once the database is reached
+type application struct {
+	repos       *repositories
+	engine      *gin.Engine
+	workers     sync.WaitGroup
+	stopWorkers context.CancelFunc
+}
+
+// newApplication builds the handlers and starts the background workers. Readiness fails once ctx is done
+func newApplication(ctx context.Context, cfg config.Config, repos *repositories, tokens *auth.TokenManager) *application {
+	checker := health.NewChecker(repos.db, storage.Driver(cfg))
+	context.AfterFunc(ctx, checker.ShuttingDown)
+
+	if repos.db != nil {
+		if err := metrics.RegisterDBStats(repos.db, storage.Driver(cfg)); err != nil {
+			log.GetLogger().Error("[Setup] failed to export the database pool metrics", zap.Error(err))
+		}
+	}
+	blog := metrics.InstrumentBlogRepository(tracing.TraceBlogRepository(repos.blog))
+	users := metrics.InstrumentUserRepository(tracing.TraceUserRepository(repos.users))
+	apiKeys := metrics.InstrumentAPIKeyRepository(tracing.TraceAPIKeyRepository(repos.apiKeys))
+
+	if err := createAdmin(ctx, users, cfg.AuthConfig.Admin); err != nil {
+		log.GetLogger().Error("[Setup] failed to create the admin", zap.Error(err))
+	}
+
+	engine := router.SetupRouter(
+		handler.NewBlogHandler(blog),
+		handler.NewAuthHandler(users, tokens),
+		handler.NewUserHandler(users),
+		handler.NewAPIKeyHandler(apiKeys),
+		handler.NewHealthHandler(checker),
+		tokens,
+		users,
+		apiKeys,
+	)
+
+	// Background workers get their own context, so they keep running while the requests in flight are drained
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	app := &application{repos: repos, engine: engine, stopWorkers: stopWorkers}
+	publisher := scheduler.NewPublisher(blog, cfg.BlogConfig.PublishInterval)
+	app.workers.Go(func() {
+		publisher.Run(workersCtx)
+	})
+
+	return app
+}
+
+// stop stops the background workers and then closes the repositories
+func (a *application) stop() error {
+	a.stopWorkers()
+	a.workers.Wait()
+	log.GetLogger().Info("[Shutdown] background workers stopped")
+
+	return a.repos.close()
+}
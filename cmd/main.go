@@ -1,16 +1,23 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"net/http"
 	"os"
+	"os/signal"
 	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
 	"github.com/aleszilagyi/prosig-blog/internal/handler"
 	log "github.com/aleszilagyi/prosig-blog/internal/logger"
-	"github.com/aleszilagyi/prosig-blog/internal/repository"
 	"github.com/aleszilagyi/prosig-blog/internal/router"
+	"github.com/aleszilagyi/prosig-blog/internal/server"
 	"github.com/aleszilagyi/prosig-blog/internal/storage"
+	"github.com/aleszilagyi/prosig-blog/internal/tracing"
 	"go.uber.org/zap"
 )
 
@@ -31,16 +38,94 @@ func main() {
 	config.LoadConfig()
 	logger = log.GetLogger()
 
-	dbConn, err := storage.Connect(config.GetConfigs())
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			logger.Fatal("[Migrate] failed to run migrations", zap.Error(err))
+		}
+		return
+	}
+
+	cfg := config.GetConfigs()
+	if cfg.AuthConfig.JWTSecret == "" {
+		logger.Fatal("[Setup] missing auth.jwt_secret configuration")
+	}
+	if err := validateAdmin(cfg.AuthConfig.Admin); err != nil {
+		logger.Fatal("[Setup] invalid auth.admin configuration", zap.Error(err))
+	}
+	tokens := auth.NewTokenManager(cfg.AuthConfig.JWTSecret, cfg.AuthConfig.TokenTTL)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingConfig)
 	if err != nil {
-		logger.Error("[Setup] failed to start db connection", zap.Error(err))
+		logger.Fatal("[Setup] failed to set up tracing", zap.Error(err),
+			zap.String("exporter", cfg.TracingConfig.Exporter),
+		)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var app atomic.Pointer[application]
+	var background sync.WaitGroup
+	var serving http.Handler
+	repos, err := openRepositories(ctx, cfg, cfg.DatabaseConfig.ConnectAttempts)
+	switch {
+	case err == nil:
+		app.Store(newApplication(ctx, cfg, repos, tokens))
+		serving = app.Load().engine
+	case ctx.Err() != nil:
+		logger.Info("[Shutdown] service stopped before opening the repositories")
+		return
+	case !cfg.DatabaseConfig.DegradedMode:
+		logger.Fatal("[Setup] failed to open the repositories", zap.Error(err),
+			zap.String("db_driver", cfg.DatabaseConfig.Driver),
+		)
+	default:
+		// Serve 503 right away and swap the service in once the database is reached
+		logger.Error("[Setup] failed to open the repositories, starting degraded", zap.Error(err),
+			zap.String("db_driver", cfg.DatabaseConfig.Driver),
+		)
+		retryAfter := storage.NewBackoff(cfg.DatabaseConfig).Max
+		swappable := server.NewSwappableHandler(router.SetupUnavailableRouter(handler.NewHealthHandler(nil), retryAfter))
+		serving = swappable
+		background.Go(func() {
+			repos, err := openRepositories(ctx, cfg, 0)
+			if err != nil {
+				logger.Info("[Setup] gave up reconnecting", zap.Error(err))
+				return
+			}
+			app.Store(newApplication(ctx, cfg, repos, tokens))
+			swappable.Swap(app.Load().engine)
+			logger.Info("[Setup] repositories opened, leaving degraded mode")
+		})
+	}
+
+	// Once a signal arrives the service stops in order, the requests in flight first,
+	// then the background workers, and the database pool last
+	exitCode := 0
+	if err := server.New(cfg.AppConfig, serving).Run(ctx); err != nil {
+		logger.Error("[Shutdown] http server stopped with an error", zap.Error(err))
+		exitCode = 1
+	}
+
+	stop()
+	background.Wait()
+	if app := app.Load(); app != nil {
+		if err := app.stop(); err != nil {
+			logger.Error("[Shutdown] failed to close the repositories", zap.Error(err))
+			exitCode = 1
+		}
 	}
-	defer dbConn.Close()
 
-	repo := repository.NewBlogRepository(dbConn)
-	blogHandler := handler.NewBlogHandler(repo)
-	server := router.SetupRouter(blogHandler)
+	// The spans of the last requests are flushed once nothing is left to record them
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracing.FlushTimeout)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Error("[Shutdown] failed to flush the traces", zap.Error(err))
+		exitCode = 1
+	}
 
-	port := config.GetConfigs().AppConfig.Port
-	server.Run(fmt.Sprintf(":%d", port))
+	logger.Info("[Shutdown] service stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
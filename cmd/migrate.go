@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/storage"
+	"github.com/aleszilagyi/prosig-blog/internal/storage/migrations"
+	"go.uber.org/zap"
+)
+
+const migrateUsage = "usage: main migrate up | down [steps] | status"
+
+// runMigrate handles the migrate subcommand, which applies or reverts the schema migrations
+// whatever db.migrate_on_start says
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("steps must be a positive integer: %s", migrateUsage)
+			}
+			steps = n
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	ctx := context.Background()
+	cfg := config.GetConfigs()
+	db, err := storage.Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	logger := log.GetLogger()
+	driver := storage.Driver(cfg)
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, db, driver)
+		if err != nil {
+			return err
+		}
+		logger.Info("[Migrate] migrations applied", zap.Int("applied_migrations", applied))
+	case "down":
+		reverted, err := migrations.Down(ctx, db, driver, steps)
+		if err != nil {
+			return err
+		}
+		logger.Info("[Migrate] migrations reverted", zap.Int("reverted_migrations", reverted))
+	case "status":
+		statuses, err := migrations.GetStatus(ctx, db, driver)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(os.Stdout, statuses)
+	}
+
+	return nil
+}
+
+func printMigrationStatus(out io.Writer, statuses []migrations.Status) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/memory"
+	"github.com/aleszilagyi/prosig-blog/internal/storage"
+)
+
+type repositories struct {
+	blog    repository.BlogRepository
+	users   repository.UserRepository
+	apiKeys repository.APIKeyRepository
+	// db is nil for the storages without a database
+	db    *sql.DB
+	close func() error
+}
+
+// openRepositories builds the repositories over the storage db.driver picks, trying to connect to the database
+// as many times as attempts allows, without limit when it is not positive
+func openRepositories(ctx context.Context, cfg config.Config, attempts int) (*repositories, error) {
+	switch cfg.DatabaseConfig.Driver {
+	case storage.DriverMemory:
+		store := memory.NewStore()
+		return &repositories{
+			blog:    memory.NewBlogRepository(store),
+			users:   memory.NewUserRepository(store),
+			apiKeys: memory.NewAPIKeyRepository(store),
+			close:   func() error { return nil },
+		}, nil
+	case "", storage.DriverPostgres, storage.DriverSQLite:
+		db, err := storage.ConnectWithRetry(ctx, cfg, attempts)
+		if err != nil {
+			return nil, err
+		}
+		dialect := repository.Dialect(storage.Driver(cfg))
+		return &repositories{
+			blog:    repository.NewBlogRepository(db, dialect),
+			users:   repository.NewUserRepository(db, dialect),
+			apiKeys: repository.NewAPIKeyRepository(db, dialect),
+			db:      db,
+			close:   db.Close,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown db driver %q", cfg.DatabaseConfig.Driver)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -13,26 +14,89 @@ type Config struct {
 	AppConfig      AppConfig      `mapstructure:"app"`
 	DatabaseConfig DatabaseConfig `mapstructure:"db"`
 	LoggerConfig   LoggerConfig   `mapstructure:"logger"`
+	BlogConfig     BlogConfig     `mapstructure:"blog"`
+	AuthConfig     AuthConfig     `mapstructure:"auth"`
+	TracingConfig  TracingConfig  `mapstructure:"tracing"`
 }
 
 type AppConfig struct {
 	Port int    `mapstructure:"port"`
 	Env  string `mapstructure:"env"`
+	// The HTTP server timeouts, the server defaults are used for the ones left out
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout bounds how long the requests in flight are waited for when the service stops
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
 }
 
 type DatabaseConfig struct {
+	// Driver picks the storage, postgres, sqlite which keeps everything in the file at Path,
+	// or memory which keeps everything in the process and loses it on restart
+	Driver   string `mapstructure:"driver"`
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	Name     string `mapstructure:"name"`
 	SSLMode  string `mapstructure:"sslmode"`
+	Path     string `mapstructure:"path"`
+	// MigrateOnStart applies the pending schema migrations when the service connects to the database
+	MigrateOnStart bool `mapstructure:"migrate_on_start"`
+	// ConnectAttempts is how many times connecting is tried at startup, waiting ConnectBackoff after the first
+	// failure and twice as long after every other, up to ConnectMaxBackoff
+	ConnectAttempts   int           `mapstructure:"connect_attempts"`
+	ConnectBackoff    time.Duration `mapstructure:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `mapstructure:"connect_max_backoff"`
+	// DegradedMode starts the service even when the database cannot be reached, answering 503
+	// until it reconnects in the background
+	DegradedMode bool `mapstructure:"degraded_mode"`
 }
 
 type LoggerConfig struct {
-	Level       string `mapstructure:"level"`
-	Encoding    string `mapstructure:"encoding"`
-	Development bool   `mapstructure:"development"`
+	Level       string          `mapstructure:"level"`
+	Encoding    string          `mapstructure:"encoding"`
+	Development bool            `mapstructure:"development"`
+	AccessLog   AccessLogConfig `mapstructure:"access_log"`
+}
+
+type AccessLogConfig struct {
+	// SkipPaths are the routes, like /api/posts/:id, or request paths left out of the access log, like the probes
+	SkipPaths []string `mapstructure:"skip_paths"`
+	// SuccessSampleRatio is the share of the successful requests that are logged, every one is when it is
+	// left out. Requests that fail are always logged
+	SuccessSampleRatio float64 `mapstructure:"success_sample_ratio"`
+}
+
+type BlogConfig struct {
+	MaxCommentDepth int           `mapstructure:"max_comment_depth"`
+	PublishInterval time.Duration `mapstructure:"publish_interval"`
+}
+
+type AuthConfig struct {
+	JWTSecret string        `mapstructure:"jwt_secret"`
+	TokenTTL  time.Duration `mapstructure:"token_ttl"`
+	// Admin is created at startup unless its username is taken, users registering themselves are commenters
+	// so this is how a new deployment gets its first admin
+	Admin AdminConfig `mapstructure:"admin"`
+}
+
+type AdminConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+type TracingConfig struct {
+	// Exporter is where the spans go, none, otlp which sends them over HTTP to Endpoint,
+	// stdout, or file which appends them to Path
+	Exporter    string `mapstructure:"exporter"`
+	Endpoint    string `mapstructure:"endpoint"`
+	Path        string `mapstructure:"path"`
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio is the share of the traces started by the service that are recorded, every trace is
+	// when it is left out. Traces started upstream keep the decision of the caller
+	SampleRatio float64 `mapstructure:"sample_ratio"`
 }
 
 var c Config
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -26,17 +27,48 @@ func TestLoadConfigAndGetConfigs(t *testing.T) {
 	// Validate app config
 	assert.Equal(t, 8080, cfg.AppConfig.Port)
 	assert.Equal(t, "local", cfg.AppConfig.Env)
+	assert.Equal(t, 5*time.Second, cfg.AppConfig.ReadHeaderTimeout)
+	assert.Equal(t, 15*time.Second, cfg.AppConfig.ReadTimeout)
+	assert.Equal(t, 30*time.Second, cfg.AppConfig.WriteTimeout)
+	assert.Equal(t, 2*time.Minute, cfg.AppConfig.IdleTimeout)
+	assert.Equal(t, 20*time.Second, cfg.AppConfig.ShutdownTimeout)
 
 	// Validate database config
+	assert.Equal(t, "postgres", cfg.DatabaseConfig.Driver)
 	assert.Equal(t, "postgres", cfg.DatabaseConfig.Host)
 	assert.Equal(t, 5432, cfg.DatabaseConfig.Port)
 	assert.Equal(t, "postgres", cfg.DatabaseConfig.User)
 	assert.Equal(t, "postgres", cfg.DatabaseConfig.Password)
 	assert.Equal(t, "postgres", cfg.DatabaseConfig.Name)
 	assert.Equal(t, "disable", cfg.DatabaseConfig.SSLMode)
+	assert.Equal(t, "blog.db", cfg.DatabaseConfig.Path)
+	assert.Equal(t, true, cfg.DatabaseConfig.MigrateOnStart)
+	assert.Equal(t, 5, cfg.DatabaseConfig.ConnectAttempts)
+	assert.Equal(t, 500*time.Millisecond, cfg.DatabaseConfig.ConnectBackoff)
+	assert.Equal(t, 10*time.Second, cfg.DatabaseConfig.ConnectMaxBackoff)
+	assert.Equal(t, false, cfg.DatabaseConfig.DegradedMode)
 
 	// Validate logger config
 	assert.Equal(t, "info", cfg.LoggerConfig.Level)
 	assert.Equal(t, "json", cfg.LoggerConfig.Encoding)
 	assert.Equal(t, true, cfg.LoggerConfig.Development)
+	assert.Equal(t, []string{"/healthz", "/readyz", "/metrics"}, cfg.LoggerConfig.AccessLog.SkipPaths)
+	assert.Equal(t, 1.0, cfg.LoggerConfig.AccessLog.SuccessSampleRatio)
+
+	// Validate blog config
+	assert.Equal(t, 5, cfg.BlogConfig.MaxCommentDepth)
+	assert.Equal(t, 30*time.Second, cfg.BlogConfig.PublishInterval)
+
+	// Validate auth config
+	assert.Equal(t, "local-development-secret", cfg.AuthConfig.JWTSecret)
+	assert.Equal(t, 24*time.Hour, cfg.AuthConfig.TokenTTL)
+	assert.Equal(t, "admin", cfg.AuthConfig.Admin.Username)
+	assert.Equal(t, "local-admin-password", cfg.AuthConfig.Admin.Password)
+
+	// Validate tracing config
+	assert.Equal(t, "none", cfg.TracingConfig.Exporter)
+	assert.Equal(t, "http://localhost:4318", cfg.TracingConfig.Endpoint)
+	assert.Equal(t, "traces.json", cfg.TracingConfig.Path)
+	assert.Equal(t, "prosig-blog", cfg.TracingConfig.ServiceName)
+	assert.Equal(t, 1.0, cfg.TracingConfig.SampleRatio)
 }
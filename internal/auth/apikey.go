@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix tells API keys apart from session tokens when both come in the Authorization header
+	APIKeyPrefix = "pbk_"
+
+	apiKeyBytes = 32
+	// The part of the key kept in clear so admins can tell keys apart when listing them
+	apiKeyVisibleLength = len(APIKeyPrefix) + 8
+)
+
+// GenerateAPIKey returns a new random key, to be handed out once, along with its visible prefix and
+// the hash to be stored in its place
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return key, key[:apiKeyVisibleLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes a key for storage and lookup. Keys are long random strings, unlike passwords,
+// so a single fast hash is enough
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(value string) bool {
+	return strings.HasPrefix(value, APIKeyPrefix)
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "auth.principal"
+
+// Principal is the authenticated user a request is made on behalf of. Requests made with an API key
+// carry the scopes of the key, requests made with a session token carry none and are not limited by them
+type Principal struct {
+	UserID   int
+	Username string
+	Role     model.Role
+	Scopes   []model.Scope
+}
+
+func (p *Principal) IsAPIKey() bool {
+	return p.Scopes != nil
+}
+
+func (p *Principal) HasScope(scope model.Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func SetPrincipal(ctx *gin.Context, principal *Principal) {
+	ctx.Set(principalKey, principal)
+}
+
+// PrincipalFromContext returns the authenticated user of the request, if any
+func PrincipalFromContext(ctx *gin.Context) (*Principal, bool) {
+	value, ok := ctx.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultTokenTTL = 24 * time.Hour
+
+	issuer = "prosig-blog"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type claims struct {
+	Username string     `json:"username"`
+	Role     model.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager issues and verifies the HMAC signed JWTs handed out on login
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewTokenManager(secret string, ttl time.Duration) *TokenManager {
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	return &TokenManager{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Issue signs a token for the user and returns it along with its expiry. The role it carries is only informative,
+// the current role of the user is read on every request
+func (m *TokenManager) Issue(userID int, username string, role model.Role) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	signed, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// Parse verifies the signature and expiry of the token and returns who it was issued to
+func (m *TokenManager) Parse(token string) (*Principal, error) {
+	parsed := &claims{}
+	_, err := jwt.ParseWithClaims(token, parsed, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.Atoi(parsed.Subject)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, fmt.Errorf("invalid subject %q", parsed.Subject))
+	}
+
+	return &Principal{UserID: userID, Username: parsed.Username, Role: parsed.Role}, nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenManager(t *testing.T) {
+	manager := NewTokenManager("secret", time.Hour)
+
+	token, expiresAt, err := manager.Issue(7, "ada", model.RoleAuthor)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	principal, err := manager.Parse(token)
+	assert.NoError(t, err)
+	assert.Equal(t, &Principal{UserID: 7, Username: "ada", Role: model.RoleAuthor}, principal)
+}
+
+func TestTokenManager_Invalid(t *testing.T) {
+	manager := NewTokenManager("secret", time.Hour)
+	token, _, err := manager.Issue(7, "ada", model.RoleAuthor)
+	assert.NoError(t, err)
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := NewTokenManager("other", time.Hour).Parse(token)
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := NewTokenManager("secret", time.Hour)
+		expired.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		_, err := expired.Parse(token)
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := manager.Parse("not-a-token")
+		assert.True(t, errors.Is(err, ErrInvalidToken))
+	})
+}
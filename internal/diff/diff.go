@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line is a single line of an edit script
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the line level edit script that turns from into to
+func Lines(from, to string) []Line {
+	return Compute(splitLines(from), splitLines(to))
+}
+
+// Compute returns the shortest edit script that turns a into b, using the Myers diff algorithm
+func Compute(a, b []string) []Line {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit == 0 {
+		return []Line{}
+	}
+
+	// v holds the furthest x reached on every diagonal k, indexed by k+offset
+	offset := limit
+	v := make([]int, 2*limit+2)
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+
+	return backtrack(a, b, trace, offset)
+}
+
+func backtrack(a, b []string, trace [][]int, offset int) []Line {
+	x, y := len(a), len(b)
+	var script []Line
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+offset]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			script = append(script, Line{Op: OpEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				script = append(script, Line{Op: OpInsert, Text: b[y-1]})
+			} else {
+				script = append(script, Line{Op: OpDelete, Text: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(script)
+	return script
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []Line
+	}{
+		{
+			name:     "both empty",
+			expected: []Line{},
+		},
+		{
+			name: "identical",
+			from: "a\nb",
+			to:   "a\nb",
+			expected: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpEqual, Text: "b"},
+			},
+		},
+		{
+			name: "from empty",
+			to:   "a\nb\n",
+			expected: []Line{
+				{Op: OpInsert, Text: "a"},
+				{Op: OpInsert, Text: "b"},
+			},
+		},
+		{
+			name: "to empty",
+			from: "a",
+			expected: []Line{
+				{Op: OpDelete, Text: "a"},
+			},
+		},
+		{
+			name: "changed line in the middle",
+			from: "title\nhello world\nbye",
+			to:   "title\nhello there\nbye",
+			expected: []Line{
+				{Op: OpEqual, Text: "title"},
+				{Op: OpDelete, Text: "hello world"},
+				{Op: OpInsert, Text: "hello there"},
+				{Op: OpEqual, Text: "bye"},
+			},
+		},
+		{
+			name: "appended and removed lines",
+			from: "a\nb\nc\nd",
+			to:   "b\nc\nd\ne",
+			expected: []Line{
+				{Op: OpDelete, Text: "a"},
+				{Op: OpEqual, Text: "b"},
+				{Op: OpEqual, Text: "c"},
+				{Op: OpEqual, Text: "d"},
+				{Op: OpInsert, Text: "e"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Lines(tt.from, tt.to))
+		})
+	}
+}
+
+func TestCompute_RebuildsBothSides(t *testing.T) {
+	a := []string{"A", "B", "C", "A", "B", "B", "A"}
+	b := []string{"C", "B", "A", "B", "A", "C"}
+
+	script := Compute(a, b)
+
+	var gotA, gotB []string
+	edits := 0
+	for _, line := range script {
+		switch line.Op {
+		case OpEqual:
+			gotA = append(gotA, line.Text)
+			gotB = append(gotB, line.Text)
+		case OpDelete:
+			gotA = append(gotA, line.Text)
+			edits++
+		case OpInsert:
+			gotB = append(gotB, line.Text)
+			edits++
+		}
+	}
+
+	assert.Equal(t, a, gotA)
+	assert.Equal(t, b, gotB)
+	// The classic Myers example has a shortest edit script of 5 edits
+	assert.Equal(t, 5, edits)
+}
@@ -6,4 +6,8 @@ var (
 	ErrNotFound       = errors.New("resource not found")
 	ErrInternalServer = errors.New("internal server error")
 	ErrInvalidInput   = errors.New("invalid request input")
+	ErrConflict       = errors.New("resource already exists")
+	ErrUnauthorized   = errors.New("authentication required")
+	ErrForbidden      = errors.New("permission denied")
+	ErrUnavailable    = errors.New("service unavailable")
 )
@@ -0,0 +1,115 @@
+package error
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// The codes of the problems every kind of error falls back to, clients branch on them rather than on the detail
+const (
+	CodeInvalidInput       = "invalid_input"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeInternal           = "internal_error"
+)
+
+type kind struct {
+	sentinel error
+	code     string
+	status   int
+	title    string
+}
+
+var kinds = []kind{
+	{sentinel: ErrInvalidInput, code: CodeInvalidInput, status: http.StatusBadRequest, title: "Invalid input"},
+	{sentinel: ErrNotFound, code: CodeNotFound, status: http.StatusNotFound, title: "Resource not found"},
+	{sentinel: ErrConflict, code: CodeConflict, status: http.StatusConflict, title: "Resource already exists"},
+	{sentinel: ErrUnauthorized, code: CodeUnauthorized, status: http.StatusUnauthorized, title: "Authentication required"},
+	{sentinel: ErrForbidden, code: CodeForbidden, status: http.StatusForbidden, title: "Permission denied"},
+	{sentinel: ErrUnavailable, code: CodeServiceUnavailable, status: http.StatusServiceUnavailable, title: "Service unavailable"},
+}
+
+var internal = kind{sentinel: ErrInternalServer, code: CodeInternal, status: http.StatusInternalServerError, title: "Internal server error"}
+
+// Error is an application error as clients get it. Its kind, one of the sentinel errors, sets the HTTP status
+// and the title, and its code tells apart the problems of the same kind
+type Error struct {
+	Kind       error
+	Code       string
+	Status     int
+	Title      string
+	Detail     string
+	Violations []Violation
+}
+
+// Violation is a rule a field of the request breaks, the field is its JSON path, like tags[1]
+type Violation struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// New returns an error of the kind, one of the sentinel errors, that clients tell apart by its code
+func New(sentinel error, code, detail string) *Error {
+	k := kindOf(sentinel)
+	return &Error{Kind: k.sentinel, Code: code, Status: k.status, Title: k.title, Detail: detail}
+}
+
+// WithViolations returns a copy of the error listing the fields at fault
+func (e *Error) WithViolations(violations ...Violation) *Error {
+	withViolations := *e
+	withViolations.Violations = append(append([]Violation(nil), e.Violations...), violations...)
+	return &withViolations
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Detail
+}
+
+// Unwrap makes the error match its kind with errors.Is
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// From turns any error into the one clients get. Errors wrapping a sentinel keep their message as the detail,
+// without the sentinel's, and every other error is an internal error whose cause is not disclosed
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	k := kindOf(err)
+	detail := k.sentinel.Error()
+	if k.status < http.StatusInternalServerError {
+		detail = describe(err, k.sentinel)
+	}
+	return &Error{Kind: k.sentinel, Code: k.code, Status: k.status, Title: k.title, Detail: detail}
+}
+
+func kindOf(err error) kind {
+	for _, k := range kinds {
+		if errors.Is(err, k.sentinel) {
+			return k
+		}
+	}
+	return internal
+}
+
+// describe keeps what the error adds to its sentinel, which errors are joined to and may be wrapped in
+func describe(err, sentinel error) string {
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(err.Error(), sentinel.Error()+"\n", ""), "\n") {
+		if part = strings.TrimSpace(part); part != "" && part != sentinel.Error() {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return sentinel.Error()
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,57 @@
+package error
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	err := New(ErrInvalidInput, "malformed_json", "malformed json")
+
+	assert.True(t, errors.Is(err, ErrInvalidInput), "error should wrap ErrInvalidInput")
+	assert.Equal(t, http.StatusBadRequest, err.Status)
+	assert.Equal(t, "Invalid input", err.Title)
+	assert.Equal(t, "malformed_json: malformed json", err.Error())
+
+	withViolations := err.WithViolations(Violation{Field: "title", Code: "required", Message: "title is required"})
+	assert.Len(t, withViolations.Violations, 1)
+	assert.Empty(t, err.Violations, "the original error should be left as it is")
+}
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{name: "sentinel", err: ErrNotFound,
+			status: http.StatusNotFound, code: CodeNotFound, detail: "resource not found"},
+		{name: "joined to its sentinel", err: errors.Join(ErrForbidden, errors.New("authors cannot delete tags")),
+			status: http.StatusForbidden, code: CodeForbidden, detail: "authors cannot delete tags"},
+		{name: "joined to several errors", err: errors.Join(ErrInvalidInput, errors.New("title is required"), errors.New("content is required")),
+			status: http.StatusBadRequest, code: CodeInvalidInput, detail: "title is required; content is required"},
+		{name: "wrapped", err: fmt.Errorf("failed to create user: %w", errors.Join(ErrConflict, errors.New("username already taken"))),
+			status: http.StatusConflict, code: CodeConflict, detail: "failed to create user: username already taken"},
+		{name: "unavailable", err: ErrUnavailable,
+			status: http.StatusServiceUnavailable, code: CodeServiceUnavailable, detail: "service unavailable"},
+		{name: "unknown", err: errors.New("pq: connection refused"),
+			status: http.StatusInternalServerError, code: CodeInternal, detail: "internal server error"},
+		{name: "application error", err: fmt.Errorf("binding: %w", New(ErrUnauthorized, "invalid_token", "invalid or expired token")),
+			status: http.StatusUnauthorized, code: "invalid_token", detail: "invalid or expired token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := From(tt.err)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.detail, problem.Detail)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/policy"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler holds the admin endpoints to manage the API keys of machine clients
+type APIKeyHandler interface {
+	CreateAPIKey(ctx *gin.Context)
+	GetAPIKeys(ctx *gin.Context)
+	RevokeAPIKey(ctx *gin.Context)
+}
+
+type apiKeyHandler struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyHandler(repo repository.APIKeyRepository) APIKeyHandler {
+	return &apiKeyHandler{repo: repo}
+}
+
+// CreateAPIKey generates a new key, which is only sent back in this response
+func (a *apiKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.CreateAPIKeyRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerCreateAPIKey] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	if err := request.ValidateCreateAPIKey(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateAPIKey] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	principal, err := authorizeAPIKeyManagement(ctx)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateAPIKey] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	userID := principal.UserID
+	if req.UserID != nil {
+		userID = *req.UserID
+	}
+
+	logger = logger.With(zap.Int("user_id", userID))
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateAPIKey] failed to generate api key", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	created, err := a.repo.CreateAPIKey(ctx.Request.Context(), req.ToAPIKey(userID, prefix, hash))
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateAPIKey] failed to create api key", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"api_key": &response.CreatedAPIKeyResponse{
+			Key:            key,
+			APIKeyResponse: created,
+		},
+	}
+
+	ctx.JSON(http.StatusCreated, data)
+}
+
+func (a *apiKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	if _, err := authorizeAPIKeyManagement(ctx); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetAPIKeys] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	keys, err := a.repo.GetAPIKeys(ctx.Request.Context())
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetAPIKeys] failed to get api keys", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"api_keys": keys,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (a *apiKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	keyID, err := getAPIKeyIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerRevokeAPIKey] invalid api key id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidAPIKeyID)
+		return
+	}
+
+	logger = logger.With(zap.Int("api_key_id", keyID))
+	if _, err := authorizeAPIKeyManagement(ctx); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRevokeAPIKey] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := a.repo.RevokeAPIKey(ctx.Request.Context(), keyID); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRevokeAPIKey] failed to revoke api key", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func authorizeAPIKeyManagement(ctx *gin.Context) (*auth.Principal, error) {
+	principal, err := getPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return principal, policy.Authorize(principal, policy.ActionManageAPIKeys, nil)
+}
+
+func getAPIKeyIDFromParams(ctx *gin.Context) (int, error) {
+	paramID := ctx.Param("keyId")
+	keyID, err := strconv.Atoi(paramID)
+	if err != nil {
+		return 0, err
+	}
+	return keyID, nil
+}
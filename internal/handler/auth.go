@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AuthHandler interface {
+	Register(ctx *gin.Context)
+	Login(ctx *gin.Context)
+}
+
+type authHandler struct {
+	repo   repository.UserRepository
+	tokens *auth.TokenManager
+}
+
+func NewAuthHandler(repo repository.UserRepository, tokens *auth.TokenManager) AuthHandler {
+	return &authHandler{
+		repo:   repo,
+		tokens: tokens,
+	}
+}
+
+func (a *authHandler) Register(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.RegisterUserRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerRegister] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	if err := request.ValidateRegisterUser(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRegister] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	logger = logger.With(zap.String("username", req.Username))
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRegister] failed to hash password", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	user, err := a.repo.CreateUser(ctx.Request.Context(), req.ToUser(hash))
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRegister] failed to create user", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"user": user,
+	}
+
+	ctx.JSON(http.StatusCreated, data)
+}
+
+// Login exchanges a username and password for a signed token, unknown users and wrong passwords
+// get the same answer
+func (a *authHandler) Login(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.LoginRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerLogin] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	if err := request.ValidateLogin(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerLogin] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	logger = logger.With(zap.String("username", req.Username))
+	user, err := a.repo.GetUserByUsername(ctx.Request.Context(), req.Username)
+	if err != nil && !errors.Is(err, app_err.ErrNotFound) {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerLogin] failed to get user", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	var passwordHash string
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+	if !auth.CheckPassword(passwordHash, req.Password) {
+		status := http.StatusUnauthorized
+		logger.Info("[HandlerLogin] invalid credentials", zap.Int("http_status", status))
+		AbortWithError(ctx, errInvalidCredential)
+		return
+	}
+
+	token, expiresAt, err := a.tokens.Issue(user.ID, user.Username, user.Role)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerLogin] failed to issue token", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"token": &response.TokenResponse{
+			Token:     token,
+			TokenType: "Bearer",
+			ExpiresAt: expiresAt.Format(time.RFC3339),
+		},
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
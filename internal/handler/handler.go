@@ -1,12 +1,17 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
 	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
 	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/policy"
 	"github.com/aleszilagyi/prosig-blog/internal/repository"
 	"github.com/aleszilagyi/prosig-blog/internal/request"
 	"github.com/gin-gonic/gin"
@@ -15,9 +20,25 @@ import (
 
 type BlogHandler interface {
 	CreateBlogPost(ctx *gin.Context)
+	UpdateBlogPost(ctx *gin.Context)
+	DeleteBlogPost(ctx *gin.Context)
 	AddComment(ctx *gin.Context)
+	UpdateComment(ctx *gin.Context)
+	DeleteComment(ctx *gin.Context)
 	GetPostWithComments(ctx *gin.Context)
+	GetPostBySlug(ctx *gin.Context)
+	GetComments(ctx *gin.Context)
+	GetReplies(ctx *gin.Context)
 	GetAllPostsWithCommentCount(ctx *gin.Context)
+	GetRevisions(ctx *gin.Context)
+	GetRevision(ctx *gin.Context)
+	DiffRevisions(ctx *gin.Context)
+	RestoreRevision(ctx *gin.Context)
+	GetTags(ctx *gin.Context)
+	CreateTag(ctx *gin.Context)
+	RenameTag(ctx *gin.Context)
+	MergeTags(ctx *gin.Context)
+	SearchPosts(ctx *gin.Context)
 }
 
 type blogHandler struct {
@@ -31,36 +52,39 @@ func NewBlogHandler(repo repository.BlogRepository) BlogHandler {
 }
 
 func (b *blogHandler) CreateBlogPost(ctx *gin.Context) {
-	logger := log.GetLogger()
+	logger := log.FromContext(ctx.Request.Context())
 	req := &request.CreateBlogPostRequest{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Error("[HandlerCreateBlogPost] malformed json", zap.Error(err),
 			zap.Int("http_status", http.StatusBadRequest),
 		)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "malformed json",
-		})
+		AbortWithError(ctx, errMalformedJSON)
 		return
 	}
 
 	if err := request.ValidateCreateBlogPost(req); err != nil {
-		status, msg := defineHTTPErrorStatus(err)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
+		status := AbortWithError(ctx, err)
 		logger.Error("[HandlerCreateBlogPost] invalid request input", zap.Error(err),
 			zap.Int("http_status", status),
 		)
 		return
 	}
 
-	logger = logger.With(zap.String("title", req.Title))
-	postID, err := b.repo.CreatePost(ctx.Request.Context(), req.Title, req.Content)
+	principal, err := b.authorize(ctx, policy.ActionCreatePost, nil)
 	if err != nil {
-		status, msg := defineHTTPErrorStatus(err)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateBlogPost] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	logger = logger.With(zap.String("title", req.Title), zap.Int("author_id", principal.UserID))
+	post := req.ToPost()
+	post.AuthorID = &principal.UserID
+	postID, err := b.repo.CreatePost(ctx.Request.Context(), post)
+	if err != nil {
+		status := AbortWithError(ctx, err)
 		logger.Error("[HandlerCreateBlogPost] failed to create blog post", zap.Error(err),
 			zap.Int("http_status", status),
 		)
@@ -74,16 +98,105 @@ func (b *blogHandler) CreateBlogPost(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, data)
 }
 
+// UpdateBlogPost serves both PUT (full replacement) and PATCH (partial update)
+func (b *blogHandler) UpdateBlogPost(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.UpdateBlogPostRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerUpdateBlogPost] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerUpdateBlogPost] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	validate := request.ValidateUpdateBlogPost
+	if ctx.Request.Method == http.MethodPut {
+		validate = request.ValidateReplaceBlogPost
+	}
+
+	if err := validate(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateBlogPost] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if _, err := b.authorize(ctx, policy.ActionUpdatePost, b.postOwner(postID)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateBlogPost] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.UpdatePost(ctx.Request.Context(), postID, req.ToPostChanges()); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateBlogPost] failed to update blog post", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"post_id": postID,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) DeleteBlogPost(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDeleteBlogPost] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	if _, err := b.authorize(ctx, policy.ActionDeletePost, b.postOwner(postID)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteBlogPost] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.DeletePost(ctx.Request.Context(), postID); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteBlogPost] failed to delete blog post", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 func (b *blogHandler) AddComment(ctx *gin.Context) {
-	logger := log.GetLogger()
+	logger := log.FromContext(ctx.Request.Context())
 	req := &request.AddCommentRequest{}
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		logger.Error("[HandlerCreateBlogPost] malformed json", zap.Error(err),
 			zap.Int("http_status", http.StatusBadRequest),
 		)
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "malformed json",
-		})
+		AbortWithError(ctx, errMalformedJSON)
 		return
 	}
 
@@ -93,33 +206,45 @@ func (b *blogHandler) AddComment(ctx *gin.Context) {
 		logger.Error("[HandlerAddComment] invalid post id", zap.Error(err),
 			zap.Int("http_status", status),
 		)
-		ctx.JSON(status, gin.H{
-			"error": "invalid post id",
-		})
+		AbortWithError(ctx, errInvalidPostID)
 		return
 	}
 
 	logger = logger.With(zap.Int("post_id", postID))
 	if err := request.ValidateAddComment(req); err != nil {
-		status, msg := defineHTTPErrorStatus(err)
+		status := AbortWithError(ctx, err)
 		logger.Error("[HandlerAddComment] invalid request input", zap.Error(err),
 			zap.Int("http_status", status),
 		)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
 		return
 	}
 
-	commentID, err := b.repo.AddComment(ctx.Request.Context(), postID, req.Content)
+	if req.ParentCommentID != nil {
+		logger = logger.With(zap.Int("parent_comment_id", *req.ParentCommentID))
+		if err := b.validateReply(ctx, postID, *req.ParentCommentID); err != nil {
+			status := AbortWithError(ctx, err)
+			logger.Error("[HandlerAddComment] invalid reply", zap.Error(err),
+				zap.Int("http_status", status),
+			)
+			return
+		}
+	}
+
+	principal, err := b.authorize(ctx, policy.ActionAddComment, nil)
 	if err != nil {
-		status, msg := defineHTTPErrorStatus(err)
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerAddComment] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	commentID, err := b.repo.AddComment(ctx.Request.Context(), postID, req.ParentCommentID, principal.UserID, req.Content)
+	if err != nil {
+		status := AbortWithError(ctx, err)
 		logger.Error("[HandlerAddComment] failed to create comment", zap.Error(err),
 			zap.Int("http_status", status),
 		)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
 		return
 	}
 
@@ -130,30 +255,153 @@ func (b *blogHandler) AddComment(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, data)
 }
 
+// validateReply checks that the parent comment is part of the post and that the reply does not nest too deep
+func (b *blogHandler) validateReply(ctx *gin.Context, postID, parentCommentID int) error {
+	parent, err := b.repo.GetComment(ctx.Request.Context(), postID, parentCommentID)
+	if errors.Is(err, app_err.ErrNotFound) {
+		return errors.Join(app_err.ErrInvalidInput, errors.New("parent comment not found in this post"))
+	}
+	if err != nil {
+		return err
+	}
+
+	return request.ValidateReplyDepth(parent.Depth, config.GetConfigs().BlogConfig.MaxCommentDepth)
+}
+
+func (b *blogHandler) UpdateComment(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.UpdateCommentRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerUpdateComment] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerUpdateComment] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	commentID, err := getCommentIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerUpdateComment] invalid comment id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidCommentID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID), zap.Int("comment_id", commentID))
+	if err := request.ValidateUpdateComment(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateComment] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if _, err := b.authorize(ctx, policy.ActionUpdateComment, b.commentOwner(postID, commentID)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateComment] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.UpdateComment(ctx.Request.Context(), postID, commentID, req.Content); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateComment] failed to update comment", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"comment_id": commentID,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) DeleteComment(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDeleteComment] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	commentID, err := getCommentIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDeleteComment] invalid comment id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidCommentID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID), zap.Int("comment_id", commentID))
+	if _, err := b.authorize(ctx, policy.ActionDeleteComment, b.commentOwner(postID, commentID)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteComment] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.DeleteComment(ctx.Request.Context(), postID, commentID); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteComment] failed to delete comment", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
 func (b *blogHandler) GetPostWithComments(ctx *gin.Context) {
-	logger := log.GetLogger()
+	logger := log.FromContext(ctx.Request.Context())
 	postID, err := getPostIDFromParams(ctx)
 	if err != nil {
 		status := http.StatusBadRequest
 		logger.Error("[HandlerGetPostWithComments] invalid post id", zap.Error(err),
 			zap.Int("http_status", status),
 		)
-		ctx.JSON(status, gin.H{
-			"error": "invalid post id",
-		})
+		AbortWithError(ctx, errInvalidPostID)
 		return
 	}
 
 	logger = logger.With(zap.Int("post_id", postID))
-	post, err := b.repo.GetPostWithComments(ctx.Request.Context(), postID)
+	view, err := request.ValidateCommentView(ctx.Query("view"))
 	if err != nil {
-		status, msg := defineHTTPErrorStatus(err)
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetPostWithComments] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	commentsPage := model.Page{Limit: request.EmbeddedCommentsLimit}
+	post, err := b.repo.GetPostWithComments(ctx.Request.Context(), postID, commentsPage, view)
+	if err != nil {
+		status := AbortWithError(ctx, err)
 		logger.Error("[HandlerGetPostWithComments] failed to get post with comments", zap.Error(err),
 			zap.Int("http_status", status),
 		)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
 		return
 	}
 
@@ -164,34 +412,151 @@ func (b *blogHandler) GetPostWithComments(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, data)
 }
 
-func (b *blogHandler) GetAllPostsWithCommentCount(ctx *gin.Context) {
-	logger := log.GetLogger()
-	posts, err := b.repo.GetAllPostsWithCommentCount(ctx.Request.Context())
+func (b *blogHandler) GetComments(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetComments] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	query := &request.CommentsQuery{}
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetComments] invalid query parameters", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidQuery)
+		return
+	}
+
+	page, view, err := request.ValidateCommentsQuery(query)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetComments] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	comments, nextCursor, err := b.repo.GetComments(ctx.Request.Context(), postID, page, view)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetComments] failed to get comments", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"comments":    comments,
+		"next_cursor": nullableString(nextCursor),
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// GetReplies reads the following replies of a thread, from the cursor its root carries in the tree view
+func (b *blogHandler) GetReplies(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetReplies] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	commentID, err := getCommentIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetReplies] invalid comment id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidCommentID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID), zap.Int("comment_id", commentID))
+	query := &request.PageQuery{}
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetReplies] invalid query parameters", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidQuery)
+		return
+	}
+
+	page, err := request.ValidatePageQuery(query)
 	if err != nil {
-		logger.Error("[HandlerGetAllPostsWithCommentCount] failed to get all posts", zap.Error(err))
-		status, msg := defineHTTPErrorStatus(err)
-		ctx.JSON(status, gin.H{
-			"error": msg,
-		})
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetReplies] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	replies, nextCursor, err := b.repo.GetReplies(ctx.Request.Context(), postID, commentID, page)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetReplies] failed to get replies", zap.Error(err),
+			zap.Int("http_status", status),
+		)
 		return
 	}
 
 	data := map[string]interface{}{
-		"posts": posts,
+		"replies":     replies,
+		"next_cursor": nullableString(nextCursor),
 	}
 
 	ctx.JSON(http.StatusOK, data)
 }
 
-func defineHTTPErrorStatus(err error) (httpStatus int, message string) {
-	switch {
-	case errors.Is(err, app_err.ErrInvalidInput):
-		return http.StatusBadRequest, err.Error()
-	case errors.Is(err, app_err.ErrNotFound):
-		return http.StatusNotFound, err.Error()
-	default:
-		return http.StatusInternalServerError, app_err.ErrInternalServer.Error()
+func (b *blogHandler) GetAllPostsWithCommentCount(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	query := &request.PostsQuery{}
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetAllPostsWithCommentCount] invalid query parameters", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidQuery)
+		return
+	}
+
+	page, filter, err := request.ValidatePostsQuery(query)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetAllPostsWithCommentCount] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	posts, nextCursor, err := b.repo.GetAllPostsWithCommentCount(ctx.Request.Context(), page, filter)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetAllPostsWithCommentCount] failed to get all posts", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
 	}
+
+	data := map[string]interface{}{
+		"posts":       posts,
+		"next_cursor": nullableString(nextCursor),
+	}
+
+	ctx.JSON(http.StatusOK, data)
 }
 
 func getPostIDFromParams(ctx *gin.Context) (int, error) {
@@ -202,3 +567,67 @@ func getPostIDFromParams(ctx *gin.Context) (int, error) {
 	}
 	return postID, nil
 }
+
+func getCommentIDFromParams(ctx *gin.Context) (int, error) {
+	paramID := ctx.Param("commentId")
+	commentID, err := strconv.Atoi(paramID)
+	if err != nil {
+		return 0, err
+	}
+	return commentID, nil
+}
+
+// getPrincipal returns the authenticated user, which the router guarantees on every write route
+func getPrincipal(ctx *gin.Context) (*auth.Principal, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, app_err.ErrUnauthorized
+	}
+	return principal, nil
+}
+
+// authorize consults the policy for the authenticated user and returns it when the action is allowed.
+// The owner of the resource is only looked up when the decision depends on it
+func (b *blogHandler) authorize(ctx *gin.Context, action policy.Action, owner func(context.Context) (*int, error)) (*auth.Principal, error) {
+	principal, err := getPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ownerID *int
+	if owner != nil && policy.NeedsOwner(principal, action) {
+		ownerID, err = owner(ctx.Request.Context())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return principal, policy.Authorize(principal, action, ownerID)
+}
+
+func (b *blogHandler) postOwner(postID int) func(context.Context) (*int, error) {
+	return func(ctx context.Context) (*int, error) {
+		return b.repo.GetPostAuthorID(ctx, postID)
+	}
+}
+
+func (b *blogHandler) commentOwner(postID, commentID int) func(context.Context) (*int, error) {
+	return func(ctx context.Context) (*int, error) {
+		comment, err := b.repo.GetComment(ctx, postID, commentID)
+		if err != nil {
+			return nil, err
+		}
+		if comment.Author == nil {
+			return nil, nil
+		}
+		return &comment.Author.ID, nil
+	}
+}
+
+// nullableString keeps empty values out of responses, rendering them as null
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
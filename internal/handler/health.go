@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/health"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HealthHandler holds the probes of the service, liveness only tells the process answers
+// while readiness checks what serving requests depends on
+type HealthHandler interface {
+	Liveness(ctx *gin.Context)
+	Readiness(ctx *gin.Context)
+}
+
+type healthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) HealthHandler {
+	return &healthHandler{checker: checker}
+}
+
+func (h *healthHandler) Liveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": health.StatusUp,
+	})
+}
+
+func (h *healthHandler) Readiness(ctx *gin.Context) {
+	resp, ready := h.checker.Ready(ctx.Request.Context())
+	if !ready {
+		log.FromContext(ctx.Request.Context()).Info("[HandlerReadiness] service is not ready", zap.Any("checks", resp.Checks))
+		ctx.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Unavailable answers every request while the service runs without its database, telling clients
+// when to try again
+func Unavailable(retryAfter time.Duration) gin.HandlerFunc {
+	seconds := strconv.Itoa(max(1, int(retryAfter.Round(time.Second).Seconds())))
+	return func(ctx *gin.Context) {
+		ctx.Header("Retry-After", seconds)
+		AbortWithError(ctx, app_err.ErrUnavailable)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/gin-gonic/gin"
+)
+
+// The problems of requests that cannot be read, before anything is looked up
+var (
+	errMalformedJSON     = app_err.New(app_err.ErrInvalidInput, "malformed_json", "malformed json")
+	errInvalidQuery      = app_err.New(app_err.ErrInvalidInput, "invalid_query_parameters", "invalid query parameters")
+	errInvalidPostID     = app_err.New(app_err.ErrInvalidInput, "invalid_post_id", "invalid post id")
+	errInvalidCommentID  = app_err.New(app_err.ErrInvalidInput, "invalid_comment_id", "invalid comment id")
+	errInvalidRevision   = app_err.New(app_err.ErrInvalidInput, "invalid_revision", "invalid revision")
+	errInvalidTagID      = app_err.New(app_err.ErrInvalidInput, "invalid_tag_id", "invalid tag id")
+	errInvalidUserID     = app_err.New(app_err.ErrInvalidInput, "invalid_user_id", "invalid user id")
+	errInvalidAPIKeyID   = app_err.New(app_err.ErrInvalidInput, "invalid_api_key_id", "invalid api key id")
+	errInvalidCredential = app_err.New(app_err.ErrUnauthorized, "invalid_credentials", "invalid username or password")
+	errRouteNotFound     = app_err.New(app_err.ErrNotFound, "route_not_found", "no route matches the request")
+)
+
+// AbortWithError stops the request with the error, which the router renders as a problem, and returns
+// the HTTP status the client gets
+func AbortWithError(ctx *gin.Context, err error) int {
+	ctx.Error(err)
+	ctx.Abort()
+	return app_err.From(err).Status
+}
+
+// RouteNotFound answers the requests no route matches
+func RouteNotFound(ctx *gin.Context) {
+	AbortWithError(ctx, errRouteNotFound)
+}
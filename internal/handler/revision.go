@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/policy"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (b *blogHandler) GetRevisions(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetRevisions] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	includeUnpublished, err := b.canReadUnpublished(ctx, postID)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetRevisions] failed to check permissions", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	revisions, err := b.repo.GetRevisions(ctx.Request.Context(), postID, includeUnpublished)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetRevisions] failed to get revisions", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"revisions": revisions,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) GetRevision(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetRevision] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	revisionNumber, err := getRevisionFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerGetRevision] invalid revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidRevision)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID), zap.Int("revision", revisionNumber))
+	includeUnpublished, err := b.canReadUnpublished(ctx, postID)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetRevision] failed to check permissions", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	revision, err := b.repo.GetRevision(ctx.Request.Context(), postID, revisionNumber, includeUnpublished)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetRevision] failed to get revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"revision": revision,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) DiffRevisions(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDiffRevisions] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	query := &request.RevisionDiffQuery{}
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDiffRevisions] invalid query parameters", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidQuery)
+		return
+	}
+
+	if err := request.ValidateRevisionDiffQuery(query); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDiffRevisions] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	logger = logger.With(zap.Int("from_revision", query.From), zap.Int("to_revision", query.To))
+	includeUnpublished, err := b.canReadUnpublished(ctx, postID)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDiffRevisions] failed to check permissions", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	from, err := b.repo.GetRevision(ctx.Request.Context(), postID, query.From, includeUnpublished)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDiffRevisions] failed to get the from revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	to, err := b.repo.GetRevision(ctx.Request.Context(), postID, query.To, includeUnpublished)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDiffRevisions] failed to get the to revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"diff": model.ToRevisionDiff(from, to),
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) RestoreRevision(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postID, err := getPostIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerRestoreRevision] invalid post id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidPostID)
+		return
+	}
+
+	revisionNumber, err := getRevisionFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerRestoreRevision] invalid revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidRevision)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID), zap.Int("revision", revisionNumber))
+	if _, err := b.authorize(ctx, policy.ActionUpdatePost, b.postOwner(postID)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRestoreRevision] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	latest, err := b.repo.RestoreRevision(ctx.Request.Context(), postID, revisionNumber)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRestoreRevision] failed to restore revision", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"post_id":  postID,
+		"revision": latest,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// canReadUnpublished tells whether the caller may read the revisions of the post while it is not published,
+// which the users allowed to update it may, and their API keys with the read scope. Anonymous callers and
+// the others only see published posts
+func (b *blogHandler) canReadUnpublished(ctx *gin.Context, postID int) (bool, error) {
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		return false, nil
+	}
+
+	_, err := b.authorize(ctx, policy.ActionReadUnpublished, b.postOwner(postID))
+	if errors.Is(err, app_err.ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func getRevisionFromParams(ctx *gin.Context) (int, error) {
+	paramRevision := ctx.Param("rev")
+	revision, err := strconv.Atoi(paramRevision)
+	if err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SearchPosts serves a page of the published posts matching the q parameter, best match first
+func (b *blogHandler) SearchPosts(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	query := &request.SearchQuery{}
+	if err := ctx.ShouldBindQuery(query); err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerSearchPosts] invalid query parameters", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidQuery)
+		return
+	}
+
+	terms, page, err := request.ValidateSearchQuery(query)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerSearchPosts] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	results, nextCursor, err := b.repo.SearchPosts(ctx.Request.Context(), terms, page)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerSearchPosts] failed to search posts", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"results":     results,
+		"next_cursor": nullableString(nextCursor),
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
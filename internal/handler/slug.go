@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GetPostBySlug serves the post like GetPostWithComments, old slugs are permanently redirected to the current one
+func (b *blogHandler) GetPostBySlug(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	postSlug := ctx.Param("slug")
+	logger = logger.With(zap.String("slug", postSlug))
+
+	view, err := request.ValidateCommentView(ctx.Query("view"))
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetPostBySlug] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	postID, currentSlug, err := b.repo.ResolveSlug(ctx.Request.Context(), postSlug)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetPostBySlug] failed to resolve slug", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if currentSlug != postSlug {
+		location := "/api/posts/by-slug/" + currentSlug
+		if ctx.Request.URL.RawQuery != "" {
+			location += "?" + ctx.Request.URL.RawQuery
+		}
+		logger.Info("[HandlerGetPostBySlug] redirecting to the current slug", zap.String("current_slug", currentSlug))
+		ctx.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	logger = logger.With(zap.Int("post_id", postID))
+	commentsPage := model.Page{Limit: request.EmbeddedCommentsLimit}
+	post, err := b.repo.GetPostWithComments(ctx.Request.Context(), postID, commentsPage, view)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetPostBySlug] failed to get post with comments", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"post": post,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/policy"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (b *blogHandler) GetTags(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	tags, err := b.repo.GetTags(ctx.Request.Context())
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetTags] failed to get tags", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"tags": tags,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (b *blogHandler) CreateTag(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.TagRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerCreateTag] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	if err := request.ValidateTag(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateTag] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if _, err := b.authorize(ctx, policy.ActionManageTags, nil); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateTag] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	name := model.NormalizeTagName(req.Name)
+	logger = logger.With(zap.String("tag_name", name))
+	tagID, err := b.repo.CreateTag(ctx.Request.Context(), name)
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerCreateTag] failed to create tag", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"tag_id": tagID,
+	}
+
+	ctx.JSON(http.StatusCreated, data)
+}
+
+func (b *blogHandler) RenameTag(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.TagRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerRenameTag] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	tagID, err := getTagIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerRenameTag] invalid tag id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidTagID)
+		return
+	}
+
+	logger = logger.With(zap.Int("tag_id", tagID))
+	if err := request.ValidateTag(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRenameTag] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if _, err := b.authorize(ctx, policy.ActionManageTags, nil); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRenameTag] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.RenameTag(ctx.Request.Context(), tagID, model.NormalizeTagName(req.Name)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerRenameTag] failed to rename tag", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"tag_id": tagID,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// MergeTags folds the tag of the path into the tag given in the body, which is kept
+func (b *blogHandler) MergeTags(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.MergeTagRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerMergeTags] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	tagID, err := getTagIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerMergeTags] invalid tag id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidTagID)
+		return
+	}
+
+	logger = logger.With(zap.Int("tag_id", tagID), zap.Int("into_tag_id", req.IntoTagID))
+	if err := request.ValidateMergeTag(tagID, req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerMergeTags] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if _, err := b.authorize(ctx, policy.ActionManageTags, nil); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerMergeTags] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := b.repo.MergeTags(ctx.Request.Context(), tagID, req.IntoTagID); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerMergeTags] failed to merge tags", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"tag_id": req.IntoTagID,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func getTagIDFromParams(ctx *gin.Context) (int, error) {
+	paramID := ctx.Param("tagId")
+	tagID, err := strconv.Atoi(paramID)
+	if err != nil {
+		return 0, err
+	}
+	return tagID, nil
+}
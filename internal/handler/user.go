@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/policy"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/request"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UserHandler holds the admin endpoints to manage the registered users
+type UserHandler interface {
+	GetUsers(ctx *gin.Context)
+	UpdateUserRole(ctx *gin.Context)
+	DeleteUser(ctx *gin.Context)
+}
+
+type userHandler struct {
+	repo repository.UserRepository
+}
+
+func NewUserHandler(repo repository.UserRepository) UserHandler {
+	return &userHandler{repo: repo}
+}
+
+func (u *userHandler) GetUsers(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	if _, err := authorizeUserManagement(ctx); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetUsers] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	users, err := u.repo.GetUsers(ctx.Request.Context())
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerGetUsers] failed to get users", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"users": users,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+// UpdateUserRole changes the role of a user, it applies from the next time the user logs in
+func (u *userHandler) UpdateUserRole(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	req := &request.UpdateUserRoleRequest{}
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		logger.Error("[HandlerUpdateUserRole] malformed json", zap.Error(err),
+			zap.Int("http_status", http.StatusBadRequest),
+		)
+		AbortWithError(ctx, errMalformedJSON)
+		return
+	}
+
+	userID, err := getUserIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerUpdateUserRole] invalid user id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidUserID)
+		return
+	}
+
+	logger = logger.With(zap.Int("user_id", userID))
+	if err := request.ValidateUpdateUserRole(req); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateUserRole] invalid request input", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	principal, err := authorizeUserManagement(ctx)
+	if err == nil && principal.UserID == userID {
+		err = errors.Join(app_err.ErrInvalidInput, errors.New("admins cannot change their own role"))
+	}
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateUserRole] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := u.repo.UpdateUserRole(ctx.Request.Context(), userID, model.Role(req.Role)); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerUpdateUserRole] failed to update user role", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	data := map[string]interface{}{
+		"user_id": userID,
+		"role":    req.Role,
+	}
+
+	ctx.JSON(http.StatusOK, data)
+}
+
+func (u *userHandler) DeleteUser(ctx *gin.Context) {
+	logger := log.FromContext(ctx.Request.Context())
+	userID, err := getUserIDFromParams(ctx)
+	if err != nil {
+		status := http.StatusBadRequest
+		logger.Error("[HandlerDeleteUser] invalid user id", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		AbortWithError(ctx, errInvalidUserID)
+		return
+	}
+
+	logger = logger.With(zap.Int("user_id", userID))
+	principal, err := authorizeUserManagement(ctx)
+	if err == nil && principal.UserID == userID {
+		err = errors.Join(app_err.ErrInvalidInput, errors.New("admins cannot delete themselves"))
+	}
+	if err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteUser] not allowed", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	if err := u.repo.DeleteUser(ctx.Request.Context(), userID); err != nil {
+		status := AbortWithError(ctx, err)
+		logger.Error("[HandlerDeleteUser] failed to delete user", zap.Error(err),
+			zap.Int("http_status", status),
+		)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func authorizeUserManagement(ctx *gin.Context) (*auth.Principal, error) {
+	principal, err := getPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return principal, policy.Authorize(principal, policy.ActionManageUsers, nil)
+}
+
+func getUserIDFromParams(ctx *gin.Context) (int, error) {
+	paramID := ctx.Param("userId")
+	userID, err := strconv.Atoi(paramID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
@@ -0,0 +1,128 @@
+// Package health tells whether the service is ready to serve, checking the dependencies it needs
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/aleszilagyi/prosig-blog/internal/storage/migrations"
+	"go.uber.org/zap"
+)
+
+// The statuses of the checks, and of readiness as a whole
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultPingTimeout bounds how long readiness waits on the database
+const DefaultPingTimeout = 2 * time.Second
+
+// The readiness body is public, the causes of the failed checks are only logged
+var (
+	errShuttingDown        = errors.New("the service is shutting down")
+	errDatabaseUnreachable = errors.New("unreachable")
+	errMigrationsUnknown   = errors.New("the applied migrations could not be read")
+)
+
+// Checker runs the readiness checks. The database is nil for the storages that have none, whose checks then
+// always pass
+type Checker struct {
+	db           *sql.DB
+	driver       string
+	pingTimeout  time.Duration
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(db *sql.DB, driver string) *Checker {
+	return &Checker{db: db, driver: driver, pingTimeout: DefaultPingTimeout}
+}
+
+// ShuttingDown makes readiness fail from now on, so no new traffic is sent while the service stops
+func (c *Checker) ShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Ready runs every check and tells whether they all passed
+func (c *Checker) Ready(ctx context.Context) (*response.ReadinessResponse, bool) {
+	checks := map[string]*response.CheckResponse{
+		"shutdown":   c.checkShutdown(),
+		"database":   c.checkDatabase(ctx),
+		"migrations": c.checkMigrations(ctx),
+	}
+
+	resp := &response.ReadinessResponse{Status: StatusUp, Checks: checks}
+	for _, check := range checks {
+		if check.Status != StatusUp {
+			resp.Status = StatusDown
+		}
+	}
+
+	return resp, resp.Status == StatusUp
+}
+
+func (c *Checker) checkShutdown() *response.CheckResponse {
+	if c.shuttingDown.Load() {
+		return down(errShuttingDown, nil)
+	}
+	return &response.CheckResponse{Status: StatusUp}
+}
+
+// checkDatabase pings the database and reports the figures of its connection pool
+func (c *Checker) checkDatabase(ctx context.Context) *response.CheckResponse {
+	if c.db == nil {
+		return &response.CheckResponse{Status: StatusUp, Details: map[string]any{"driver": c.driver}}
+	}
+
+	stats := c.db.Stats()
+	details := map[string]any{
+		"driver":               c.driver,
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.pingTimeout)
+	defer cancel()
+
+	if err := c.db.PingContext(ctx); err != nil {
+		log.FromContext(ctx).Error("[HealthCheckDatabase] failed to ping the database", zap.Error(err))
+		return down(errDatabaseUnreachable, details)
+	}
+	return &response.CheckResponse{Status: StatusUp, Details: details}
+}
+
+// checkMigrations fails while the schema is behind the migrations the binary embeds
+func (c *Checker) checkMigrations(ctx context.Context) *response.CheckResponse {
+	if c.db == nil {
+		return &response.CheckResponse{Status: StatusUp}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.pingTimeout)
+	defer cancel()
+
+	pending, err := migrations.Pending(ctx, c.db, c.driver)
+	if err != nil {
+		log.FromContext(ctx).Error("[HealthCheckMigrations] failed to read the applied migrations", zap.Error(err))
+		return down(errMigrationsUnknown, nil)
+	}
+
+	details := map[string]any{"pending": pending}
+	if pending > 0 {
+		return down(fmt.Errorf("%d migrations are pending", pending), details)
+	}
+	return &response.CheckResponse{Status: StatusUp, Details: details}
+}
+
+func down(err error, details map[string]any) *response.CheckResponse {
+	return &response.CheckResponse{Status: StatusDown, Error: err.Error(), Details: details}
+}
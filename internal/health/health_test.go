@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/storage/migrations"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "blog.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestChecker_Ready(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	_, err := migrations.Up(ctx, db, "sqlite")
+	assert.NoError(t, err)
+
+	resp, ready := NewChecker(db, "sqlite").Ready(ctx)
+
+	assert.True(t, ready)
+	assert.Equal(t, StatusUp, resp.Status)
+	assert.Equal(t, StatusUp, resp.Checks["database"].Status)
+	assert.Equal(t, "sqlite", resp.Checks["database"].Details["driver"])
+	assert.Contains(t, resp.Checks["database"].Details, "open_connections")
+	assert.Equal(t, 0, resp.Checks["migrations"].Details["pending"])
+}
+
+func TestChecker_PendingMigrations(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	_, err := migrations.Up(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	_, err = migrations.Down(ctx, db, "sqlite", 1)
+	assert.NoError(t, err)
+
+	resp, ready := NewChecker(db, "sqlite").Ready(ctx)
+
+	assert.False(t, ready)
+	assert.Equal(t, StatusDown, resp.Status)
+	assert.Equal(t, StatusUp, resp.Checks["database"].Status)
+	assert.Equal(t, StatusDown, resp.Checks["migrations"].Status)
+	assert.Equal(t, 1, resp.Checks["migrations"].Details["pending"])
+}
+
+func TestChecker_ClosedDatabase(t *testing.T) {
+	db := openSQLite(t)
+	db.Close()
+
+	core, logs := observer.New(zap.ErrorLevel)
+	ctx := log.WithContext(context.Background(), zap.New(core))
+	resp, ready := NewChecker(db, "sqlite").Ready(ctx)
+
+	assert.False(t, ready)
+	assert.Equal(t, StatusDown, resp.Checks["database"].Status)
+	assert.Equal(t, "unreachable", resp.Checks["database"].Error, "the cause should not be disclosed")
+	assert.Equal(t, "the applied migrations could not be read", resp.Checks["migrations"].Error)
+
+	failures := logs.FilterMessage("[HealthCheckDatabase] failed to ping the database").All()
+	if assert.Len(t, failures, 1) {
+		assert.Contains(t, failures[0].ContextMap()["error"], "closed")
+	}
+}
+
+func TestChecker_ShuttingDown(t *testing.T) {
+	checker := NewChecker(nil, "memory")
+	_, ready := checker.Ready(context.Background())
+	assert.True(t, ready)
+
+	checker.ShuttingDown()
+
+	resp, ready := checker.Ready(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusDown, resp.Checks["shutdown"].Status)
+	assert.Equal(t, StatusUp, resp.Checks["database"].Status)
+}
@@ -1,15 +1,45 @@
 package logger
 
 import (
+	"context"
 	"strings"
+	"sync"
 
 	"github.com/aleszilagyi/prosig-blog/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-// GetLogger initializes a zap logger using configs
+var (
+	once   sync.Once
+	logger *zap.Logger
+)
+
+type contextKey struct{}
+
+// GetLogger returns the service logger, built from the configs on the first call
 func GetLogger() *zap.Logger {
+	once.Do(func() {
+		logger = build()
+	})
+	return logger
+}
+
+// WithContext stores the logger in the context, for the code handling the request to log with
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored in the context, carrying the fields of the request,
+// or the service logger when there is none
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return GetLogger()
+}
+
+func build() *zap.Logger {
 	cfg := config.GetConfigs().LoggerConfig
 	var zapCfg zap.Config
 	if cfg.Development {
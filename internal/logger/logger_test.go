@@ -1,12 +1,22 @@
 package logger
 
 import (
+	"context"
+	"os"
 	"testing"
 
+	"github.com/aleszilagyi/prosig-blog/config"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
 func TestParseLevel(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -26,3 +36,15 @@ func TestParseLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLogger_BuiltOnce(t *testing.T) {
+	assert.Same(t, GetLogger(), GetLogger())
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, GetLogger(), FromContext(context.Background()), "the service logger should be used outside of requests")
+
+	requestLogger := GetLogger().With(zap.String("request_id", "abc"))
+	ctx := WithContext(context.Background(), requestLogger)
+	assert.Same(t, requestLogger, FromContext(ctx))
+}
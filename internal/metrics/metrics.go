@@ -0,0 +1,109 @@
+// Package metrics exposes the Prometheus metrics of the service, its HTTP traffic, its database pool,
+// the time spent in the repositories and what the blog creates
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// unmatchedRoute labels the requests no route matched, so unknown paths cannot grow the label values
+	unmatchedRoute = "unmatched"
+	// otherMethod labels the requests made with a method outside of the standard ones, for the same reason
+	otherMethod = "other"
+)
+
+// standardMethods are the HTTP methods requests are labelled with as they are
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Registry holds every metric of the service along with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests handled, by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method, route template and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	repositoryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "repository_operation_duration_seconds",
+		Help:    "Time taken by the repository operations, by repository and operation.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "operation"})
+
+	postsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Name: "blog_posts_created_total",
+		Help: "Posts created.",
+	})
+
+	commentsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Name: "blog_comments_created_total",
+		Help: "Comments and replies created.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware counts and times every request, labelled by the route template rather than the path
+// so that ids do not make a series each, and by its method unless it is not a standard one
+func Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := ctx.Request.Method
+		if !standardMethods[method] {
+			method = otherMethod
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+
+		httpRequests.WithLabelValues(method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDBStats exports the statistics of the connection pool as gauges, labelled with the driver
+func RegisterDBStats(db *sql.DB, driver string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, driver))
+}
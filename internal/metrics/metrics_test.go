@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestMiddleware_RouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/posts/:id", func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/posts/:id", "200"))
+	beforeUnmatched := testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404"))
+	for _, target := range []string{"/posts/1", "/posts/2", "/nowhere"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, "/posts/:id", "200")))
+	assert.Equal(t, beforeUnmatched+1, testutil.ToFloat64(httpRequests.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+	assert.Equal(t, 0, testutil.CollectAndCount(httpRequests, "http_requests_total")-testutil.CollectAndCount(httpRequestDuration, "http_request_duration_seconds"),
+		"every counted request should be timed")
+}
+
+func TestMiddleware_NonStandardMethod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+
+	before := testutil.ToFloat64(httpRequests.WithLabelValues(otherMethod, unmatchedRoute, "404"))
+	series := testutil.CollectAndCount(httpRequests, "http_requests_total")
+	for _, method := range []string{"FOO", "BAR1", "PROPFIND"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/nowhere", nil))
+	}
+
+	assert.Equal(t, before+3, testutil.ToFloat64(httpRequests.WithLabelValues(otherMethod, unmatchedRoute, "404")))
+	assert.Equal(t, series, testutil.CollectAndCount(httpRequests, "http_requests_total"), "unknown methods should not make a series each")
+}
+
+func TestInstrumentBlogRepository(t *testing.T) {
+	ctx := context.Background()
+	blog := InstrumentBlogRepository(memory.NewBlogRepository(memory.NewStore()))
+	postsBefore := testutil.ToFloat64(postsCreated)
+	commentsBefore := testutil.ToFloat64(commentsCreated)
+
+	postID, err := blog.CreatePost(ctx, &model.Post{Title: "Metrics", Content: "Counted", Status: model.PostStatusPublished})
+	assert.NoError(t, err)
+	_, err = blog.AddComment(ctx, postID, nil, 1, "Counted too")
+	assert.NoError(t, err)
+	_, err = blog.AddComment(ctx, postID+1, nil, 1, "Not counted")
+	assert.Error(t, err)
+
+	assert.Equal(t, postsBefore+1, testutil.ToFloat64(postsCreated))
+	assert.Equal(t, commentsBefore+1, testutil.ToFloat64(commentsCreated), "failed comments should not be counted")
+}
+
+func TestHandler_Exposition(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "blog.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, RegisterDBStats(db, "sqlite"))
+	_, err = InstrumentUserRepository(memory.NewUserRepository(memory.NewStore())).GetUsers(context.Background())
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `go_sql_open_connections{db_name="sqlite"}`)
+	assert.Contains(t, w.Body.String(), `repository_operation_duration_seconds_count{operation="GetUsers",repository="users"} 1`)
+	assert.Contains(t, w.Body.String(), "blog_posts_created_total")
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// observe records how long the operation took, it is deferred at the start of the operation
+func observe(repo, operation string, start time.Time) {
+	repositoryDuration.WithLabelValues(repo, operation).Observe(time.Since(start).Seconds())
+}
+
+type blogRepository struct {
+	next repository.BlogRepository
+}
+
+// InstrumentBlogRepository times every operation of the repository and counts the posts and comments created
+func InstrumentBlogRepository(next repository.BlogRepository) repository.BlogRepository {
+	return &blogRepository{next: next}
+}
+
+func (r *blogRepository) GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) ([]*response.PostWithCommentCountResponse, string, error) {
+	defer observe("blog", "GetAllPostsWithCommentCount", time.Now())
+	return r.next.GetAllPostsWithCommentCount(ctx, page, filter)
+}
+
+func (r *blogRepository) GetPostWithComments(ctx context.Context, id int, comments model.Page, view model.CommentView) (*response.PostWithCommentsResponse, error) {
+	defer observe("blog", "GetPostWithComments", time.Now())
+	return r.next.GetPostWithComments(ctx, id, comments, view)
+}
+
+func (r *blogRepository) GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, string, error) {
+	defer observe("blog", "GetComments", time.Now())
+	return r.next.GetComments(ctx, blogPostID, page, view)
+}
+
+func (r *blogRepository) GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) ([]*response.CommentResponse, string, error) {
+	defer observe("blog", "GetReplies", time.Now())
+	return r.next.GetReplies(ctx, blogPostID, rootCommentID, page)
+}
+
+func (r *blogRepository) GetComment(ctx context.Context, blogPostID, commentID int) (*response.CommentResponse, error) {
+	defer observe("blog", "GetComment", time.Now())
+	return r.next.GetComment(ctx, blogPostID, commentID)
+}
+
+func (r *blogRepository) CreatePost(ctx context.Context, post *model.Post) (int, error) {
+	defer observe("blog", "CreatePost", time.Now())
+	id, err := r.next.CreatePost(ctx, post)
+	if err == nil {
+		postsCreated.Inc()
+	}
+	return id, err
+}
+
+func (r *blogRepository) UpdatePost(ctx context.Context, id int, changes model.PostChanges) error {
+	defer observe("blog", "UpdatePost", time.Now())
+	return r.next.UpdatePost(ctx, id, changes)
+}
+
+func (r *blogRepository) DeletePost(ctx context.Context, id int) error {
+	defer observe("blog", "DeletePost", time.Now())
+	return r.next.DeletePost(ctx, id)
+}
+
+func (r *blogRepository) GetPostAuthorID(ctx context.Context, id int) (*int, error) {
+	defer observe("blog", "GetPostAuthorID", time.Now())
+	return r.next.GetPostAuthorID(ctx, id)
+}
+
+func (r *blogRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	defer observe("blog", "PublishScheduledPosts", time.Now())
+	return r.next.PublishScheduledPosts(ctx, now)
+}
+
+func (r *blogRepository) GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) ([]*response.RevisionResponse, error) {
+	defer observe("blog", "GetRevisions", time.Now())
+	return r.next.GetRevisions(ctx, blogPostID, includeUnpublished)
+}
+
+func (r *blogRepository) GetRevision(ctx context.Context, blogPostID, revision int, includeUnpublished bool) (*response.RevisionResponse, error) {
+	defer observe("blog", "GetRevision", time.Now())
+	return r.next.GetRevision(ctx, blogPostID, revision, includeUnpublished)
+}
+
+func (r *blogRepository) RestoreRevision(ctx context.Context, blogPostID, revision int) (int, error) {
+	defer observe("blog", "RestoreRevision", time.Now())
+	return r.next.RestoreRevision(ctx, blogPostID, revision)
+}
+
+func (r *blogRepository) AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (int, error) {
+	defer observe("blog", "AddComment", time.Now())
+	id, err := r.next.AddComment(ctx, blogPostID, parentCommentID, authorID, content)
+	if err == nil {
+		commentsCreated.Inc()
+	}
+	return id, err
+}
+
+func (r *blogRepository) UpdateComment(ctx context.Context, blogPostID, commentID int, content string) error {
+	defer observe("blog", "UpdateComment", time.Now())
+	return r.next.UpdateComment(ctx, blogPostID, commentID, content)
+}
+
+func (r *blogRepository) DeleteComment(ctx context.Context, blogPostID, commentID int) error {
+	defer observe("blog", "DeleteComment", time.Now())
+	return r.next.DeleteComment(ctx, blogPostID, commentID)
+}
+
+func (r *blogRepository) ResolveSlug(ctx context.Context, slug string) (int, string, error) {
+	defer observe("blog", "ResolveSlug", time.Now())
+	return r.next.ResolveSlug(ctx, slug)
+}
+
+func (r *blogRepository) GetTags(ctx context.Context) ([]*response.TagResponse, error) {
+	defer observe("blog", "GetTags", time.Now())
+	return r.next.GetTags(ctx)
+}
+
+func (r *blogRepository) CreateTag(ctx context.Context, name string) (int, error) {
+	defer observe("blog", "CreateTag", time.Now())
+	return r.next.CreateTag(ctx, name)
+}
+
+func (r *blogRepository) RenameTag(ctx context.Context, id int, name string) error {
+	defer observe("blog", "RenameTag", time.Now())
+	return r.next.RenameTag(ctx, id, name)
+}
+
+func (r *blogRepository) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	defer observe("blog", "MergeTags", time.Now())
+	return r.next.MergeTags(ctx, sourceID, targetID)
+}
+
+func (r *blogRepository) SearchPosts(ctx context.Context, terms string, page model.SearchPage) ([]*response.SearchResultResponse, string, error) {
+	defer observe("blog", "SearchPosts", time.Now())
+	return r.next.SearchPosts(ctx, terms, page)
+}
+
+type userRepository struct {
+	next repository.UserRepository
+}
+
+// InstrumentUserRepository times every operation of the repository
+func InstrumentUserRepository(next repository.UserRepository) repository.UserRepository {
+	return &userRepository{next: next}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (*response.UserResponse, error) {
+	defer observe("users", "CreateUser", time.Now())
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	defer observe("users", "GetUserByUsername", time.Now())
+	return r.next.GetUserByUsername(ctx, username)
+}
+
+func (r *userRepository) GetUser(ctx context.Context, id int) (*model.User, error) {
+	defer observe("users", "GetUser", time.Now())
+	return r.next.GetUser(ctx, id)
+}
+
+func (r *userRepository) GetUsers(ctx context.Context) ([]*response.UserResponse, error) {
+	defer observe("users", "GetUsers", time.Now())
+	return r.next.GetUsers(ctx)
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id int, role model.Role) error {
+	defer observe("users", "UpdateUserRole", time.Now())
+	return r.next.UpdateUserRole(ctx, id, role)
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
+	defer observe("users", "DeleteUser", time.Now())
+	return r.next.DeleteUser(ctx, id)
+}
+
+type apiKeyRepository struct {
+	next repository.APIKeyRepository
+}
+
+// InstrumentAPIKeyRepository times every operation of the repository
+func InstrumentAPIKeyRepository(next repository.APIKeyRepository) repository.APIKeyRepository {
+	return &apiKeyRepository{next: next}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (*response.APIKeyResponse, error) {
+	defer observe("api_keys", "CreateAPIKey", time.Now())
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r *apiKeyRepository) GetAPIKeys(ctx context.Context) ([]*response.APIKeyResponse, error) {
+	defer observe("api_keys", "GetAPIKeys", time.Now())
+	return r.next.GetAPIKeys(ctx)
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	defer observe("api_keys", "RevokeAPIKey", time.Now())
+	return r.next.RevokeAPIKey(ctx, id)
+}
+
+func (r *apiKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string, now time.Time) (*model.APIKey, error) {
+	defer observe("api_keys", "AuthenticateAPIKey", time.Now())
+	return r.next.AuthenticateAPIKey(ctx, keyHash, now)
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// Scope limits what an API key may do, on top of what the role of the user owning it allows
+type Scope string
+
+const (
+	ScopePostsWrite       Scope = "posts:write"
+	ScopeCommentsModerate Scope = "comments:moderate"
+	ScopeRead             Scope = "read"
+)
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopePostsWrite, ScopeCommentsModerate, ScopeRead:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey lets a machine client act on behalf of a user, only the hash of the key itself is ever stored
+type APIKey struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []Scope
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	User       *User
+}
+
+func (k *APIKey) ToAPIKeyResponse() *response.APIKeyResponse {
+	scopes := make([]string, len(k.Scopes))
+	for idx, scope := range k.Scopes {
+		scopes[idx] = string(scope)
+	}
+	return &response.APIKeyResponse{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		RevokedAt:  formatOptionalTime(k.RevokedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// JoinScopes and SplitScopes convert scopes to and from the comma separated list they are stored as
+func JoinScopes(scopes []Scope) string {
+	values := make([]string, len(scopes))
+	for idx, scope := range scopes {
+		values[idx] = string(scope)
+	}
+	return strings.Join(values, ",")
+}
+
+func SplitScopes(value string) []Scope {
+	scopes := []Scope{}
+	for _, scope := range strings.Split(value, ",") {
+		if scope != "" {
+			scopes = append(scopes, Scope(scope))
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes_RoundTrip(t *testing.T) {
+	scopes := []Scope{ScopePostsWrite, ScopeRead}
+
+	assert.Equal(t, "posts:write,read", JoinScopes(scopes))
+	assert.Equal(t, scopes, SplitScopes(JoinScopes(scopes)))
+	assert.Equal(t, []Scope{}, SplitScopes(""))
+}
+
+func TestAPIKey_ToAPIKeyResponse(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	lastUsedAt := createdAt.Add(time.Hour)
+	key := &APIKey{
+		ID:         1,
+		UserID:     2,
+		Name:       "ci",
+		Prefix:     "pbk_abcdefgh",
+		KeyHash:    "secret hash",
+		Scopes:     []Scope{ScopePostsWrite},
+		LastUsedAt: &lastUsedAt,
+		CreatedAt:  createdAt,
+	}
+
+	resp := key.ToAPIKeyResponse()
+
+	assert.Equal(t, []string{"posts:write"}, resp.Scopes)
+	assert.Equal(t, "2025-03-01T13:00:00Z", *resp.LastUsedAt)
+	assert.Nil(t, resp.ExpiresAt)
+	assert.Nil(t, resp.RevokedAt)
+	assert.Equal(t, "2025-03-01T12:00:00Z", resp.CreatedAt)
+}
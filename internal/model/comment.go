@@ -1,17 +1,34 @@
 package model
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/aleszilagyi/prosig-blog/internal/response"
 )
 
+// CommentView is how comments are laid out in a response
+type CommentView string
+
+const (
+	// CommentViewFlat lists every comment, newest first, with its parent and depth
+	CommentViewFlat CommentView = "flat"
+	// CommentViewTree lists root comments, newest first, with their replies nested in chronological order
+	CommentViewTree CommentView = "tree"
+)
+
 type Comment struct {
 	ID        int
 	PostID    int
+	ParentID  *int
+	RootID    *int
+	Depth     int
+	Author    *Author
 	Content   string
 	CreatedAt time.Time
+	UpdatedAt *time.Time
 }
 
 func (c *Comment) String() string {
@@ -26,8 +43,92 @@ func (c *Comment) String() string {
 
 func (c *Comment) ToCommentResponse() *response.CommentResponse {
 	return &response.CommentResponse{
-		ID:        c.ID,
-		Content:   c.Content,
-		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		ID:              c.ID,
+		ParentCommentID: c.ParentID,
+		Depth:           c.Depth,
+		Author:          c.Author.ToAuthorResponse(),
+		Content:         c.Content,
+		CreatedAt:       c.CreatedAt.Format(time.RFC3339),
+		EditedAt:        formatOptionalTime(c.UpdatedAt),
+	}
+}
+
+// ToCommentTree nests the replies under their parents. Roots keep the given order, replies are sorted
+// chronologically and the ones whose parent is not part of the tree are dropped
+func ToCommentTree(roots []Comment, replies []Comment) []*response.CommentResponse {
+	nodes := make(map[int]*response.CommentResponse, len(roots)+len(replies))
+	tree := make([]*response.CommentResponse, 0, len(roots))
+	for _, root := range roots {
+		node := root.ToCommentResponse()
+		nodes[root.ID] = node
+		tree = append(tree, node)
 	}
+
+	// Shallower replies go first so every parent is already in the tree when its replies are attached
+	sorted := slices.Clone(replies)
+	slices.SortStableFunc(sorted, func(a, b Comment) int {
+		return cmp.Or(
+			cmp.Compare(a.Depth, b.Depth),
+			a.CreatedAt.Compare(b.CreatedAt),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	for _, reply := range sorted {
+		if reply.ParentID == nil {
+			continue
+		}
+		parent, ok := nodes[*reply.ParentID]
+		if !ok {
+			continue
+		}
+		node := reply.ToCommentResponse()
+		parent.Replies = append(parent.Replies, node)
+		nodes[reply.ID] = node
+	}
+
+	return tree
+}
+
+// ToCommentThreads nests at most limit replies under each root, the oldest ones which always include the
+// parents of the others, and hands the roots with more replies the cursor to read the following ones
+func ToCommentThreads(roots []Comment, replies []Comment, limit int) []*response.CommentResponse {
+	sorted := slices.Clone(replies)
+	slices.SortStableFunc(sorted, oldestFirst)
+
+	counts := make(map[int]int, len(roots))
+	lasts := make(map[int]Comment, len(roots))
+	cursors := make(map[int]*Cursor)
+	kept := make([]Comment, 0, len(sorted))
+	for _, reply := range sorted {
+		if reply.RootID == nil {
+			continue
+		}
+		rootID := *reply.RootID
+		if counts[rootID] == limit {
+			if _, ok := cursors[rootID]; !ok {
+				last := lasts[rootID]
+				cursors[rootID] = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			}
+			continue
+		}
+		counts[rootID]++
+		lasts[rootID] = reply
+		kept = append(kept, reply)
+	}
+
+	tree := ToCommentTree(roots, kept)
+	for _, root := range tree {
+		if cursor, ok := cursors[root.ID]; ok {
+			encoded := cursor.Encode()
+			root.RepliesCursor = &encoded
+		}
+	}
+
+	return tree
+}
+
+// oldestFirst orders comments chronologically, the id breaks ties between comments created at once
+func oldestFirst(a, b Comment) int {
+	return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
 }
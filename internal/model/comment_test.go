@@ -37,3 +37,101 @@ func TestComment_ToCommentResponse(t *testing.T) {
 	assert.Equal(t, comment.Content, resp.Content)
 	assert.Equal(t, created.Format(time.RFC3339), resp.CreatedAt)
 }
+
+func TestComment_ToCommentResponse_Edited(t *testing.T) {
+	created := time.Date(2025, 10, 21, 12, 34, 56, 0, time.UTC)
+	edited := created.Add(30 * time.Minute)
+	comment := Comment{
+		ID:        1,
+		Content:   "Great post! (edited)",
+		CreatedAt: created,
+		UpdatedAt: &edited,
+	}
+
+	resp := comment.ToCommentResponse()
+
+	assert.NotNil(t, resp.EditedAt)
+	assert.Equal(t, edited.Format(time.RFC3339), *resp.EditedAt)
+}
+
+func TestComment_ToCommentResponse_NotEdited(t *testing.T) {
+	comment := Comment{
+		ID:        1,
+		Content:   "Great post!",
+		CreatedAt: time.Date(2025, 10, 21, 12, 34, 56, 0, time.UTC),
+	}
+
+	resp := comment.ToCommentResponse()
+
+	assert.Nil(t, resp.EditedAt)
+}
+
+func TestToCommentTree(t *testing.T) {
+	created := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	rootA, rootB := 1, 2
+	replyA1 := 3
+
+	roots := []Comment{
+		{ID: rootB, Content: "Second root", CreatedAt: created.Add(time.Minute)},
+		{ID: rootA, Content: "First root", CreatedAt: created},
+	}
+	replies := []Comment{
+		{ID: 5, ParentID: &replyA1, RootID: &rootA, Depth: 2, Content: "Nested reply", CreatedAt: created.Add(4 * time.Minute)},
+		{ID: 4, ParentID: &rootA, RootID: &rootA, Depth: 1, Content: "Later reply", CreatedAt: created.Add(3 * time.Minute)},
+		{ID: replyA1, ParentID: &rootA, RootID: &rootA, Depth: 1, Content: "Early reply", CreatedAt: created.Add(2 * time.Minute)},
+		{ID: 6, ParentID: new(int), Depth: 1, Content: "Orphan", CreatedAt: created},
+	}
+
+	tree := ToCommentTree(roots, replies)
+
+	assert.Len(t, tree, 2)
+	assert.Equal(t, rootB, tree[0].ID)
+	assert.Empty(t, tree[0].Replies)
+
+	assert.Equal(t, rootA, tree[1].ID)
+	assert.Len(t, tree[1].Replies, 2)
+	assert.Equal(t, replyA1, tree[1].Replies[0].ID)
+	assert.Equal(t, 4, tree[1].Replies[1].ID)
+
+	assert.Len(t, tree[1].Replies[0].Replies, 1)
+	assert.Equal(t, 5, tree[1].Replies[0].Replies[0].ID)
+	assert.Equal(t, 2, tree[1].Replies[0].Replies[0].Depth)
+	assert.Equal(t, replyA1, *tree[1].Replies[0].Replies[0].ParentCommentID)
+}
+
+func TestToCommentThreads(t *testing.T) {
+	created := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	rootA, rootB := 1, 2
+	replyA1 := 3
+
+	roots := []Comment{
+		{ID: rootB, Content: "Quiet root", CreatedAt: created.Add(time.Minute)},
+		{ID: rootA, Content: "Busy root", CreatedAt: created},
+	}
+	replies := []Comment{
+		{ID: 6, ParentID: &rootA, RootID: &rootA, Depth: 1, Content: "Latest reply", CreatedAt: created.Add(5 * time.Minute)},
+		{ID: 5, ParentID: &replyA1, RootID: &rootA, Depth: 2, Content: "Nested reply", CreatedAt: created.Add(4 * time.Minute)},
+		{ID: 4, ParentID: &rootB, RootID: &rootB, Depth: 1, Content: "Only reply", CreatedAt: created.Add(3 * time.Minute)},
+		{ID: replyA1, ParentID: &rootA, RootID: &rootA, Depth: 1, Content: "Early reply", CreatedAt: created.Add(2 * time.Minute)},
+	}
+
+	tree := ToCommentThreads(roots, replies, 2)
+
+	assert.Len(t, tree, 2)
+	assert.Equal(t, rootB, tree[0].ID)
+	assert.Len(t, tree[0].Replies, 1)
+	assert.Nil(t, tree[0].RepliesCursor)
+
+	assert.Equal(t, rootA, tree[1].ID)
+	assert.Len(t, tree[1].Replies, 1)
+	assert.Equal(t, replyA1, tree[1].Replies[0].ID)
+	assert.Len(t, tree[1].Replies[0].Replies, 1)
+	assert.Equal(t, 5, tree[1].Replies[0].Replies[0].ID)
+
+	if assert.NotNil(t, tree[1].RepliesCursor) {
+		cursor, err := DecodeCursor(*tree[1].RepliesCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, cursor.ID)
+		assert.True(t, created.Add(4*time.Minute).Equal(cursor.CreatedAt))
+	}
+}
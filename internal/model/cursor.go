@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Cursor is the keyset position of the last item of a page, ordered by (created_at, id)
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"id"`
+}
+
+// Page describes which slice of a keyset ordered list should be read, a nil cursor means the first page
+type Page struct {
+	Cursor *Cursor
+	Limit  int
+}
+
+// Encode returns the opaque representation of the cursor that is handed to clients
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+		return nil, errors.New("incomplete cursor")
+	}
+
+	return cursor, nil
+}
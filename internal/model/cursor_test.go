@@ -0,0 +1,41 @@
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	cursor := &Cursor{
+		CreatedAt: time.Date(2025, 10, 21, 12, 0, 0, 123456000, time.UTC),
+		ID:        42,
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt), "created_at should survive the round trip")
+	assert.Equal(t, cursor.ID, decoded.ID)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{"missing id", base64.RawURLEncoding.EncodeToString([]byte(`{"t":"2025-10-21T12:00:00Z"}`))},
+		{"missing time", base64.RawURLEncoding.EncodeToString([]byte(`{"id":1}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeCursor(tt.encoded)
+			assert.Error(t, err)
+		})
+	}
+}
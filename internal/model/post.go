@@ -8,15 +8,48 @@ import (
 	"github.com/aleszilagyi/prosig-blog/internal/response"
 )
 
+// PostStatus is the publication state of a post, only published posts are publicly visible
+type PostStatus string
+
+const (
+	PostStatusDraft     PostStatus = "draft"
+	PostStatusPublished PostStatus = "published"
+	PostStatusScheduled PostStatus = "scheduled"
+	PostStatusArchived  PostStatus = "archived"
+)
+
+func (s PostStatus) IsValid() bool {
+	switch s {
+	case PostStatusDraft, PostStatusPublished, PostStatusScheduled, PostStatusArchived:
+		return true
+	default:
+		return false
+	}
+}
+
 type Post struct {
 	ID        int
 	Title     string
+	Slug      string
+	AuthorID  *int
+	Author    *Author
 	Content   string
+	Status    PostStatus
+	PublishAt *time.Time
+	Tags      []string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Comments  []Comment
 }
 
+// PostChanges holds the fields of a post to be updated, nil fields are kept unchanged
+type PostChanges struct {
+	Title     *string
+	Content   *string
+	Status    *PostStatus
+	PublishAt *time.Time
+}
+
 func (p *Post) String() string {
 	createdAt := p.CreatedAt.Format(time.RFC3339)
 	updatedAt := p.UpdatedAt.Format(time.RFC3339)
@@ -38,9 +71,14 @@ func (p *Post) ToPostWithCommentCount(commentsCount int) *response.PostWithComme
 	return &response.PostWithCommentCountResponse{
 		ID:           p.ID,
 		Title:        p.Title,
+		Slug:         p.Slug,
+		Author:       p.Author.ToAuthorResponse(),
 		Content:      p.Content,
 		CreatedAt:    p.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:    p.UpdatedAt.Format(time.RFC3339),
+		Status:       string(p.Status),
+		PublishAt:    formatOptionalTime(p.PublishAt),
+		Tags:         tagsOrEmpty(p.Tags),
 		CommentCount: commentsCount,
 	}
 }
@@ -53,9 +91,22 @@ func (p *Post) ToPostWithComments() *response.PostWithCommentsResponse {
 	return &response.PostWithCommentsResponse{
 		ID:        p.ID,
 		Title:     p.Title,
+		Slug:      p.Slug,
+		Author:    p.Author.ToAuthorResponse(),
 		Content:   p.Content,
 		CreatedAt: p.CreatedAt.Format(time.RFC3339),
 		UpdatedAt: p.UpdatedAt.Format(time.RFC3339),
+		Status:    string(p.Status),
+		PublishAt: formatOptionalTime(p.PublishAt),
+		Tags:      tagsOrEmpty(p.Tags),
 		Comments:  comments,
 	}
 }
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
@@ -86,3 +86,27 @@ func TestPost_ToPostWithComments(t *testing.T) {
 	assert.Equal(t, 2, resp.Comments[1].ID)
 	assert.Equal(t, "Great post", resp.Comments[1].Content)
 }
+
+func TestPostStatus_IsValid(t *testing.T) {
+	for _, status := range []PostStatus{PostStatusDraft, PostStatusPublished, PostStatusScheduled, PostStatusArchived} {
+		assert.True(t, status.IsValid(), "status %q should be valid", status)
+	}
+
+	assert.False(t, PostStatus("").IsValid())
+	assert.False(t, PostStatus("deleted").IsValid())
+}
+
+func TestPost_ToPostWithCommentCount_Scheduled(t *testing.T) {
+	publishAt := time.Date(2025, 12, 24, 9, 0, 0, 0, time.UTC)
+	post := Post{
+		ID:        1,
+		Title:     "Coming soon",
+		Status:    PostStatusScheduled,
+		PublishAt: &publishAt,
+	}
+
+	resp := post.ToPostWithCommentCount(0)
+
+	assert.Equal(t, "scheduled", resp.Status)
+	assert.Equal(t, publishAt.Format(time.RFC3339), *resp.PublishAt)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/diff"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// Revision is a snapshot of the title and content of a post, numbered from 1 in the order they were made
+type Revision struct {
+	PostID       int
+	Number       int
+	Title        string
+	Content      string
+	RestoredFrom *int
+	CreatedAt    time.Time
+}
+
+func (r *Revision) ToRevisionResponse() *response.RevisionResponse {
+	return &response.RevisionResponse{
+		Revision:             r.Number,
+		Title:                r.Title,
+		Content:              r.Content,
+		RestoredFromRevision: r.RestoredFrom,
+		CreatedAt:            r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToRevisionDiff compares the title and content of two revisions line by line
+func ToRevisionDiff(from, to *response.RevisionResponse) *response.RevisionDiffResponse {
+	return &response.RevisionDiffResponse{
+		FromRevision: from.Revision,
+		ToRevision:   to.Revision,
+		Title:        toDiffLines(diff.Lines(from.Title, to.Title)),
+		Content:      toDiffLines(diff.Lines(from.Content, to.Content)),
+	}
+}
+
+func toDiffLines(script []diff.Line) []*response.DiffLineResponse {
+	lines := make([]*response.DiffLineResponse, len(script))
+	for idx, line := range script {
+		lines[idx] = &response.DiffLineResponse{
+			Op:   string(line.Op),
+			Text: line.Text,
+		}
+	}
+	return lines
+}
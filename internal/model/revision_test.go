@@ -0,0 +1,49 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevision_ToRevisionResponse(t *testing.T) {
+	created := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	restoredFrom := 1
+	revision := Revision{
+		PostID:       7,
+		Number:       3,
+		Title:        "Hello",
+		Content:      "World",
+		RestoredFrom: &restoredFrom,
+		CreatedAt:    created,
+	}
+
+	resp := revision.ToRevisionResponse()
+
+	assert.Equal(t, 3, resp.Revision)
+	assert.Equal(t, "Hello", resp.Title)
+	assert.Equal(t, "World", resp.Content)
+	assert.Equal(t, restoredFrom, *resp.RestoredFromRevision)
+	assert.Equal(t, created.Format(time.RFC3339), resp.CreatedAt)
+}
+
+func TestToRevisionDiff(t *testing.T) {
+	from := &response.RevisionResponse{Revision: 1, Title: "Helo", Content: "first line\nsecond line"}
+	to := &response.RevisionResponse{Revision: 2, Title: "Hello", Content: "first line\nsecond line\nthird line"}
+
+	resp := ToRevisionDiff(from, to)
+
+	assert.Equal(t, 1, resp.FromRevision)
+	assert.Equal(t, 2, resp.ToRevision)
+	assert.Equal(t, []*response.DiffLineResponse{
+		{Op: "delete", Text: "Helo"},
+		{Op: "insert", Text: "Hello"},
+	}, resp.Title)
+	assert.Equal(t, []*response.DiffLineResponse{
+		{Op: "equal", Text: "first line"},
+		{Op: "equal", Text: "second line"},
+		{Op: "insert", Text: "third line"},
+	}, resp.Content)
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// SearchCursor is the keyset position of the last result of a search page, ordered by (rank, id)
+type SearchCursor struct {
+	Rank float64 `json:"r"`
+	ID   int     `json:"id"`
+}
+
+// SearchPage describes which slice of the ranked search results should be read, a nil cursor means the first page
+type SearchPage struct {
+	Cursor *SearchCursor
+	Limit  int
+}
+
+func (c *SearchCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeSearchCursor(encoded string) (*SearchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &SearchCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, err
+	}
+
+	if cursor.ID <= 0 || cursor.Rank < 0 {
+		return nil, errors.New("incomplete cursor")
+	}
+
+	return cursor, nil
+}
+
+// SearchResult is a post matching a search, either on its own text or on its comments, with its highlighted snippets
+type SearchResult struct {
+	PostID           int
+	Title            string
+	Slug             string
+	Author           *Author
+	Rank             float64
+	TitleHighlight   string
+	Snippet          string
+	MatchingComments int
+	CreatedAt        time.Time
+}
+
+func (r *SearchResult) ToSearchResultResponse() *response.SearchResultResponse {
+	return &response.SearchResultResponse{
+		PostID:           r.PostID,
+		Title:            r.Title,
+		Slug:             r.Slug,
+		Author:           r.Author.ToAuthorResponse(),
+		Rank:             float32(r.Rank),
+		TitleHighlight:   r.TitleHighlight,
+		Snippet:          r.Snippet,
+		MatchingComments: r.MatchingComments,
+		CreatedAt:        r.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchCursor_EncodeDecode(t *testing.T) {
+	cursor := &SearchCursor{Rank: 0.0607927, ID: 42}
+
+	decoded, err := DecodeSearchCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded, "the rank should survive the round trip exactly")
+}
+
+func TestDecodeSearchCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("nope"))},
+		{"missing id", base64.RawURLEncoding.EncodeToString([]byte(`{"r":0.5}`))},
+		{"negative rank", base64.RawURLEncoding.EncodeToString([]byte(`{"r":-1,"id":1}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeSearchCursor(tt.encoded)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSearchResult_ToSearchResultResponse(t *testing.T) {
+	result := &SearchResult{
+		PostID:           1,
+		Title:            "Go generics",
+		Slug:             "go-generics",
+		Rank:             0.5,
+		TitleHighlight:   "<mark>Go</mark> generics",
+		Snippet:          "Using <mark>Go</mark> type parameters",
+		MatchingComments: 2,
+		CreatedAt:        time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	resp := result.ToSearchResultResponse()
+
+	assert.Equal(t, 1, resp.PostID)
+	assert.Equal(t, "<mark>Go</mark> generics", resp.TitleHighlight)
+	assert.Equal(t, 2, resp.MatchingComments)
+	assert.Nil(t, resp.Author)
+	assert.Equal(t, "2025-03-01T12:00:00Z", resp.CreatedAt)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// TagMatch tells whether a post must carry any or all of the filtered tags
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+)
+
+// TagFilter narrows a posts listing down to the given tags, an empty filter lists every post
+type TagFilter struct {
+	Tags  []string
+	Match TagMatch
+}
+
+type Tag struct {
+	ID        int
+	Name      string
+	PostCount int
+}
+
+func (t *Tag) ToTagResponse() *response.TagResponse {
+	return &response.TagResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		PostCount: t.PostCount,
+	}
+}
+
+// NormalizeTagName makes tag names case and surrounding space insensitive
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NormalizeTags normalizes every tag name and drops the duplicates, keeping the given order
+func NormalizeTags(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag := NormalizeTagName(name)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// tagsOrEmpty renders posts without tags as an empty list instead of null
+func tagsOrEmpty(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
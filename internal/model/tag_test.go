@@ -0,0 +1,32 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tags := NormalizeTags([]string{" Go ", "postgres", "go", "POSTGRES", "sql"})
+
+	assert.Equal(t, []string{"go", "postgres", "sql"}, tags)
+}
+
+func TestTag_ToTagResponse(t *testing.T) {
+	tag := Tag{ID: 1, Name: "go", PostCount: 3}
+
+	resp := tag.ToTagResponse()
+
+	assert.Equal(t, 1, resp.ID)
+	assert.Equal(t, "go", resp.Name)
+	assert.Equal(t, 3, resp.PostCount)
+}
+
+func TestPost_ToPostWithCommentCount_Tags(t *testing.T) {
+	post := Post{ID: 1, Title: "Hello"}
+	assert.Equal(t, []string{}, post.ToPostWithCommentCount(0).Tags)
+
+	post.Tags = []string{"go", "sql"}
+	assert.Equal(t, []string{"go", "sql"}, post.ToPostWithCommentCount(0).Tags)
+	assert.Equal(t, []string{"go", "sql"}, post.ToPostWithComments().Tags)
+}
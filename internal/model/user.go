@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// Role grants a user its permissions, from commenting only up to managing other users
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleEditor    Role = "editor"
+	RoleAuthor    Role = "author"
+	RoleCommenter Role = "commenter"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleAuthor, RoleCommenter:
+		return true
+	default:
+		return false
+	}
+}
+
+type User struct {
+	ID           int
+	Username     string
+	DisplayName  string
+	Role         Role
+	PasswordHash string
+	CreatedAt    time.Time
+}
+
+// Author is the public profile of the user who wrote a post or a comment
+type Author struct {
+	ID          int
+	Username    string
+	DisplayName string
+}
+
+func (u *User) ToUserResponse() *response.UserResponse {
+	return &response.UserResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+		Role:        string(u.Role),
+		CreatedAt:   u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// ToAuthorResponse renders a missing author, as on content written before authors were recorded, as null
+func (a *Author) ToAuthorResponse() *response.AuthorResponse {
+	if a == nil {
+		return nil
+	}
+	return &response.AuthorResponse{
+		ID:          a.ID,
+		Username:    a.Username,
+		DisplayName: a.DisplayName,
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_ToUserResponse(t *testing.T) {
+	created := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	user := User{ID: 1, Username: "ada", DisplayName: "Ada", PasswordHash: "hash", CreatedAt: created}
+
+	resp := user.ToUserResponse()
+
+	assert.Equal(t, 1, resp.ID)
+	assert.Equal(t, "ada", resp.Username)
+	assert.Equal(t, "Ada", resp.DisplayName)
+	assert.Equal(t, created.Format(time.RFC3339), resp.CreatedAt)
+}
+
+func TestAuthor_ToAuthorResponse(t *testing.T) {
+	var missing *Author
+	assert.Nil(t, missing.ToAuthorResponse())
+
+	post := Post{ID: 1, Author: &Author{ID: 2, Username: "ada", DisplayName: "Ada"}}
+	assert.Equal(t, "ada", post.ToPostWithCommentCount(0).Author.Username)
+
+	comment := Comment{ID: 1, Author: &Author{ID: 2, Username: "ada", DisplayName: "Ada"}}
+	assert.Equal(t, 2, comment.ToCommentResponse().Author.ID)
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+)
+
+// Action is something a user may be allowed to do, possibly only on what they own
+type Action string
+
+const (
+	ActionCreatePost      Action = "create_post"
+	ActionUpdatePost      Action = "update_post"
+	ActionDeletePost      Action = "delete_post"
+	ActionReadUnpublished Action = "read_unpublished"
+	ActionAddComment      Action = "add_comment"
+	ActionUpdateComment   Action = "update_comment"
+	ActionDeleteComment   Action = "delete_comment"
+	ActionManageTags      Action = "manage_tags"
+	ActionManageUsers     Action = "manage_users"
+	ActionManageAPIKeys   Action = "manage_api_keys"
+)
+
+// grant tells whether a role may perform an action on anything, or only on what its user owns
+type grant int
+
+const (
+	grantNone grant = iota
+	grantOwn
+	grantAll
+)
+
+var grants = map[model.Role]map[Action]grant{
+	model.RoleAdmin: {
+		ActionCreatePost:      grantAll,
+		ActionUpdatePost:      grantAll,
+		ActionDeletePost:      grantAll,
+		ActionReadUnpublished: grantAll,
+		ActionAddComment:      grantAll,
+		ActionUpdateComment:   grantAll,
+		ActionDeleteComment:   grantAll,
+		ActionManageTags:      grantAll,
+		ActionManageUsers:     grantAll,
+		ActionManageAPIKeys:   grantAll,
+	},
+	model.RoleEditor: {
+		ActionCreatePost:      grantAll,
+		ActionUpdatePost:      grantAll,
+		ActionDeletePost:      grantAll,
+		ActionReadUnpublished: grantAll,
+		ActionAddComment:      grantAll,
+		ActionUpdateComment:   grantOwn,
+		ActionDeleteComment:   grantAll,
+		ActionManageTags:      grantAll,
+	},
+	model.RoleAuthor: {
+		ActionCreatePost:      grantAll,
+		ActionUpdatePost:      grantOwn,
+		ActionDeletePost:      grantOwn,
+		ActionReadUnpublished: grantOwn,
+		ActionAddComment:      grantAll,
+		ActionUpdateComment:   grantOwn,
+		ActionDeleteComment:   grantOwn,
+	},
+	model.RoleCommenter: {
+		ActionAddComment:    grantAll,
+		ActionUpdateComment: grantOwn,
+		ActionDeleteComment: grantOwn,
+	},
+}
+
+// actionScopes holds the scope an API key needs for each action, actions missing here are never
+// available to API keys whatever the role of their user
+var actionScopes = map[Action]model.Scope{
+	ActionCreatePost:      model.ScopePostsWrite,
+	ActionUpdatePost:      model.ScopePostsWrite,
+	ActionDeletePost:      model.ScopePostsWrite,
+	ActionReadUnpublished: model.ScopeRead,
+	ActionUpdateComment:   model.ScopeCommentsModerate,
+	ActionDeleteComment:   model.ScopeCommentsModerate,
+}
+
+// Authorize checks the principal may perform the action on a resource owned by ownerID, which is nil
+// for resources without an owner. It returns an error wrapping ErrForbidden when it may not
+func Authorize(principal *auth.Principal, action Action, ownerID *int) error {
+	if principal == nil {
+		return app_err.ErrUnauthorized
+	}
+
+	if err := authorizeScope(principal, action); err != nil {
+		return err
+	}
+
+	switch grants[principal.Role][action] {
+	case grantAll:
+		return nil
+	case grantOwn:
+		if ownerID != nil && *ownerID == principal.UserID {
+			return nil
+		}
+		return errors.Join(app_err.ErrForbidden, fmt.Errorf("%ss can only %s their own content", principal.Role, action.verb()))
+	default:
+		return errors.Join(app_err.ErrForbidden, fmt.Errorf("%ss cannot %s", principal.Role, action.verb()))
+	}
+}
+
+// NeedsOwner tells whether authorizing the action for the principal depends on who owns the resource,
+// so handlers only look the owner up when it matters
+func NeedsOwner(principal *auth.Principal, action Action) bool {
+	return principal != nil && authorizeScope(principal, action) == nil && grants[principal.Role][action] == grantOwn
+}
+
+// authorizeScope checks API keys carry the scope of the action, their user role is checked on top of it
+func authorizeScope(principal *auth.Principal, action Action) error {
+	if !principal.IsAPIKey() {
+		return nil
+	}
+
+	scope, ok := actionScopes[action]
+	if !ok {
+		return errors.Join(app_err.ErrForbidden, fmt.Errorf("api keys cannot %s", action.verb()))
+	}
+	if !principal.HasScope(scope) {
+		return errors.Join(app_err.ErrForbidden, fmt.Errorf("api key needs the %q scope to %s", scope, action.verb()))
+	}
+	return nil
+}
+
+func (a Action) verb() string {
+	switch a {
+	case ActionCreatePost:
+		return "create posts"
+	case ActionUpdatePost:
+		return "update posts"
+	case ActionDeletePost:
+		return "delete posts"
+	case ActionReadUnpublished:
+		return "read unpublished posts"
+	case ActionAddComment:
+		return "comment"
+	case ActionUpdateComment:
+		return "update comments"
+	case ActionDeleteComment:
+		return "delete comments"
+	case ActionManageTags:
+		return "manage tags"
+	case ActionManageUsers:
+		return "manage users"
+	case ActionManageAPIKeys:
+		return "manage api keys"
+	default:
+		return string(a)
+	}
+}
@@ -0,0 +1,84 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	self, other := 1, 2
+	admin := &auth.Principal{UserID: self, Role: model.RoleAdmin}
+	editor := &auth.Principal{UserID: self, Role: model.RoleEditor}
+	author := &auth.Principal{UserID: self, Role: model.RoleAuthor}
+	commenter := &auth.Principal{UserID: self, Role: model.RoleCommenter}
+	unknown := &auth.Principal{UserID: self, Role: "guest"}
+	postsKey := &auth.Principal{UserID: self, Role: model.RoleAdmin, Scopes: []model.Scope{model.ScopePostsWrite}}
+	readKey := &auth.Principal{UserID: self, Role: model.RoleAdmin, Scopes: []model.Scope{model.ScopeRead}}
+	authorModerationKey := &auth.Principal{UserID: self, Role: model.RoleAuthor, Scopes: []model.Scope{model.ScopeCommentsModerate}}
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		action    Action
+		ownerID   *int
+		allowed   bool
+	}{
+		{name: "admin manages users", principal: admin, action: ActionManageUsers, allowed: true},
+		{name: "admin updates any post", principal: admin, action: ActionUpdatePost, ownerID: &other, allowed: true},
+		{name: "editor updates any post", principal: editor, action: ActionUpdatePost, ownerID: &other, allowed: true},
+		{name: "editor deletes any comment", principal: editor, action: ActionDeleteComment, ownerID: &other, allowed: true},
+		{name: "editor updates only own comments", principal: editor, action: ActionUpdateComment, ownerID: &other},
+		{name: "editor cannot manage users", principal: editor, action: ActionManageUsers},
+		{name: "author creates posts", principal: author, action: ActionCreatePost, allowed: true},
+		{name: "author updates own post", principal: author, action: ActionUpdatePost, ownerID: &self, allowed: true},
+		{name: "author cannot update others post", principal: author, action: ActionUpdatePost, ownerID: &other},
+		{name: "author cannot update post without owner", principal: author, action: ActionUpdatePost},
+		{name: "author cannot manage tags", principal: author, action: ActionManageTags},
+		{name: "commenter comments", principal: commenter, action: ActionAddComment, allowed: true},
+		{name: "commenter deletes own comment", principal: commenter, action: ActionDeleteComment, ownerID: &self, allowed: true},
+		{name: "commenter cannot create posts", principal: commenter, action: ActionCreatePost},
+		{name: "unknown role is denied", principal: unknown, action: ActionAddComment},
+		{name: "editor cannot manage api keys", principal: editor, action: ActionManageAPIKeys},
+		{name: "posts key creates posts", principal: postsKey, action: ActionCreatePost, allowed: true},
+		{name: "posts key cannot delete comments", principal: postsKey, action: ActionDeleteComment, ownerID: &other},
+		{name: "read key cannot create posts", principal: readKey, action: ActionCreatePost},
+		{name: "read key reads unpublished posts", principal: readKey, action: ActionReadUnpublished, ownerID: &other, allowed: true},
+		{name: "posts key cannot read unpublished posts", principal: postsKey, action: ActionReadUnpublished, ownerID: &other},
+		{name: "author reads own unpublished post", principal: author, action: ActionReadUnpublished, ownerID: &self, allowed: true},
+		{name: "author cannot read others unpublished post", principal: author, action: ActionReadUnpublished, ownerID: &other},
+		{name: "commenter cannot read unpublished posts", principal: commenter, action: ActionReadUnpublished, ownerID: &self},
+		{name: "admin key cannot manage users", principal: postsKey, action: ActionManageUsers},
+		{name: "moderation key keeps the role of its user", principal: authorModerationKey, action: ActionDeleteComment, ownerID: &other},
+		{name: "moderation key deletes own comment", principal: authorModerationKey, action: ActionDeleteComment, ownerID: &self, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Authorize(tt.principal, tt.action, tt.ownerID)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, app_err.ErrForbidden), "error should wrap ErrForbidden")
+			}
+		})
+	}
+}
+
+func TestAuthorize_Anonymous(t *testing.T) {
+	err := Authorize(nil, ActionAddComment, nil)
+	assert.True(t, errors.Is(err, app_err.ErrUnauthorized))
+}
+
+func TestNeedsOwner(t *testing.T) {
+	assert.True(t, NeedsOwner(&auth.Principal{Role: model.RoleAuthor}, ActionUpdatePost))
+	assert.False(t, NeedsOwner(&auth.Principal{Role: model.RoleEditor}, ActionUpdatePost))
+	assert.False(t, NeedsOwner(&auth.Principal{Role: model.RoleCommenter}, ActionCreatePost))
+
+	readKey := &auth.Principal{Role: model.RoleAuthor, Scopes: []model.Scope{model.ScopeRead}}
+	assert.False(t, NeedsOwner(readKey, ActionUpdatePost), "a key without the scope is denied without looking the owner up")
+}
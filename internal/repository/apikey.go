@@ -0,0 +1,185 @@
+//go:generate mockgen -destination ./mocks/mock_apikey.go -package mocks github.com/aleszilagyi/prosig-blog/internal/repository APIKeyRepository
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.uber.org/zap"
+)
+
+const (
+	// Selecting from users fails with no rows, instead of a foreign key violation, when the user does not exist
+	queryCreateAPIKey = `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		SELECT id, $2, $3, $4, $5, $6
+		FROM users
+		WHERE id = $1
+		RETURNING id, created_at
+	`
+
+	queryAPIKeys = `
+		SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+		FROM api_keys
+		ORDER BY id
+	`
+
+	// The revocation time is sent along, in UTC like the use and expiry times it is compared with,
+	// rather than read from the clock of the database session
+	queryRevokeAPIKey = `
+		UPDATE api_keys
+		SET revoked_at = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	// Finding a usable key and recording its use is a single statement, revoked and expired keys match nothing.
+	// The user is read with subqueries, as SQLite cannot return the columns of an UPDATE ... FROM
+	queryAuthenticateAPIKey = `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE key_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > $2)
+			AND EXISTS (SELECT 1 FROM users u WHERE u.id = api_keys.user_id)
+		RETURNING id, user_id, name, prefix, scopes,
+			(SELECT u.username FROM users u WHERE u.id = api_keys.user_id),
+			(SELECT u.role FROM users u WHERE u.id = api_keys.user_id)
+	`
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) (*response.APIKeyResponse, error)
+	GetAPIKeys(ctx context.Context) ([]*response.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	AuthenticateAPIKey(ctx context.Context, keyHash string, now time.Time) (*model.APIKey, error)
+}
+
+type apiKeyRepository struct {
+	db *database
+}
+
+func NewAPIKeyRepository(db *sql.DB, dialect Dialect) APIKeyRepository {
+	return &apiKeyRepository{db: newDatabase(db, dialect)}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (*response.APIKeyResponse, error) {
+	logger := log.FromContext(ctx).With(zap.Int("user_id", key.UserID), zap.String("prefix", key.Prefix))
+	err := r.db.QueryRowContext(ctx, queryCreateAPIKey,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		model.JoinScopes(key.Scopes),
+		key.ExpiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoCreateAPIKey] could not find the user")
+		return nil, errors.Join(app_err.ErrNotFound, errors.New("user not found"))
+	}
+	if err != nil {
+		logger.Error("[RepoCreateAPIKey] could not persist the api key", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoCreateAPIKey] api key created", zap.Int("api_key_id", key.ID))
+	return key.ToAPIKeyResponse(), nil
+}
+
+func (r *apiKeyRepository) GetAPIKeys(ctx context.Context) ([]*response.APIKeyResponse, error) {
+	logger := log.FromContext(ctx)
+	rows, err := r.db.QueryContext(ctx, queryAPIKeys)
+	if err != nil {
+		logger.Error("[RepoGetAPIKeys] failed to query api keys", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	keys := []*response.APIKeyResponse{}
+	for rows.Next() {
+		key := &model.APIKey{}
+		var scopes string
+		if err := rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&scopes,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.CreatedAt,
+		); err != nil {
+			logger.Error("[RepoGetAPIKeys] failed to scan api key", zap.Error(err))
+			return nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		key.Scopes = model.SplitScopes(scopes)
+		keys = append(keys, key.ToAPIKeyResponse())
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetAPIKeys] row iteration error", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops the key from authenticating, revoked keys are kept so they still show up when listing
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	logger := log.FromContext(ctx).With(zap.Int("api_key_id", id))
+	result, err := r.db.ExecContext(ctx, queryRevokeAPIKey, id, time.Now().UTC())
+	if err != nil {
+		logger.Error("[RepoRevokeAPIKey] could not revoke the api key", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoRevokeAPIKey] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoRevokeAPIKey] could not find an active api key")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoRevokeAPIKey] api key revoked")
+	return nil
+}
+
+// AuthenticateAPIKey finds the active key with the given hash, along with the user it acts for,
+// and records it was used at now. Unknown, revoked and expired keys are all not found
+func (r *apiKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string, now time.Time) (*model.APIKey, error) {
+	logger := log.FromContext(ctx)
+	key := &model.APIKey{User: &model.User{}}
+	var scopes string
+	err := r.db.QueryRowContext(ctx, queryAuthenticateAPIKey, keyHash, now).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.User.Username,
+		&key.User.Role,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoAuthenticateAPIKey] could not find an active api key")
+		return nil, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoAuthenticateAPIKey] failed to query api key", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	key.User.ID = key.UserID
+	key.Scopes = model.SplitScopes(scopes)
+	key.LastUsedAt = &now
+	return key, nil
+}
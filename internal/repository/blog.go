@@ -5,6 +5,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"html"
+	"strings"
 	"time"
 
 	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
@@ -15,191 +18,631 @@ import (
 )
 
 const (
-	queryAllPostsWithCommentCount = `
-		SELECT b.id, b.title, b.created_at, b.updated_at, COUNT(c.id) AS comment_count
+	// The posts page query is completed with the tag condition, %s, which may be empty
+	queryFirstPostsPageWithCommentCount = `
+		SELECT b.id, b.title, b.slug, b.status, b.publish_at, b.created_at, b.updated_at,
+			(SELECT COUNT(*) FROM comments c WHERE c.blog_post_id = b.id AND c.deleted_at IS NULL) AS comment_count,
+			u.id, u.username, u.display_name
 		FROM blog_posts b
-		LEFT JOIN comments c ON c.blog_post_id = b.id
-		GROUP BY b.id
-		ORDER BY b.created_at DESC
+		LEFT JOIN users u ON u.id = b.author_id
+		WHERE b.status = 'published' %s
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT $1
 	`
 
-	queryPostWithComments = `
-		SELECT 
-			b.id AS post_id,
-			b.title,
-			b.content,
-			b.created_at AS post_created_at,
-			b.updated_at AS post_updated_at,
-			c.id AS comment_id,
-			c.content AS comment_content,
-			c.created_at AS comment_created_at
+	queryPostsPageWithCommentCount = `
+		SELECT b.id, b.title, b.slug, b.status, b.publish_at, b.created_at, b.updated_at,
+			(SELECT COUNT(*) FROM comments c WHERE c.blog_post_id = b.id AND c.deleted_at IS NULL) AS comment_count,
+			u.id, u.username, u.display_name
 		FROM blog_posts b
-		LEFT JOIN comments c ON c.blog_post_id = b.id
-		WHERE b.id = $1
-		ORDER BY c.created_at DESC
+		LEFT JOIN users u ON u.id = b.author_id
+		WHERE b.status = 'published' AND (b.created_at, b.id) < ($2, $3) %s
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT $1
+	`
+
+	queryPostWithCommentCount = `
+		SELECT b.id, b.title, b.slug, b.content, b.status, b.publish_at, b.created_at, b.updated_at,
+			(SELECT COUNT(*) FROM comments c WHERE c.blog_post_id = b.id AND c.deleted_at IS NULL) AS comment_count,
+			u.id, u.username, u.display_name
+		FROM blog_posts b
+		LEFT JOIN users u ON u.id = b.author_id
+		WHERE b.id = $1 AND b.status = 'published'
+	`
+
+	queryPublishedPostExists = `
+		SELECT EXISTS (SELECT 1 FROM blog_posts WHERE id = $1 AND status = 'published')
+	`
+
+	queryPostExists = `
+		SELECT EXISTS (SELECT 1 FROM blog_posts WHERE id = $1)
+	`
+
+	queryPostAuthorID = `
+		SELECT author_id
+		FROM blog_posts
+		WHERE id = $1
+	`
+
+	// Locks the post so concurrent updates append their revisions one after the other
+	queryPostForUpdate = `
+		SELECT title, slug, content, status
+		FROM blog_posts
+		WHERE id = $1
+		FOR UPDATE
 	`
 
 	queryCreatePost = `
-		INSERT INTO blog_posts (title, content)
-		VALUES ($1, $2)
+		INSERT INTO blog_posts (title, slug, content, status, publish_at, author_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id
 	`
 
-	queryAddComment = `
-		INSERT INTO comments (blog_post_id, content)
-		VALUES ($1, $2)
-		RETURNING id
+	// The publish time is replaced whenever the status changes, so it never outlives the scheduled status
+	queryUpdatePost = `
+		UPDATE blog_posts
+		SET title = COALESCE($2, title),
+			content = COALESCE($3, content),
+			status = COALESCE($4, status),
+			publish_at = CASE WHEN $4 IS NULL AND $5 IS NULL THEN publish_at ELSE $5 END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	queryDeletePost = `
+		DELETE FROM blog_posts
+		WHERE id = $1
+	`
+
+	// A single statement flips every due post, concurrent schedulers on other replicas
+	// wait on the row locks and then skip the rows that are no longer scheduled
+	queryPublishScheduledPosts = `
+		UPDATE blog_posts
+		SET status = 'published',
+			updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'scheduled' AND publish_at <= $1
+	`
+
+	// The search query is completed with the cursor condition, %s, which is empty on the first page.
+	// Published posts match on their title and content or on their comments, the best matching comment
+	// adding half of its rank, and snippets are only highlighted for the posts of the page being read.
+	// Matches are delimited by the highlight control characters, so the text can be escaped before they become marks
+	querySearchPosts = `
+		WITH search AS (
+			SELECT websearch_to_tsquery('english', $1) AS query
+		),
+		comment_matches AS (
+			SELECT c.blog_post_id, MAX(ts_rank(c.search_vector, search.query)) AS rank, COUNT(*) AS matches
+			FROM comments c
+			CROSS JOIN search
+			WHERE c.deleted_at IS NULL AND c.search_vector @@ search.query
+			GROUP BY c.blog_post_id
+		),
+		ranked AS (
+			SELECT b.id, b.title, b.slug, b.content, b.author_id, b.created_at,
+				(ts_rank(b.search_vector, search.query) + COALESCE(cm.rank, 0) / 2)::double precision AS rank,
+				COALESCE(cm.matches, 0) AS comment_matches
+			FROM blog_posts b
+			CROSS JOIN search
+			LEFT JOIN comment_matches cm ON cm.blog_post_id = b.id
+			WHERE b.status = 'published' AND (b.search_vector @@ search.query OR cm.blog_post_id IS NOT NULL)
+		),
+		page AS (
+			SELECT *
+			FROM ranked
+			%s
+			ORDER BY rank DESC, id DESC
+			LIMIT $2
+		)
+		SELECT p.id, p.title, p.slug, p.rank, p.comment_matches, p.created_at,
+			ts_headline('english', p.title, search.query, 'HighlightAll=true, StartSel=' || chr(2) || ', StopSel=' || chr(3)),
+			ts_headline('english', p.content, search.query, 'MaxFragments=2, MaxWords=30, MinWords=10, StartSel=' || chr(2) || ', StopSel=' || chr(3)),
+			u.id, u.username, u.display_name
+		FROM page p
+		CROSS JOIN search
+		LEFT JOIN users u ON u.id = p.author_id
+		ORDER BY p.rank DESC, p.id DESC
+	`
+
+	// The SQLite counterpart of querySearchPosts over the FTS5 indexes, where terms are an FTS5 query.
+	// bm25 ranks better matches lower, so it is negated, and posts only matching on their comments
+	// keep their title and the start of their content as snippets. The FTS5 functions only run on the
+	// index being scanned, so the matches are materialized before being joined
+	querySearchPostsSQLite = `
+		WITH comment_ranks AS MATERIALIZED (
+			SELECT rowid AS id, -bm25(comments_search) AS rank
+			FROM comments_search
+			WHERE comments_search MATCH $1
+		),
+		comment_matches AS (
+			SELECT c.blog_post_id, MAX(cr.rank) AS rank, COUNT(*) AS matches
+			FROM comment_ranks cr
+			JOIN comments c ON c.id = cr.id
+			WHERE c.deleted_at IS NULL
+			GROUP BY c.blog_post_id
+		),
+		post_matches AS MATERIALIZED (
+			SELECT rowid AS id, -bm25(blog_posts_search, 2.0, 1.0) AS rank,
+				highlight(blog_posts_search, 0, char(2), char(3)) AS title_highlight,
+				snippet(blog_posts_search, 1, char(2), char(3), '...', 30) AS snippet
+			FROM blog_posts_search
+			WHERE blog_posts_search MATCH $1
+		),
+		ranked AS (
+			SELECT b.id, b.title, b.slug, b.author_id, b.created_at,
+				COALESCE(pm.rank, 0) + COALESCE(cm.rank, 0) / 2 AS rank,
+				COALESCE(cm.matches, 0) AS comment_matches,
+				COALESCE(pm.title_highlight, b.title) AS title_highlight,
+				COALESCE(pm.snippet, CASE WHEN length(b.content) > 200 THEN substr(b.content, 1, 200) || '...' ELSE b.content END) AS snippet
+			FROM blog_posts b
+			LEFT JOIN post_matches pm ON pm.id = b.id
+			LEFT JOIN comment_matches cm ON cm.blog_post_id = b.id
+			WHERE b.status = 'published' AND (pm.id IS NOT NULL OR cm.blog_post_id IS NOT NULL)
+		),
+		page AS (
+			SELECT *
+			FROM ranked
+			%s
+			ORDER BY rank DESC, id DESC
+			LIMIT $2
+		)
+		SELECT p.id, p.title, p.slug, p.rank, p.comment_matches, p.created_at, p.title_highlight, p.snippet,
+			u.id, u.username, u.display_name
+		FROM page p
+		LEFT JOIN users u ON u.id = p.author_id
+		ORDER BY p.rank DESC, p.id DESC
 	`
+
+	querySearchCursorCondition = `WHERE (rank, id) < ($3, $4)`
+)
+
+// The search queries delimit the matches of their snippets with these control characters in place of the marks
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
 )
 
+// highlightMarks turns the delimiters into marks once the rest of the snippet is escaped, so posts cannot inject HTML
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
 type BlogRepository interface {
-	GetAllPostsWithCommentCount(ctx context.Context) ([]*response.PostWithCommentCountResponse, error)
-	GetPostWithComments(ctx context.Context, id int) (*response.PostWithCommentsResponse, error)
-	CreatePost(ctx context.Context, title, content string) (int, error)
-	AddComment(ctx context.Context, blogPostID int, content string) (int, error)
+	GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) ([]*response.PostWithCommentCountResponse, string, error)
+	GetPostWithComments(ctx context.Context, id int, comments model.Page, view model.CommentView) (*response.PostWithCommentsResponse, error)
+	GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, string, error)
+	GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) ([]*response.CommentResponse, string, error)
+	GetComment(ctx context.Context, blogPostID, commentID int) (*response.CommentResponse, error)
+	CreatePost(ctx context.Context, post *model.Post) (int, error)
+	UpdatePost(ctx context.Context, id int, changes model.PostChanges) error
+	DeletePost(ctx context.Context, id int) error
+	GetPostAuthorID(ctx context.Context, id int) (*int, error)
+	PublishScheduledPosts(ctx context.Context, now time.Time) (int, error)
+	GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) ([]*response.RevisionResponse, error)
+	GetRevision(ctx context.Context, blogPostID, revision int, includeUnpublished bool) (*response.RevisionResponse, error)
+	RestoreRevision(ctx context.Context, blogPostID, revision int) (int, error)
+	AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (int, error)
+	UpdateComment(ctx context.Context, blogPostID, commentID int, content string) error
+	DeleteComment(ctx context.Context, blogPostID, commentID int) error
+	ResolveSlug(ctx context.Context, slug string) (int, string, error)
+	GetTags(ctx context.Context) ([]*response.TagResponse, error)
+	CreateTag(ctx context.Context, name string) (int, error)
+	RenameTag(ctx context.Context, id int, name string) error
+	MergeTags(ctx context.Context, sourceID, targetID int) error
+	SearchPosts(ctx context.Context, terms string, page model.SearchPage) ([]*response.SearchResultResponse, string, error)
 }
 
 type blogRepository struct {
-	db *sql.DB
+	db *database
 }
 
-func NewBlogRepository(db *sql.DB) BlogRepository {
-	return &blogRepository{db: db}
+func NewBlogRepository(db *sql.DB, dialect Dialect) BlogRepository {
+	return &blogRepository{db: newDatabase(db, dialect)}
 }
 
-func (r *blogRepository) GetAllPostsWithCommentCount(ctx context.Context) ([]*response.PostWithCommentCountResponse, error) {
-	logger := log.GetLogger()
+// GetAllPostsWithCommentCount reads a single page of posts, newest first, and returns the cursor of the next page
+// which is empty when there are no more posts to read. Only posts matching the tag filter are read
+func (r *blogRepository) GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) ([]*response.PostWithCommentCountResponse, string, error) {
+	logger := log.FromContext(ctx).With(zap.Int("page_limit", page.Limit), zap.Strings("tags", filter.Tags))
 
-	rows, err := r.db.QueryContext(ctx, queryAllPostsWithCommentCount)
+	// One extra row is read to know if there is a next page
+	var rows *sql.Rows
+	var err error
+	if page.Cursor == nil {
+		condition, tagArgs := tagCondition(filter, 2)
+		args := append([]any{page.Limit + 1}, tagArgs...)
+		rows, err = r.db.QueryContext(ctx, fmt.Sprintf(queryFirstPostsPageWithCommentCount, condition), args...)
+	} else {
+		condition, tagArgs := tagCondition(filter, 4)
+		args := append([]any{page.Limit + 1, page.Cursor.CreatedAt, page.Cursor.ID}, tagArgs...)
+		rows, err = r.db.QueryContext(ctx, fmt.Sprintf(queryPostsPageWithCommentCount, condition), args...)
+	}
 	if err != nil {
 		logger.Error("[RepoGetAllPostsWithCommentCount] failed to query all posts", zap.Error(err))
-		return nil, errors.Join(app_err.ErrInternalServer, err)
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
 	}
 	defer rows.Close()
 
-	var posts []*response.PostWithCommentCountResponse
+	posts := make([]*model.Post, 0, page.Limit)
+	counts := make([]int, 0, page.Limit)
+	hasNext := false
 	for rows.Next() {
-		var id int
-		var title string
-		var createdAt, updatedAt time.Time
+		if len(posts) == page.Limit {
+			hasNext = true
+			break
+		}
+
+		post := &model.Post{}
 		var count int
-		if err := rows.Scan(&id, &title, &createdAt, &updatedAt, &count); err != nil {
+		var author authorColumns
+		if err := rows.Scan(&post.ID, &post.Title, &post.Slug, &post.Status, &post.PublishAt, &post.CreatedAt, &post.UpdatedAt, &count,
+			&author.ID, &author.Username, &author.DisplayName); err != nil {
 			logger.Error("[RepoGetAllPostsWithCommentCount] failed to scan post", zap.Error(err))
 			// Return all available posts, do not block
 			continue
 		}
 
-		post := &model.Post{
-			ID:        id,
-			Title:     title,
-			CreatedAt: createdAt,
-			UpdatedAt: updatedAt,
-		}
-
-		posts = append(posts, post.ToPostWithCommentCount(count))
+		post.Author = author.toAuthor()
+		posts = append(posts, post)
+		counts = append(counts, count)
 	}
 
 	if err := rows.Err(); err != nil {
 		logger.Error("[RepoGetAllPostsWithCommentCount] row iteration error", zap.Error(err))
-		return nil, errors.Join(app_err.ErrInternalServer, err)
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	rows.Close()
+
+	postIDs := make([]int, len(posts))
+	for idx, post := range posts {
+		postIDs[idx] = post.ID
+	}
+	tags, err := r.getPostTags(ctx, logger, postIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := make([]*response.PostWithCommentCountResponse, len(posts))
+	for idx, post := range posts {
+		post.Tags = tags[post.ID]
+		resp[idx] = post.ToPostWithCommentCount(counts[idx])
+	}
+
+	var nextCursor string
+	if hasNext && len(posts) > 0 {
+		last := posts[len(posts)-1]
+		nextCursor = (&model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
 	}
 
-	return posts, nil
+	return resp, nextCursor, nil
 }
 
-func (r *blogRepository) GetPostWithComments(ctx context.Context, requestPostID int) (*response.PostWithCommentsResponse, error) {
-	logger := log.GetLogger().With(zap.Int("post_id", requestPostID))
-	rows, err := r.db.QueryContext(ctx, queryPostWithComments, requestPostID)
+// GetPostWithComments reads the post and embeds only the given page of its comments, laid out as requested
+func (r *blogRepository) GetPostWithComments(ctx context.Context, requestPostID int, commentsPage model.Page, view model.CommentView) (*response.PostWithCommentsResponse, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", requestPostID))
+
+	post := &model.Post{}
+	var commentCount int
+	var author authorColumns
+	err := r.db.QueryRowContext(ctx, queryPostWithCommentCount, requestPostID).Scan(
+		&post.ID,
+		&post.Title,
+		&post.Slug,
+		&post.Content,
+		&post.Status,
+		&post.PublishAt,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+		&commentCount,
+		&author.ID,
+		&author.Username,
+		&author.DisplayName,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetPostWithComments] could not find the post")
+		return nil, app_err.ErrNotFound
+	}
 	if err != nil {
 		logger.Error("[RepoGetPostWithComments] failed to query post", zap.Error(err))
 		return nil, errors.Join(app_err.ErrInternalServer, err)
 	}
-	defer rows.Close()
 
-	var post *model.Post
-	for rows.Next() {
-		var (
-			postID         *int
-			title          *string
-			content        *string
-			postCreatedAt  *time.Time
-			postUpdatedAt  *time.Time
-			commentID      *int
-			commentContent *string
-			commentCreated *time.Time
-		)
+	comments, nextCursor, err := r.getCommentsPage(ctx, logger, requestPostID, commentsPage, view)
+	if err != nil {
+		return nil, err
+	}
 
-		err := rows.Scan(
-			&postID,
-			&title,
-			&content,
-			&postCreatedAt,
-			&postUpdatedAt,
-			&commentID,
-			&commentContent,
-			&commentCreated,
-		)
-		if err != nil {
-			logger.Error("[RepoGetPostWithComments] failed to scan post", zap.Error(err))
-			return nil, errors.Join(app_err.ErrInternalServer, err)
-		}
+	tags, err := r.getPostTags(ctx, logger, []int{post.ID})
+	if err != nil {
+		return nil, err
+	}
+	post.Tags = tags[post.ID]
+	post.Author = author.toAuthor()
+
+	resp := post.ToPostWithComments()
+	resp.CommentCount = commentCount
+	resp.Comments = comments
+	if nextCursor != nil {
+		encoded := nextCursor.Encode()
+		resp.NextCommentsCursor = &encoded
+	}
+
+	return resp, nil
+}
+
+// CreatePost persists the post along with its first revision and its tags
+func (r *blogRepository) CreatePost(ctx context.Context, post *model.Post) (int, error) {
+	logger := log.FromContext(ctx).With(zap.String("post_title", post.Title), zap.String("post_status", string(post.Status)))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("[RepoCreatePost] could not begin transaction", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer tx.Rollback()
+
+	postSlug, err := r.freeSlug(ctx, tx, logger, 0, post.Title)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, queryCreatePost, post.Title, postSlug, post.Content, post.Status, post.PublishAt, post.AuthorID).Scan(&id)
+	if err != nil {
+		logger.Error("[RepoCreatePost] could not persist the post", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryAddSlug, postSlug, id); err != nil {
+		logger.Error("[RepoCreatePost] could not persist the slug", zap.Error(err), zap.String("slug", postSlug))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryAddRevision, id, 1, post.Title, post.Content, nil); err != nil {
+		logger.Error("[RepoCreatePost] could not persist the first revision", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if err := r.attachTags(ctx, tx, logger, id, post.Tags); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("[RepoCreatePost] could not commit transaction", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoCreatePost] post created", zap.Int("post_id", id))
+	return id, nil
+}
+
+// UpdatePost changes the given fields of a post, nil fields are kept unchanged.
+// A new revision is appended whenever the title or the content changes
+func (r *blogRepository) UpdatePost(ctx context.Context, id int, changes model.PostChanges) error {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", id))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("[RepoUpdatePost] could not begin transaction", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := r.updatePost(ctx, tx, logger, id, changes, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("[RepoUpdatePost] could not commit transaction", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoUpdatePost] post updated")
+	return nil
+}
+
+// updatePost applies the changes inside the transaction and returns the latest revision of the post
+func (r *blogRepository) updatePost(ctx context.Context, tx *transaction, logger *zap.Logger, id int, changes model.PostChanges, restoredFrom *int) (int, error) {
+	var currentTitle, currentSlug, currentContent string
+	var currentStatus model.PostStatus
+	err := tx.QueryRowContext(ctx, queryPostForUpdate, id).Scan(&currentTitle, &currentSlug, &currentContent, &currentStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoUpdatePost] could not find the post")
+		return 0, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoUpdatePost] could not lock the post", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	// A publish time alone reschedules the post, which only scheduled posts can be
+	if changes.Status == nil && changes.PublishAt != nil && currentStatus != model.PostStatusScheduled {
+		logger.Info("[RepoUpdatePost] cannot reschedule a post that is not scheduled", zap.String("status", string(currentStatus)))
+		return 0, errors.Join(app_err.ErrInvalidInput, errors.New("publish_at requires the scheduled status"))
+	}
+
+	var revision int
+	if err := tx.QueryRowContext(ctx, queryLatestRevision, id).Scan(&revision); err != nil {
+		logger.Error("[RepoUpdatePost] could not read the latest revision", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
 
-		// Initialize post only once
-		if post == nil {
-			post = &model.Post{
-				ID:        *postID,
-				Title:     *title,
-				Content:   *content,
-				CreatedAt: *postCreatedAt,
-				UpdatedAt: *postUpdatedAt,
-			}
+	// Posts created before revisions were kept get their current state recorded first
+	if revision == 0 {
+		revision = 1
+		if _, err := tx.ExecContext(ctx, queryAddRevision, id, revision, currentTitle, currentContent, nil); err != nil {
+			logger.Error("[RepoUpdatePost] could not record the initial revision", zap.Error(err))
+			return 0, errors.Join(app_err.ErrInternalServer, err)
 		}
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpdatePost, id, changes.Title, changes.Content, changes.Status, changes.PublishAt); err != nil {
+		logger.Error("[RepoUpdatePost] could not update the post", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
 
-		// Add comments if present
-		if commentID != nil && commentContent != nil {
-			comment := model.Comment{
-				ID:        *commentID,
-				PostID:    *postID,
-				Content:   *commentContent,
-				CreatedAt: *commentCreated,
-			}
-			post.Comments = append(post.Comments, comment)
+	title, content := currentTitle, currentContent
+	if changes.Title != nil {
+		title = *changes.Title
+	}
+	if changes.Content != nil {
+		content = *changes.Content
+	}
+	if title != currentTitle {
+		if err := r.updateSlug(ctx, tx, logger, id, currentSlug, title); err != nil {
+			return 0, err
 		}
 	}
 
-	if err := rows.Err(); err != nil {
-		logger.Error("[RepoGetPostWithComments] row iteration error", zap.Error(err))
-		return nil, errors.Join(app_err.ErrInternalServer, err)
+	if title == currentTitle && content == currentContent {
+		return revision, nil
 	}
 
-	if post == nil {
-		logger.Info("[RepoGetPostWithComments] could not find the post")
+	revision++
+	if _, err := tx.ExecContext(ctx, queryAddRevision, id, revision, title, content, restoredFrom); err != nil {
+		logger.Error("[RepoUpdatePost] could not record the revision", zap.Error(err), zap.Int("revision", revision))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return revision, nil
+}
+
+// GetPostAuthorID reads who wrote the post, whatever its status, nil when the post has no author
+func (r *blogRepository) GetPostAuthorID(ctx context.Context, id int) (*int, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", id))
+	var authorID *int
+	err := r.db.QueryRowContext(ctx, queryPostAuthorID, id).Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetPostAuthorID] could not find the post")
 		return nil, app_err.ErrNotFound
 	}
+	if err != nil {
+		logger.Error("[RepoGetPostAuthorID] failed to query post author", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
 
-	return post.ToPostWithComments(), nil
+	return authorID, nil
 }
 
-func (r *blogRepository) CreatePost(ctx context.Context, title, content string) (int, error) {
-	logger := log.GetLogger().With(zap.String("post_title", title))
-	var id int
-	err := r.db.QueryRowContext(ctx, queryCreatePost, title, content).Scan(&id)
+func (r *blogRepository) DeletePost(ctx context.Context, id int) error {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", id))
+	result, err := r.db.ExecContext(ctx, queryDeletePost, id)
 	if err != nil {
-		logger.Error("[RepoCreatePost] could not persist the post", zap.Error(err))
-		return 0, errors.Join(app_err.ErrInternalServer, err)
+		logger.Error("[RepoDeletePost] could not delete the post", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
 	}
-	logger.Info("[RepoCreatePost] post created", zap.Int("post_id", id))
-	return id, nil
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoDeletePost] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoDeletePost] could not find the post")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoDeletePost] post deleted")
+	return nil
 }
 
-func (r *blogRepository) AddComment(ctx context.Context, blogPostID int, content string) (int, error) {
-	logger := log.GetLogger().With(zap.Int("post_id", blogPostID))
-	var id int
-	err := r.db.QueryRowContext(ctx, queryAddComment, blogPostID, content).Scan(&id)
+// PublishScheduledPosts publishes every scheduled post whose publish time is not after now
+func (r *blogRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	logger := log.FromContext(ctx)
+	result, err := r.db.ExecContext(ctx, queryPublishScheduledPosts, now)
 	if err != nil {
-		logger.Error("[RepoAddComment] could not add the comment to blog post", zap.Error(err))
+		logger.Error("[RepoPublishScheduledPosts] could not publish scheduled posts", zap.Error(err))
 		return 0, errors.Join(app_err.ErrInternalServer, err)
 	}
-	logger.Info("[RepoAddComment] comment added to blog post", zap.Int("comment_id", id))
-	return id, nil
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoPublishScheduledPosts] could not read affected rows", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return int(affected), nil
+}
+
+// SearchPosts reads a single page of the published posts matching the search terms, best match first, and returns
+// the cursor of the next page which is empty when there are no more results. Terms use the web search syntax,
+// quoted phrases, or and -excluded words
+func (r *blogRepository) SearchPosts(ctx context.Context, terms string, page model.SearchPage) ([]*response.SearchResultResponse, string, error) {
+	logger := log.FromContext(ctx).With(zap.String("terms", terms), zap.Int("page_limit", page.Limit))
+
+	query := querySearchPosts
+	if r.db.dialect == DialectSQLite {
+		query = querySearchPostsSQLite
+		if terms = ftsQuery(terms); terms == "" {
+			return []*response.SearchResultResponse{}, "", nil
+		}
+	}
+
+	// One extra row is read to know if there is a next page
+	var rows *sql.Rows
+	var err error
+	if page.Cursor == nil {
+		rows, err = r.db.QueryContext(ctx, fmt.Sprintf(query, ""), terms, page.Limit+1)
+	} else {
+		rows, err = r.db.QueryContext(ctx, fmt.Sprintf(query, querySearchCursorCondition),
+			terms, page.Limit+1, page.Cursor.Rank, page.Cursor.ID)
+	}
+	if err != nil {
+		logger.Error("[RepoSearchPosts] failed to search posts", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	results := make([]*model.SearchResult, 0, page.Limit)
+	hasNext := false
+	for rows.Next() {
+		if len(results) == page.Limit {
+			hasNext = true
+			break
+		}
+
+		result := &model.SearchResult{}
+		var author authorColumns
+		if err := rows.Scan(
+			&result.PostID,
+			&result.Title,
+			&result.Slug,
+			&result.Rank,
+			&result.MatchingComments,
+			&result.CreatedAt,
+			&result.TitleHighlight,
+			&result.Snippet,
+			&author.ID,
+			&author.Username,
+			&author.DisplayName,
+		); err != nil {
+			logger.Error("[RepoSearchPosts] failed to scan search result", zap.Error(err))
+			return nil, "", errors.Join(app_err.ErrInternalServer, err)
+		}
+
+		result.Author = author.toAuthor()
+		result.TitleHighlight = markHighlights(result.TitleHighlight)
+		result.Snippet = markHighlights(result.Snippet)
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoSearchPosts] row iteration error", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	resp := make([]*response.SearchResultResponse, len(results))
+	for idx, result := range results {
+		resp[idx] = result.ToSearchResultResponse()
+	}
+
+	var nextCursor string
+	if hasNext && len(results) > 0 {
+		last := results[len(results)-1]
+		nextCursor = (&model.SearchCursor{Rank: last.Rank, ID: last.PostID}).Encode()
+	}
+
+	return resp, nextCursor, nil
+}
+
+// markHighlights escapes the text of a snippet and marks its highlighted matches
+func markHighlights(snippet string) string {
+	return highlightMarks.Replace(html.EscapeString(snippet))
 }
@@ -0,0 +1,449 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.uber.org/zap"
+)
+
+const (
+	// Comments are read along with their author, which is missing on comments written before authors were recorded
+	selectComments = `
+		SELECT c.id, c.parent_comment_id, c.root_comment_id, c.depth, c.content, c.created_at, c.updated_at,
+			u.id, u.username, u.display_name
+		FROM comments c
+		LEFT JOIN users u ON u.id = c.author_id
+	`
+
+	queryFirstCommentsPage = selectComments + `
+		WHERE c.blog_post_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $2
+	`
+
+	queryCommentsPage = selectComments + `
+		WHERE c.blog_post_id = $1 AND c.deleted_at IS NULL AND (c.created_at, c.id) < ($2, $3)
+		ORDER BY c.created_at DESC, c.id DESC
+		LIMIT $4
+	`
+
+	// Threads are paginated by their root comment, only the oldest replies of the selected roots are read along
+	// so a single busy thread cannot grow the page, one extra reply per root tells if the thread has more
+	queryFirstCommentThreadsPage = `
+		WITH roots AS (
+			SELECT id
+			FROM comments
+			WHERE blog_post_id = $1 AND parent_comment_id IS NULL AND deleted_at IS NULL
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		), replies AS (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY root_comment_id ORDER BY created_at, id) AS position
+			FROM comments
+			WHERE root_comment_id IN (SELECT id FROM roots) AND deleted_at IS NULL
+		)` + selectComments + `
+		WHERE c.id IN (SELECT id FROM roots) OR c.id IN (SELECT id FROM replies WHERE position <= $2)
+		ORDER BY c.created_at DESC, c.id DESC
+	`
+
+	queryCommentThreadsPage = `
+		WITH roots AS (
+			SELECT id
+			FROM comments
+			WHERE blog_post_id = $1 AND parent_comment_id IS NULL AND deleted_at IS NULL AND (created_at, id) < ($2, $3)
+			ORDER BY created_at DESC, id DESC
+			LIMIT $4
+		), replies AS (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY root_comment_id ORDER BY created_at, id) AS position
+			FROM comments
+			WHERE root_comment_id IN (SELECT id FROM roots) AND deleted_at IS NULL
+		)` + selectComments + `
+		WHERE c.id IN (SELECT id FROM roots) OR c.id IN (SELECT id FROM replies WHERE position <= $4)
+		ORDER BY c.created_at DESC, c.id DESC
+	`
+
+	queryThreadExists = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM comments c
+			JOIN blog_posts b ON b.id = c.blog_post_id AND b.status = 'published'
+			WHERE c.id = $2 AND c.blog_post_id = $1 AND c.parent_comment_id IS NULL AND c.deleted_at IS NULL
+		)
+	`
+
+	// Replies of a thread are read oldest first, the order they are nested in
+	queryFirstRepliesPage = selectComments + `
+		WHERE c.root_comment_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id
+		LIMIT $2
+	`
+
+	queryRepliesPage = selectComments + `
+		WHERE c.root_comment_id = $1 AND c.deleted_at IS NULL AND (c.created_at, c.id) > ($2, $3)
+		ORDER BY c.created_at, c.id
+		LIMIT $4
+	`
+
+	queryComment = selectComments + `
+		WHERE c.id = $2 AND c.blog_post_id = $1 AND c.deleted_at IS NULL
+	`
+
+	// Only published posts can be commented on
+	queryAddComment = `
+		INSERT INTO comments (blog_post_id, author_id, content)
+		SELECT b.id, $3, $2
+		FROM blog_posts b
+		WHERE b.id = $1 AND b.status = 'published'
+		RETURNING id
+	`
+
+	// Replies inherit the thread of their parent, which must belong to the same post
+	queryAddReply = `
+		INSERT INTO comments (blog_post_id, parent_comment_id, root_comment_id, depth, author_id, content)
+		SELECT p.blog_post_id, p.id, COALESCE(p.root_comment_id, p.id), p.depth + 1, $4, $3
+		FROM comments p
+		JOIN blog_posts b ON b.id = p.blog_post_id AND b.status = 'published'
+		WHERE p.id = $2 AND p.blog_post_id = $1 AND p.deleted_at IS NULL
+		RETURNING id
+	`
+
+	// Like adding them, editing and deleting comments is limited to published posts, the comments of the
+	// other posts are hidden along with them
+	queryUpdateComment = `
+		UPDATE comments
+		SET content = $3,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND blog_post_id = $1 AND deleted_at IS NULL
+			AND EXISTS (SELECT 1 FROM blog_posts b WHERE b.id = $1 AND b.status = 'published')
+	`
+
+	// Comments are soft deleted so they disappear from reads and counts but keep their row,
+	// replies are deleted along with their parent
+	queryDeleteComment = `
+		WITH RECURSIVE thread AS (
+			SELECT c.id
+			FROM comments c
+			JOIN blog_posts b ON b.id = c.blog_post_id AND b.status = 'published'
+			WHERE c.id = $2 AND c.blog_post_id = $1 AND c.deleted_at IS NULL
+			UNION ALL
+			SELECT c.id
+			FROM comments c
+			JOIN thread t ON c.parent_comment_id = t.id
+			WHERE c.deleted_at IS NULL
+		)
+		UPDATE comments
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id IN (SELECT id FROM thread)
+	`
+)
+
+// GetComments reads a single page of the post comments, newest first, and returns the cursor of the next page
+// which is empty when there are no more comments to read
+func (r *blogRepository) GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, string, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("page_limit", page.Limit))
+
+	// Comments of posts that are not publicly visible are hidden along with the post
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, queryPublishedPostExists, blogPostID).Scan(&exists); err != nil {
+		logger.Error("[RepoGetComments] failed to check if post exists", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	if !exists {
+		logger.Info("[RepoGetComments] could not find the post")
+		return nil, "", app_err.ErrNotFound
+	}
+
+	comments, nextCursor, err := r.getCommentsPage(ctx, logger, blogPostID, page, view)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var encoded string
+	if nextCursor != nil {
+		encoded = nextCursor.Encode()
+	}
+
+	return comments, encoded, nil
+}
+
+func (r *blogRepository) GetComment(ctx context.Context, blogPostID, commentID int) (*response.CommentResponse, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("comment_id", commentID))
+
+	comment := model.Comment{PostID: blogPostID}
+	err := scanComment(r.db.QueryRowContext(ctx, queryComment, blogPostID, commentID), &comment)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetComment] could not find the comment")
+		return nil, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoGetComment] failed to query comment", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return comment.ToCommentResponse(), nil
+}
+
+func (r *blogRepository) getCommentsPage(ctx context.Context, logger *zap.Logger, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, *model.Cursor, error) {
+	if view == model.CommentViewTree {
+		return r.getCommentThreadsPage(ctx, logger, blogPostID, page)
+	}
+
+	// One extra row is read to know if there is a next page
+	var rows *sql.Rows
+	var err error
+	if page.Cursor == nil {
+		rows, err = r.db.QueryContext(ctx, queryFirstCommentsPage, blogPostID, page.Limit+1)
+	} else {
+		rows, err = r.db.QueryContext(ctx, queryCommentsPage, blogPostID, page.Cursor.CreatedAt, page.Cursor.ID, page.Limit+1)
+	}
+	if err != nil {
+		logger.Error("[RepoGetCommentsPage] failed to query comments", zap.Error(err))
+		return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	comments := make([]model.Comment, 0, page.Limit)
+	hasNext := false
+	for rows.Next() {
+		if len(comments) == page.Limit {
+			hasNext = true
+			break
+		}
+
+		comment := model.Comment{PostID: blogPostID}
+		if err := scanComment(rows, &comment); err != nil {
+			logger.Error("[RepoGetCommentsPage] failed to scan comment", zap.Error(err))
+			return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetCommentsPage] row iteration error", zap.Error(err))
+		return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	resp := make([]*response.CommentResponse, len(comments))
+	for idx, comment := range comments {
+		resp[idx] = comment.ToCommentResponse()
+	}
+
+	var nextCursor *model.Cursor
+	if hasNext && len(comments) > 0 {
+		last := comments[len(comments)-1]
+		nextCursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return resp, nextCursor, nil
+}
+
+func (r *blogRepository) getCommentThreadsPage(ctx context.Context, logger *zap.Logger, blogPostID int, page model.Page) ([]*response.CommentResponse, *model.Cursor, error) {
+	// One extra root is read to know if there is a next page
+	var rows *sql.Rows
+	var err error
+	if page.Cursor == nil {
+		rows, err = r.db.QueryContext(ctx, queryFirstCommentThreadsPage, blogPostID, page.Limit+1)
+	} else {
+		rows, err = r.db.QueryContext(ctx, queryCommentThreadsPage, blogPostID, page.Cursor.CreatedAt, page.Cursor.ID, page.Limit+1)
+	}
+	if err != nil {
+		logger.Error("[RepoGetCommentThreadsPage] failed to query comment threads", zap.Error(err))
+		return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	var roots, replies []model.Comment
+	for rows.Next() {
+		comment := model.Comment{PostID: blogPostID}
+		if err := scanComment(rows, &comment); err != nil {
+			logger.Error("[RepoGetCommentThreadsPage] failed to scan comment", zap.Error(err))
+			return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+		} else {
+			replies = append(replies, comment)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetCommentThreadsPage] row iteration error", zap.Error(err))
+		return nil, nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	// Replies of the extra root are left out of the tree along with it
+	var nextCursor *model.Cursor
+	if len(roots) > page.Limit {
+		roots = roots[:page.Limit]
+		last := roots[len(roots)-1]
+		nextCursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return model.ToCommentThreads(roots, replies, page.Limit), nextCursor, nil
+}
+
+// GetReplies reads a single page of the replies of a thread, oldest first, and returns the cursor of the next page
+// which is empty when there are no more replies to read
+func (r *blogRepository) GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) ([]*response.CommentResponse, string, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("comment_id", rootCommentID),
+		zap.Int("page_limit", page.Limit))
+
+	// Threads of posts that are not publicly visible are hidden along with the post
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, queryThreadExists, blogPostID, rootCommentID).Scan(&exists); err != nil {
+		logger.Error("[RepoGetReplies] failed to check if thread exists", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	if !exists {
+		logger.Info("[RepoGetReplies] could not find the thread")
+		return nil, "", app_err.ErrNotFound
+	}
+
+	// One extra row is read to know if there is a next page
+	var rows *sql.Rows
+	var err error
+	if page.Cursor == nil {
+		rows, err = r.db.QueryContext(ctx, queryFirstRepliesPage, rootCommentID, page.Limit+1)
+	} else {
+		rows, err = r.db.QueryContext(ctx, queryRepliesPage, rootCommentID, page.Cursor.CreatedAt, page.Cursor.ID, page.Limit+1)
+	}
+	if err != nil {
+		logger.Error("[RepoGetReplies] failed to query replies", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	replies := make([]model.Comment, 0, page.Limit)
+	hasNext := false
+	for rows.Next() {
+		if len(replies) == page.Limit {
+			hasNext = true
+			break
+		}
+
+		reply := model.Comment{PostID: blogPostID}
+		if err := scanComment(rows, &reply); err != nil {
+			logger.Error("[RepoGetReplies] failed to scan reply", zap.Error(err))
+			return nil, "", errors.Join(app_err.ErrInternalServer, err)
+		}
+		replies = append(replies, reply)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetReplies] row iteration error", zap.Error(err))
+		return nil, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	resp := make([]*response.CommentResponse, len(replies))
+	for idx, reply := range replies {
+		resp[idx] = reply.ToCommentResponse()
+	}
+
+	var nextCursor string
+	if hasNext && len(replies) > 0 {
+		last := replies[len(replies)-1]
+		nextCursor = (&model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+	}
+
+	return resp, nextCursor, nil
+}
+
+func (r *blogRepository) AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (int, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("author_id", authorID))
+	var id int
+	var err error
+	if parentCommentID == nil {
+		err = r.db.QueryRowContext(ctx, queryAddComment, blogPostID, content, authorID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Info("[RepoAddComment] could not find the blog post")
+			return 0, app_err.ErrNotFound
+		}
+	} else {
+		logger = logger.With(zap.Int("parent_comment_id", *parentCommentID))
+		err = r.db.QueryRowContext(ctx, queryAddReply, blogPostID, *parentCommentID, content, authorID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Info("[RepoAddComment] could not find the parent comment in the blog post")
+			return 0, errors.Join(app_err.ErrInvalidInput, errors.New("parent comment not found in this post"))
+		}
+	}
+	if err != nil {
+		logger.Error("[RepoAddComment] could not add the comment to blog post", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+	logger.Info("[RepoAddComment] comment added to blog post", zap.Int("comment_id", id))
+	return id, nil
+}
+
+func (r *blogRepository) UpdateComment(ctx context.Context, blogPostID, commentID int, content string) error {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("comment_id", commentID))
+	result, err := r.db.ExecContext(ctx, queryUpdateComment, blogPostID, commentID, content)
+	if err != nil {
+		logger.Error("[RepoUpdateComment] could not update the comment", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoUpdateComment] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoUpdateComment] could not find the comment")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoUpdateComment] comment updated")
+	return nil
+}
+
+func (r *blogRepository) DeleteComment(ctx context.Context, blogPostID, commentID int) error {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("comment_id", commentID))
+	result, err := r.db.ExecContext(ctx, queryDeleteComment, blogPostID, commentID)
+	if err != nil {
+		logger.Error("[RepoDeleteComment] could not delete the comment", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoDeleteComment] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoDeleteComment] could not find the comment")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoDeleteComment] comment deleted", zap.Int64("deleted_comments", affected))
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanComment(row rowScanner, comment *model.Comment) error {
+	var author authorColumns
+	err := row.Scan(
+		&comment.ID,
+		&comment.ParentID,
+		&comment.RootID,
+		&comment.Depth,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&author.ID,
+		&author.Username,
+		&author.DisplayName,
+	)
+	comment.Author = author.toAuthor()
+	return err
+}
@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/repositorytest"
+	"github.com/aleszilagyi/prosig-blog/internal/storage"
+	"github.com/aleszilagyi/prosig-blog/internal/storage/migrations"
+	_ "github.com/lib/pq"
+)
+
+// postgresDSNEnv names the variable holding the database the conformance suite runs against, its tables are emptied
+const postgresDSNEnv = "APP_TEST_POSTGRES_DSN"
+
+const queryTruncate = `
+	TRUNCATE users, api_keys, blog_posts, comments, post_revisions, tags, post_tags, post_slugs RESTART IDENTITY CASCADE
+`
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestConformance_Postgres(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", postgresDSNEnv)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := migrations.Up(context.Background(), db, storage.DriverPostgres); err != nil {
+		t.Fatalf("failed to migrate the database: %v", err)
+	}
+
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		if _, err := db.Exec(queryTruncate); err != nil {
+			t.Fatalf("failed to empty the database: %v", err)
+		}
+		return repositorytest.Repositories{
+			Blog:    repository.NewBlogRepository(db, repository.DialectPostgres),
+			Users:   repository.NewUserRepository(db, repository.DialectPostgres),
+			APIKeys: repository.NewAPIKeyRepository(db, repository.DialectPostgres),
+		}
+	})
+}
+
+func TestConformance_SQLite(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		cfg := config.Config{DatabaseConfig: config.DatabaseConfig{
+			Driver:         storage.DriverSQLite,
+			Path:           filepath.Join(t.TempDir(), "blog.db"),
+			MigrateOnStart: true,
+		}}
+		db, err := storage.Connect(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("failed to open the database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		return repositorytest.Repositories{
+			Blog:    repository.NewBlogRepository(db, repository.DialectSQLite),
+			Users:   repository.NewUserRepository(db, repository.DialectSQLite),
+			APIKeys: repository.NewAPIKeyRepository(db, repository.DialectSQLite),
+		}
+	})
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Dialect is the SQL flavour of the database behind the repositories. Queries are written for Postgres
+// and adapted to the other dialects as they run
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// sqliteTimeFormat is how the SQLite schema stores timestamps, fixed width UTC text that compares in time order
+const sqliteTimeFormat = "2006-01-02 15:04:05.000"
+
+// SQLite has no row locks, its transactions begin holding the write lock instead, and CURRENT_TIMESTAMP
+// only has whole seconds, so it is replaced by the same expression the schema defaults to
+var sqliteReplacer = strings.NewReplacer(
+	"FOR UPDATE", "",
+	"CURRENT_TIMESTAMP", "strftime('%Y-%m-%d %H:%M:%f', 'now')",
+)
+
+// adapt rewrites the query and its arguments for the dialect. Timestamps are stored in UTC without their offset,
+// which Postgres would drop from a TIMESTAMP, so times are sent in UTC whatever their location
+func (d Dialect) adapt(query string, args []any) (string, []any) {
+	adapted := make([]any, len(args))
+	for idx, arg := range args {
+		adapted[idx] = d.adaptArg(arg)
+	}
+
+	if d == DialectSQLite {
+		query = sqliteReplacer.Replace(query)
+	}
+	return query, adapted
+}
+
+func (d Dialect) adaptArg(arg any) any {
+	var value time.Time
+	switch t := arg.(type) {
+	case time.Time:
+		value = t
+	case *time.Time:
+		if t == nil {
+			return nil
+		}
+		value = *t
+	default:
+		return arg
+	}
+
+	if d == DialectSQLite {
+		return value.UTC().Format(sqliteTimeFormat)
+	}
+	return value.UTC()
+}
+
+var tracer = otel.Tracer("github.com/aleszilagyi/prosig-blog/internal/repository")
+
+// startStatement opens the span of a SQL statement as it is sent, the rows it returns are read after the span ends
+func (d Dialect) startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	system := semconv.DBSystemNamePostgreSQL
+	if d == DialectSQLite {
+		system = semconv.DBSystemNameSQLite
+	}
+	name := statementName(query)
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		system,
+		semconv.DBQuerySummary(name),
+		semconv.DBQueryText(strings.Join(strings.Fields(query), " ")),
+	))
+}
+
+func endStatement(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statementName names a SQL statement after what it does and the table it does it to, like SELECT blog_posts.
+// Subqueries and common table expressions are skipped for the outermost statement, unless it reads no table
+// itself. The arguments are never part of the name, the queries only hold placeholders
+func statementName(query string) string {
+	operation, table, nested := "", "", ""
+	opDepth, depth := -1, 0
+	words := strings.Fields(query)
+	for idx, word := range words {
+		trimmed := strings.TrimLeft(word, "(")
+		at := depth + len(word) - len(trimmed)
+		depth = at + strings.Count(trimmed, "(") - strings.Count(trimmed, ")")
+
+		keyword := strings.ToUpper(trimmed)
+		switch {
+		case (keyword == "SELECT" || keyword == "INSERT" || keyword == "UPDATE" || keyword == "DELETE") && (opDepth < 0 || at < opDepth):
+			operation, table, nested, opDepth = keyword, "", "", at
+			if keyword == "UPDATE" && idx+1 < len(words) {
+				table = words[idx+1]
+			}
+		case opDepth >= 0 && (keyword == "FROM" || keyword == "INTO") && idx+1 < len(words):
+			if at == opDepth && table == "" {
+				table = words[idx+1]
+			} else if at > opDepth && nested == "" {
+				nested = words[idx+1]
+			}
+		}
+	}
+	if table == "" {
+		table = nested
+	}
+
+	table = strings.TrimFunc(table, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' })
+	if operation == "" {
+		return "SQL"
+	}
+	if table == "" {
+		return operation
+	}
+	return operation + " " + table
+}
+
+// database runs the queries of the repositories adapted to the dialect
+type database struct {
+	db      *sql.DB
+	dialect Dialect
+}
+
+func newDatabase(db *sql.DB, dialect Dialect) *database {
+	return &database{db: db, dialect: dialect}
+}
+
+func (d *database) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, args = d.dialect.adapt(query, args)
+	ctx, span := d.dialect.startStatement(ctx, query)
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	endStatement(span, err)
+	return rows, err
+}
+
+func (d *database) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	query, args = d.dialect.adapt(query, args)
+	ctx, span := d.dialect.startStatement(ctx, query)
+	row := d.db.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+	return row
+}
+
+func (d *database) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, args = d.dialect.adapt(query, args)
+	ctx, span := d.dialect.startStatement(ctx, query)
+	result, err := d.db.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+	return result, err
+}
+
+func (d *database) BeginTx(ctx context.Context, opts *sql.TxOptions) (*transaction, error) {
+	tx, err := d.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{tx: tx, dialect: d.dialect}, nil
+}
+
+// transaction is the database counterpart for queries run inside a transaction
+type transaction struct {
+	tx      *sql.Tx
+	dialect Dialect
+}
+
+func (t *transaction) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, args = t.dialect.adapt(query, args)
+	ctx, span := t.dialect.startStatement(ctx, query)
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	endStatement(span, err)
+	return rows, err
+}
+
+func (t *transaction) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	query, args = t.dialect.adapt(query, args)
+	ctx, span := t.dialect.startStatement(ctx, query)
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+	return row
+}
+
+func (t *transaction) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, args = t.dialect.adapt(query, args)
+	ctx, span := t.dialect.startStatement(ctx, query)
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+	return result, err
+}
+
+func (t *transaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *transaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// ftsQuery translates web search terms, as Postgres reads them, to an FTS5 query. Words and quoted phrases
+// must all match unless or stands between them, and -excluded words or phrases must not match.
+// It is empty when nothing is left to match
+func ftsQuery(terms string) string {
+	var included, excluded []string
+	pendingOr := false
+	for rest := strings.TrimSpace(terms); rest != ""; rest = strings.TrimSpace(rest) {
+		exclude := false
+		if strings.HasPrefix(rest, "-") {
+			exclude = true
+			rest = rest[1:]
+		}
+
+		var term string
+		quoted := strings.HasPrefix(rest, `"`)
+		if quoted {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				term, rest = rest[1:], ""
+			} else {
+				term, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				term, rest = rest, ""
+			} else {
+				term, rest = rest[:end], rest[end:]
+			}
+		}
+
+		if !strings.ContainsFunc(term, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+
+		// Every term is quoted, so FTS5 never reads it as an operator or a column filter
+		phrase := `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+		switch {
+		case exclude:
+			excluded = append(excluded, phrase)
+		case !quoted && strings.EqualFold(term, "or"):
+			pendingOr = len(included) > 0
+		default:
+			if pendingOr {
+				included = append(included, "OR")
+				pendingOr = false
+			}
+			included = append(included, phrase)
+		}
+	}
+
+	if len(included) == 0 {
+		return ""
+	}
+
+	query := "(" + strings.Join(included, " ") + ")"
+	for _, phrase := range excluded {
+		query += " NOT " + phrase
+	}
+	return query
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		terms    string
+		expected string
+	}{
+		{terms: "go generics", expected: `("go" "generics")`},
+		{terms: `"go modules" or vendoring`, expected: `("go modules" OR "vendoring")`},
+		{terms: `go -vendoring -"gopath mode"`, expected: `("go") NOT "vendoring" NOT "gopath mode"`},
+		{terms: `or go or`, expected: `("go")`},
+		{terms: `say"hi" NEAR`, expected: `("say""hi""" "NEAR")`},
+		{terms: `"unterminated phrase`, expected: `("unterminated phrase")`},
+		{terms: `-go`, expected: ""},
+		{terms: ` - "" !! `, expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ftsQuery(tt.terms), tt.terms)
+	}
+}
+
+func TestDialect_Adapt(t *testing.T) {
+	at := time.Date(2025, 10, 21, 14, 30, 5, 123456789, time.FixedZone("CEST", 2*60*60))
+	var missing *time.Time
+
+	query, args := DialectSQLite.adapt("UPDATE posts SET updated_at = CURRENT_TIMESTAMP WHERE publish_at <= $1 FOR UPDATE", []any{at, &at, missing, 3})
+	assert.Equal(t, "UPDATE posts SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE publish_at <= $1 ", query)
+	assert.Equal(t, []any{"2025-10-21 12:30:05.123", "2025-10-21 12:30:05.123", nil, 3}, args)
+
+	query, args = DialectPostgres.adapt("SELECT $1 FOR UPDATE", []any{at, &at, missing, 3})
+	assert.Equal(t, "SELECT $1 FOR UPDATE", query)
+	assert.Equal(t, []any{at.UTC(), at.UTC(), nil, 3}, args, "times are sent in UTC, a TIMESTAMP would drop their offset")
+}
+
+func TestStatementName(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: queryCreatePost, expected: "INSERT blog_posts"},
+		{query: queryDeletePost, expected: "DELETE blog_posts"},
+		{query: "\n\t\tUPDATE tags\n\t\tSET name = $2\n\t\tWHERE id = $1", expected: "UPDATE tags"},
+		{query: "SELECT EXISTS (SELECT 1 FROM tags WHERE id = $1)", expected: "SELECT tags"},
+		{query: queryFirstPostsPageWithCommentCount, expected: "SELECT blog_posts"},
+		{query: "WITH roots AS (\n\t\tSELECT id FROM comments WHERE blog_post_id = $1\n\t) SELECT * FROM roots", expected: "SELECT roots"},
+		{query: "INSERT INTO post_tags (blog_post_id, tag_id) SELECT $1, id FROM tags", expected: "INSERT post_tags"},
+		{query: "SELECT 1", expected: "SELECT"},
+		{query: "PRAGMA foreign_keys", expected: "SQL"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, statementName(tt.query), tt.query)
+	}
+}
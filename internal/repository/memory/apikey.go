@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+type apiKeyRepository struct {
+	store *Store
+}
+
+func NewAPIKeyRepository(store *Store) repository.APIKeyRepository {
+	return &apiKeyRepository{store: store}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (*response.APIKeyResponse, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[key.UserID]; !ok {
+		return nil, errors.Join(app_err.ErrNotFound, errors.New("user not found"))
+	}
+
+	r.store.lastAPIKeyID++
+	key.ID = r.store.lastAPIKeyID
+	key.CreatedAt = now()
+
+	stored := *key
+	stored.Scopes = slices.Clone(key.Scopes)
+	stored.ExpiresAt = copyTime(key.ExpiresAt)
+	stored.User = nil
+	r.store.apiKeys[stored.ID] = &stored
+	return key.ToAPIKeyResponse(), nil
+}
+
+func (r *apiKeyRepository) GetAPIKeys(ctx context.Context) ([]*response.APIKeyResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	keys := make([]*response.APIKeyResponse, 0, len(r.store.apiKeys))
+	for _, key := range r.store.apiKeys {
+		keys = append(keys, key.ToAPIKeyResponse())
+	}
+	slices.SortFunc(keys, func(a, b *response.APIKeyResponse) int {
+		return a.ID - b.ID
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey stops the key from authenticating, revoked keys are kept so they still show up when listing
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key, ok := r.store.apiKeys[id]
+	if !ok || key.RevokedAt != nil {
+		return app_err.ErrNotFound
+	}
+
+	revokedAt := now()
+	key.RevokedAt = &revokedAt
+	return nil
+}
+
+// AuthenticateAPIKey finds the active key with the given hash, along with the user it acts for,
+// and records it was used at now. Unknown, revoked and expired keys are all not found
+func (r *apiKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string, usedAt time.Time) (*model.APIKey, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	usedAt = usedAt.UTC()
+	for _, key := range r.store.apiKeys {
+		if key.KeyHash != keyHash || key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(usedAt)) {
+			continue
+		}
+		user, ok := r.store.users[key.UserID]
+		if !ok {
+			continue
+		}
+
+		key.LastUsedAt = &usedAt
+		return &model.APIKey{
+			ID:         key.ID,
+			UserID:     key.UserID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     slices.Clone(key.Scopes),
+			LastUsedAt: &usedAt,
+			User:       &model.User{ID: user.ID, Username: user.Username, Role: user.Role},
+		}, nil
+	}
+
+	return nil, app_err.ErrNotFound
+}
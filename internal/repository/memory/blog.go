@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+type blogRepository struct {
+	store *Store
+}
+
+func NewBlogRepository(store *Store) repository.BlogRepository {
+	return &blogRepository{store: store}
+}
+
+// GetAllPostsWithCommentCount reads a single page of published posts, newest first, and returns the cursor of the
+// next page which is empty when there are no more posts to read. Only posts matching the tag filter are read
+func (r *blogRepository) GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) ([]*response.PostWithCommentCountResponse, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	var posts []*model.Post
+	for _, post := range r.store.posts {
+		if post.Status == model.PostStatusPublished && afterCursor(post.CreatedAt, post.ID, page.Cursor) && r.matchesTags(post.ID, filter) {
+			posts = append(posts, post)
+		}
+	}
+	slices.SortFunc(posts, func(a, b *model.Post) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+
+	hasNext := len(posts) > page.Limit
+	if hasNext {
+		posts = posts[:page.Limit]
+	}
+
+	resp := make([]*response.PostWithCommentCountResponse, len(posts))
+	for idx, stored := range posts {
+		// The listing leaves the content out, like the database query does
+		post := *stored
+		post.Content = ""
+		post.Author = r.store.author(post.AuthorID)
+		post.Tags = r.store.postTagNames(post.ID)
+		resp[idx] = post.ToPostWithCommentCount(r.store.commentCount(post.ID))
+	}
+
+	var nextCursor string
+	if hasNext && len(posts) > 0 {
+		last := posts[len(posts)-1]
+		nextCursor = (&model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+	}
+
+	return resp, nextCursor, nil
+}
+
+// matchesTags tells whether the post carries any, or all, of the filtered tags
+func (r *blogRepository) matchesTags(postID int, filter model.TagFilter) bool {
+	if len(filter.Tags) == 0 {
+		return true
+	}
+
+	matches := 0
+	for _, name := range r.store.postTagNames(postID) {
+		if slices.Contains(filter.Tags, name) {
+			matches++
+		}
+	}
+
+	if filter.Match == model.TagMatchAll {
+		return matches == len(filter.Tags)
+	}
+	return matches > 0
+}
+
+// GetPostWithComments reads the published post and embeds only the given page of its comments, laid out as requested
+func (r *blogRepository) GetPostWithComments(ctx context.Context, requestPostID int, commentsPage model.Page, view model.CommentView) (*response.PostWithCommentsResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored, ok := r.store.posts[requestPostID]
+	if !ok || stored.Status != model.PostStatusPublished {
+		return nil, app_err.ErrNotFound
+	}
+
+	post := *stored
+	post.Author = r.store.author(post.AuthorID)
+	post.Tags = r.store.postTagNames(post.ID)
+	comments, nextCursor := r.getCommentsPage(post.ID, commentsPage, view)
+
+	resp := post.ToPostWithComments()
+	resp.CommentCount = r.store.commentCount(post.ID)
+	resp.Comments = comments
+	if nextCursor != nil {
+		encoded := nextCursor.Encode()
+		resp.NextCommentsCursor = &encoded
+	}
+
+	return resp, nil
+}
+
+// CreatePost stores the post along with its first revision and its tags
+func (r *blogRepository) CreatePost(ctx context.Context, post *model.Post) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	createdAt := now()
+	r.store.lastPostID++
+	stored := &model.Post{
+		ID:        r.store.lastPostID,
+		Title:     post.Title,
+		Slug:      r.freeSlug(0, post.Title),
+		AuthorID:  copyInt(post.AuthorID),
+		Content:   post.Content,
+		Status:    post.Status,
+		PublishAt: copyTime(post.PublishAt),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+	r.store.posts[stored.ID] = stored
+	r.store.slugs[stored.Slug] = stored.ID
+	r.store.revisions[stored.ID] = []model.Revision{{
+		PostID:    stored.ID,
+		Number:    1,
+		Title:     stored.Title,
+		Content:   stored.Content,
+		CreatedAt: createdAt,
+	}}
+	r.attachTags(stored.ID, post.Tags)
+
+	return stored.ID, nil
+}
+
+// UpdatePost changes the given fields of a post, nil fields are kept unchanged.
+// A new revision is appended whenever the title or the content changes
+func (r *blogRepository) UpdatePost(ctx context.Context, id int, changes model.PostChanges) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	_, err := r.updatePost(id, changes, nil)
+	return err
+}
+
+// updatePost applies the changes and returns the latest revision of the post, the store must be locked
+func (r *blogRepository) updatePost(id int, changes model.PostChanges, restoredFrom *int) (int, error) {
+	post, ok := r.store.posts[id]
+	if !ok {
+		return 0, app_err.ErrNotFound
+	}
+
+	// A publish time alone reschedules the post, which only scheduled posts can be
+	if changes.Status == nil && changes.PublishAt != nil && post.Status != model.PostStatusScheduled {
+		return 0, errors.Join(app_err.ErrInvalidInput, errors.New("publish_at requires the scheduled status"))
+	}
+
+	currentTitle, currentContent := post.Title, post.Content
+	if changes.Title != nil {
+		post.Title = *changes.Title
+	}
+	if changes.Content != nil {
+		post.Content = *changes.Content
+	}
+	// The publish time is replaced whenever the status changes, so it never outlives the scheduled status
+	if changes.Status != nil || changes.PublishAt != nil {
+		post.PublishAt = copyTime(changes.PublishAt)
+	}
+	if changes.Status != nil {
+		post.Status = *changes.Status
+	}
+	post.UpdatedAt = now()
+
+	if post.Title != currentTitle {
+		r.updateSlug(post, post.Title)
+	}
+
+	revisions := r.store.revisions[id]
+	revision := len(revisions)
+	if post.Title == currentTitle && post.Content == currentContent {
+		return revision, nil
+	}
+
+	revision++
+	r.store.revisions[id] = append(revisions, model.Revision{
+		PostID:       id,
+		Number:       revision,
+		Title:        post.Title,
+		Content:      post.Content,
+		RestoredFrom: copyInt(restoredFrom),
+		CreatedAt:    post.UpdatedAt,
+	})
+
+	return revision, nil
+}
+
+// GetPostAuthorID reads who wrote the post, whatever its status, nil when the post has no author
+func (r *blogRepository) GetPostAuthorID(ctx context.Context, id int) (*int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	post, ok := r.store.posts[id]
+	if !ok {
+		return nil, app_err.ErrNotFound
+	}
+
+	return copyInt(post.AuthorID), nil
+}
+
+// DeletePost removes the post along with its comments, revisions, tags and slugs
+func (r *blogRepository) DeletePost(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.posts[id]; !ok {
+		return app_err.ErrNotFound
+	}
+
+	delete(r.store.posts, id)
+	delete(r.store.revisions, id)
+	delete(r.store.postTags, id)
+	for commentID, c := range r.store.comments {
+		if c.PostID == id {
+			delete(r.store.comments, commentID)
+		}
+	}
+	for postSlug, postID := range r.store.slugs {
+		if postID == id {
+			delete(r.store.slugs, postSlug)
+		}
+	}
+
+	return nil
+}
+
+// PublishScheduledPosts publishes every scheduled post whose publish time is not after now
+func (r *blogRepository) PublishScheduledPosts(ctx context.Context, publishTime time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	published := 0
+	for _, post := range r.store.posts {
+		if post.Status != model.PostStatusScheduled || post.PublishAt == nil || post.PublishAt.After(publishTime) {
+			continue
+		}
+		post.Status = model.PostStatusPublished
+		post.UpdatedAt = now()
+		published++
+	}
+
+	return published, nil
+}
+
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	copied := *value
+	return &copied
+}
+
+// copyTime copies a time in UTC, as the databases keep them
+func copyTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	copied := value.UTC()
+	return &copied
+}
@@ -0,0 +1,265 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// GetComments reads a single page of the post comments, newest first, and returns the cursor of the next page
+// which is empty when there are no more comments to read
+func (r *blogRepository) GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Comments of posts that are not publicly visible are hidden along with the post
+	post, ok := r.store.posts[blogPostID]
+	if !ok || post.Status != model.PostStatusPublished {
+		return nil, "", app_err.ErrNotFound
+	}
+
+	comments, nextCursor := r.getCommentsPage(blogPostID, page, view)
+
+	var encoded string
+	if nextCursor != nil {
+		encoded = nextCursor.Encode()
+	}
+
+	return comments, encoded, nil
+}
+
+func (r *blogRepository) GetComment(ctx context.Context, blogPostID, commentID int) (*response.CommentResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	c, ok := r.liveComment(blogPostID, commentID)
+	if !ok {
+		return nil, app_err.ErrNotFound
+	}
+
+	return r.toModel(c).ToCommentResponse(), nil
+}
+
+// getCommentsPage reads a page of the comments of a post, the store must be locked
+func (r *blogRepository) getCommentsPage(blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, *model.Cursor) {
+	if view == model.CommentViewTree {
+		return r.getCommentThreadsPage(blogPostID, page)
+	}
+
+	comments := r.newestComments(blogPostID, func(c *comment) bool {
+		return afterCursor(c.CreatedAt, c.ID, page.Cursor)
+	})
+
+	var nextCursor *model.Cursor
+	if len(comments) > page.Limit {
+		comments = comments[:page.Limit]
+		last := comments[len(comments)-1]
+		nextCursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	resp := make([]*response.CommentResponse, len(comments))
+	for idx, c := range comments {
+		resp[idx] = r.toModel(c).ToCommentResponse()
+	}
+
+	return resp, nextCursor
+}
+
+// getCommentThreadsPage paginates the threads by their root comment, only the oldest replies of the selected roots
+// are read along
+func (r *blogRepository) getCommentThreadsPage(blogPostID int, page model.Page) ([]*response.CommentResponse, *model.Cursor) {
+	roots := r.newestComments(blogPostID, func(c *comment) bool {
+		return c.ParentID == nil && afterCursor(c.CreatedAt, c.ID, page.Cursor)
+	})
+
+	var nextCursor *model.Cursor
+	if len(roots) > page.Limit {
+		roots = roots[:page.Limit]
+		last := roots[len(roots)-1]
+		nextCursor = &model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	rootIDs := make(map[int]bool, len(roots))
+	rootComments := make([]model.Comment, len(roots))
+	for idx, root := range roots {
+		rootIDs[root.ID] = true
+		rootComments[idx] = *r.toModel(root)
+	}
+
+	var replies []model.Comment
+	for _, reply := range r.newestComments(blogPostID, func(c *comment) bool {
+		return c.RootID != nil && rootIDs[*c.RootID]
+	}) {
+		replies = append(replies, *r.toModel(reply))
+	}
+
+	return model.ToCommentThreads(rootComments, replies, page.Limit), nextCursor
+}
+
+// GetReplies reads a single page of the replies of a thread, oldest first, and returns the cursor of the next page
+// which is empty when there are no more replies to read
+func (r *blogRepository) GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) ([]*response.CommentResponse, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	// Threads of posts that are not publicly visible are hidden along with the post
+	post, ok := r.store.posts[blogPostID]
+	if !ok || post.Status != model.PostStatusPublished {
+		return nil, "", app_err.ErrNotFound
+	}
+	root, ok := r.liveComment(blogPostID, rootCommentID)
+	if !ok || root.ParentID != nil {
+		return nil, "", app_err.ErrNotFound
+	}
+
+	replies := r.newestComments(blogPostID, func(c *comment) bool {
+		return c.RootID != nil && *c.RootID == rootCommentID &&
+			(page.Cursor == nil || newestFirst(c.CreatedAt, c.ID, page.Cursor.CreatedAt, page.Cursor.ID) < 0)
+	})
+	slices.Reverse(replies)
+
+	var nextCursor string
+	if len(replies) > page.Limit {
+		replies = replies[:page.Limit]
+		last := replies[len(replies)-1]
+		nextCursor = (&model.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}).Encode()
+	}
+
+	resp := make([]*response.CommentResponse, len(replies))
+	for idx, c := range replies {
+		resp[idx] = r.toModel(c).ToCommentResponse()
+	}
+
+	return resp, nextCursor, nil
+}
+
+// newestComments lists the comments of a post which are not deleted and match the filter, newest first
+func (r *blogRepository) newestComments(blogPostID int, filter func(c *comment) bool) []*comment {
+	var comments []*comment
+	for _, c := range r.store.comments {
+		if c.PostID == blogPostID && c.DeletedAt == nil && filter(c) {
+			comments = append(comments, c)
+		}
+	}
+	slices.SortFunc(comments, func(a, b *comment) int {
+		return newestFirst(a.CreatedAt, a.ID, b.CreatedAt, b.ID)
+	})
+	return comments
+}
+
+// AddComment adds a comment to a published post, replies inherit the thread of their parent which must belong
+// to the same post
+func (r *blogRepository) AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	post, ok := r.store.posts[blogPostID]
+	published := ok && post.Status == model.PostStatusPublished
+
+	added := &comment{
+		Comment: model.Comment{
+			PostID:    blogPostID,
+			Content:   content,
+			CreatedAt: now(),
+		},
+		AuthorID: &authorID,
+	}
+
+	if parentCommentID == nil {
+		if !published {
+			return 0, app_err.ErrNotFound
+		}
+	} else {
+		parent, ok := r.liveComment(blogPostID, *parentCommentID)
+		if !ok || !published {
+			return 0, errors.Join(app_err.ErrInvalidInput, errors.New("parent comment not found in this post"))
+		}
+
+		added.ParentID = copyInt(&parent.ID)
+		added.RootID = copyInt(parent.RootID)
+		if added.RootID == nil {
+			added.RootID = copyInt(&parent.ID)
+		}
+		added.Depth = parent.Depth + 1
+	}
+
+	r.store.lastCommentID++
+	added.ID = r.store.lastCommentID
+	r.store.comments[added.ID] = added
+
+	return added.ID, nil
+}
+
+// UpdateComment edits a comment of a published post, the comments of the other posts are hidden along with them
+func (r *blogRepository) UpdateComment(ctx context.Context, blogPostID, commentID int, content string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	post, ok := r.store.posts[blogPostID]
+	if !ok || post.Status != model.PostStatusPublished {
+		return app_err.ErrNotFound
+	}
+	c, ok := r.liveComment(blogPostID, commentID)
+	if !ok {
+		return app_err.ErrNotFound
+	}
+
+	updatedAt := now()
+	c.Content = content
+	c.UpdatedAt = &updatedAt
+	return nil
+}
+
+// DeleteComment soft deletes the comment so it disappears from reads and counts, its replies are deleted along
+func (r *blogRepository) DeleteComment(ctx context.Context, blogPostID, commentID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	post, ok := r.store.posts[blogPostID]
+	if !ok || post.Status != model.PostStatusPublished {
+		return app_err.ErrNotFound
+	}
+	c, ok := r.liveComment(blogPostID, commentID)
+	if !ok {
+		return app_err.ErrNotFound
+	}
+
+	deletedAt := now()
+	thread := []*comment{c}
+	for len(thread) > 0 {
+		current := thread[0]
+		thread = thread[1:]
+		current.DeletedAt = &deletedAt
+
+		for _, reply := range r.store.comments {
+			if reply.ParentID != nil && *reply.ParentID == current.ID && reply.DeletedAt == nil {
+				thread = append(thread, reply)
+			}
+		}
+	}
+
+	return nil
+}
+
+// liveComment finds a comment of the post which is not deleted, the store must be locked
+func (r *blogRepository) liveComment(blogPostID, commentID int) (*comment, bool) {
+	c, ok := r.store.comments[commentID]
+	if !ok || c.PostID != blogPostID || c.DeletedAt != nil {
+		return nil, false
+	}
+	return c, true
+}
+
+// toModel copies the stored comment along with its author
+func (r *blogRepository) toModel(c *comment) *model.Comment {
+	copied := c.Comment
+	copied.ParentID = copyInt(c.ParentID)
+	copied.RootID = copyInt(c.RootID)
+	copied.UpdatedAt = copyTime(c.UpdatedAt)
+	copied.Author = r.store.author(c.AuthorID)
+	return &copied
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repositories {
+		store := NewStore()
+		return repositorytest.Repositories{
+			Blog:    NewBlogRepository(store),
+			Users:   NewUserRepository(store),
+			APIKeys: NewAPIKeyRepository(store),
+		}
+	})
+}
+
+func TestBlogRepository_Concurrency(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	blog := NewBlogRepository(store)
+	postID, err := blog.CreatePost(ctx, &model.Post{Title: "Busy", Content: "Hello world", Status: model.PostStatusPublished})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for idx := 0; idx < 50; idx++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := blog.AddComment(ctx, postID, nil, 1, "Nice post")
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, err := blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 10}, model.TagFilter{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	post, err := blog.GetPostWithComments(ctx, postID, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, post.CommentCount)
+}
+
+func TestSearchTerms(t *testing.T) {
+	included, excluded := parseTerms(`"Go Modules" or -vendoring`)
+	assert.Equal(t, []string{"go", "modules"}, included)
+	assert.Equal(t, []string{"vendoring"}, excluded)
+
+	assert.Equal(t, "Using <mark>Go</mark> modules", highlight("Using Go modules", []string{"go"}, 0))
+	assert.Equal(t, "one two", highlight("one two three", nil, 2))
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt; <mark>go&lt;b&gt;</mark>", highlight("<script>alert(1)</script> go<b>", []string{"go"}, 0))
+}
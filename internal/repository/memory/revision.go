@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// GetRevisions lists the revisions of a post, newest first, without their content. Unless told to include them,
+// the revisions of unpublished posts are not found
+func (r *blogRepository) GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) ([]*response.RevisionResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if !r.visible(blogPostID, includeUnpublished) {
+		return nil, app_err.ErrNotFound
+	}
+
+	stored := r.store.revisions[blogPostID]
+	revisions := make([]*response.RevisionResponse, 0, len(stored))
+	for idx := len(stored) - 1; idx >= 0; idx-- {
+		revision := stored[idx]
+		revision.Content = ""
+		revisions = append(revisions, revision.ToRevisionResponse())
+	}
+
+	return revisions, nil
+}
+
+// GetRevision reads a revision of a post with its content. Unless told to include them, the revisions of
+// unpublished posts are not found
+func (r *blogRepository) GetRevision(ctx context.Context, blogPostID, revisionNumber int, includeUnpublished bool) (*response.RevisionResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	revision, ok := r.getRevision(blogPostID, revisionNumber)
+	if !ok || !r.visible(blogPostID, includeUnpublished) {
+		return nil, app_err.ErrNotFound
+	}
+
+	return revision.ToRevisionResponse(), nil
+}
+
+// RestoreRevision brings back the title and content of a past revision, recording it as a new revision
+// which is returned
+func (r *blogRepository) RestoreRevision(ctx context.Context, blogPostID, revisionNumber int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	revision, ok := r.getRevision(blogPostID, revisionNumber)
+	if !ok {
+		return 0, app_err.ErrNotFound
+	}
+
+	changes := model.PostChanges{Title: &revision.Title, Content: &revision.Content}
+	return r.updatePost(blogPostID, changes, &revision.Number)
+}
+
+// getRevision copies a revision of the post, the store must be locked
+func (r *blogRepository) getRevision(blogPostID, revisionNumber int) (*model.Revision, bool) {
+	for _, revision := range r.store.revisions[blogPostID] {
+		if revision.Number == revisionNumber {
+			revision.RestoredFrom = copyInt(revision.RestoredFrom)
+			return &revision, true
+		}
+	}
+	return nil, false
+}
+
+// visible tells whether the post exists and, unless unpublished posts are included, is published. The store
+// must be locked
+func (r *blogRepository) visible(blogPostID int, includeUnpublished bool) bool {
+	post, ok := r.store.posts[blogPostID]
+	return ok && (includeUnpublished || post.Status == model.PostStatusPublished)
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// snippetWords is how many words of the content are kept in a search result snippet
+const snippetWords = 30
+
+// SearchPosts reads a single page of the published posts matching the search terms, best match first, and returns
+// the cursor of the next page which is empty when there are no more results. Without the full text search of the
+// database, words match case insensitively anywhere in the text, -excluded words are left out and or is ignored
+func (r *blogRepository) SearchPosts(ctx context.Context, terms string, page model.SearchPage) ([]*response.SearchResultResponse, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	included, excluded := parseTerms(terms)
+	if len(included) == 0 {
+		return []*response.SearchResultResponse{}, "", nil
+	}
+
+	var results []*model.SearchResult
+	for _, post := range r.store.posts {
+		if post.Status != model.PostStatusPublished {
+			continue
+		}
+
+		text := post.Title + " " + post.Content
+		rank, matched := textRank(text, included, excluded)
+
+		// The best matching comment adds half of its rank
+		var commentRank float64
+		matchingComments := 0
+		for _, c := range r.store.comments {
+			if c.PostID != post.ID || c.DeletedAt != nil {
+				continue
+			}
+			if cRank, ok := textRank(c.Content, included, excluded); ok {
+				matchingComments++
+				commentRank = max(commentRank, cRank)
+			}
+		}
+
+		if !matched && matchingComments == 0 {
+			continue
+		}
+
+		result := &model.SearchResult{
+			PostID:           post.ID,
+			Title:            post.Title,
+			Slug:             post.Slug,
+			Author:           r.store.author(post.AuthorID),
+			Rank:             rank + commentRank/2,
+			TitleHighlight:   highlight(post.Title, included, 0),
+			Snippet:          highlight(post.Content, included, snippetWords),
+			MatchingComments: matchingComments,
+			CreatedAt:        post.CreatedAt,
+		}
+		if page.Cursor == nil || result.Rank < page.Cursor.Rank || (result.Rank == page.Cursor.Rank && result.PostID < page.Cursor.ID) {
+			results = append(results, result)
+		}
+	}
+
+	slices.SortFunc(results, func(a, b *model.SearchResult) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(b.PostID, a.PostID))
+	})
+
+	hasNext := len(results) > page.Limit
+	if hasNext {
+		results = results[:page.Limit]
+	}
+
+	resp := make([]*response.SearchResultResponse, len(results))
+	for idx, result := range results {
+		resp[idx] = result.ToSearchResultResponse()
+	}
+
+	var nextCursor string
+	if hasNext && len(results) > 0 {
+		last := results[len(results)-1]
+		nextCursor = (&model.SearchCursor{Rank: last.Rank, ID: last.PostID}).Encode()
+	}
+
+	return resp, nextCursor, nil
+}
+
+// parseTerms splits the search terms into the lower cased words that must, and must not, be in the text
+func parseTerms(terms string) (included, excluded []string) {
+	for _, word := range strings.Fields(strings.ToLower(terms)) {
+		word = strings.Trim(word, `"`)
+		if word == "or" {
+			continue
+		}
+		if exclude, found := strings.CutPrefix(word, "-"); found {
+			if exclude != "" {
+				excluded = append(excluded, exclude)
+			}
+			continue
+		}
+		if word != "" {
+			included = append(included, word)
+		}
+	}
+	return included, excluded
+}
+
+// textRank tells whether the text holds every included word and none of the excluded ones, ranking it by how
+// many times the included words show up
+func textRank(text string, included, excluded []string) (float64, bool) {
+	lower := strings.ToLower(text)
+	for _, word := range excluded {
+		if strings.Contains(lower, word) {
+			return 0, false
+		}
+	}
+
+	occurrences := 0
+	for _, word := range included {
+		count := strings.Count(lower, word)
+		if count == 0 {
+			return 0, false
+		}
+		occurrences += count
+	}
+
+	return float64(occurrences) / float64(occurrences+1), true
+}
+
+// highlight marks the words of the text holding any of the included words, keeping only the first limit words
+// when limit is positive. The words are escaped, so posts cannot inject HTML into the snippets
+func highlight(text string, included []string, limit int) string {
+	words := strings.Fields(text)
+	if limit > 0 && len(words) > limit {
+		words = words[:limit]
+	}
+
+	for idx, word := range words {
+		lower := strings.ToLower(word)
+		words[idx] = html.EscapeString(word)
+		if slices.ContainsFunc(included, func(term string) bool { return strings.Contains(lower, term) }) {
+			words[idx] = "<mark>" + words[idx] + "</mark>"
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/slug"
+)
+
+// ResolveSlug finds the published post a current or past slug belongs to, along with its current slug
+func (r *blogRepository) ResolveSlug(ctx context.Context, postSlug string) (int, string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	postID, ok := r.store.slugs[postSlug]
+	if !ok {
+		return 0, "", app_err.ErrNotFound
+	}
+
+	post := r.store.posts[postID]
+	if post.Status != model.PostStatusPublished {
+		return 0, "", app_err.ErrNotFound
+	}
+
+	return post.ID, post.Slug, nil
+}
+
+// updateSlug gives the post a slug matching its new title, the previous slug is kept for redirects
+func (r *blogRepository) updateSlug(post *model.Post, title string) {
+	postSlug := r.freeSlug(post.ID, title)
+	if postSlug == post.Slug {
+		return
+	}
+
+	r.store.slugs[postSlug] = post.ID
+	post.Slug = postSlug
+}
+
+// freeSlug picks the slug of the title, adding a numeric suffix when another post already used it.
+// A slug the post itself used before is handed back to it
+func (r *blogRepository) freeSlug(postID int, title string) string {
+	base := slug.Make(title)
+	candidate := base
+	for suffix := 2; ; suffix++ {
+		owner, used := r.store.slugs[candidate]
+		if !used || owner == postID {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
@@ -0,0 +1,103 @@
+// Package memory keeps the blog in the memory of the process, behaving like the database backed repositories.
+// It backs tests and lets the service run without a database, everything is lost when the process stops
+package memory
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+)
+
+// Store holds the data shared by the repositories of a single in-memory blog, they can be used concurrently
+type Store struct {
+	mu sync.RWMutex
+
+	users     map[int]*model.User
+	apiKeys   map[int]*model.APIKey
+	posts     map[int]*model.Post
+	comments  map[int]*comment
+	revisions map[int][]model.Revision
+	tags      map[int]string
+	postTags  map[int]map[int]bool
+	slugs     map[string]int
+
+	lastUserID    int
+	lastAPIKeyID  int
+	lastPostID    int
+	lastCommentID int
+	lastTagID     int
+}
+
+// comment is a stored comment, deleted comments are kept like the database soft deletes them
+type comment struct {
+	model.Comment
+	AuthorID  *int
+	DeletedAt *time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:     make(map[int]*model.User),
+		apiKeys:   make(map[int]*model.APIKey),
+		posts:     make(map[int]*model.Post),
+		comments:  make(map[int]*comment),
+		revisions: make(map[int][]model.Revision),
+		tags:      make(map[int]string),
+		postTags:  make(map[int]map[int]bool),
+		slugs:     make(map[string]int),
+	}
+}
+
+// now matches the precision of the database timestamps, so cursors compare the same way on both
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// author reads the public profile of a user, nil when there is no such user
+func (s *Store) author(userID *int) *model.Author {
+	if userID == nil {
+		return nil
+	}
+	user, ok := s.users[*userID]
+	if !ok {
+		return nil
+	}
+	return &model.Author{ID: user.ID, Username: user.Username, DisplayName: user.DisplayName}
+}
+
+// postTagNames lists the names of the tags of a post sorted by name, nil when it has none
+func (s *Store) postTagNames(postID int) []string {
+	var names []string
+	for tagID := range s.postTags[postID] {
+		names = append(names, s.tags[tagID])
+	}
+	slices.Sort(names)
+	return names
+}
+
+// commentCount counts the comments of a post which are not deleted
+func (s *Store) commentCount(postID int) int {
+	count := 0
+	for _, c := range s.comments {
+		if c.PostID == postID && c.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// newestFirst orders by (created_at, id) descending, as the keyset paginated listings are
+func newestFirst(aCreatedAt time.Time, aID int, bCreatedAt time.Time, bID int) int {
+	return cmp.Or(bCreatedAt.Compare(aCreatedAt), cmp.Compare(bID, aID))
+}
+
+// afterCursor tells whether an item comes after the cursor in a newest first listing, every item does without one
+func afterCursor(createdAt time.Time, id int, cursor *model.Cursor) bool {
+	if cursor == nil {
+		return true
+	}
+	return newestFirst(createdAt, id, cursor.CreatedAt, cursor.ID) > 0
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+// GetTags lists every tag by name along with how many published posts carry it
+func (r *blogRepository) GetTags(ctx context.Context) ([]*response.TagResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tags := make([]*response.TagResponse, 0, len(r.store.tags))
+	for id, name := range r.store.tags {
+		tag := &model.Tag{ID: id, Name: name}
+		for postID, postTags := range r.store.postTags {
+			if postTags[id] && r.store.posts[postID].Status == model.PostStatusPublished {
+				tag.PostCount++
+			}
+		}
+		tags = append(tags, tag.ToTagResponse())
+	}
+	slices.SortFunc(tags, func(a, b *response.TagResponse) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return tags, nil
+}
+
+func (r *blogRepository) CreateTag(ctx context.Context, name string) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.tagID(name); ok {
+		return 0, errors.Join(app_err.ErrConflict, errors.New("tag already exists"))
+	}
+
+	return r.addTag(name), nil
+}
+
+func (r *blogRepository) RenameTag(ctx context.Context, id int, name string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.tags[id]; !ok {
+		return app_err.ErrNotFound
+	}
+	if existing, ok := r.tagID(name); ok && existing != id {
+		return errors.Join(app_err.ErrConflict, errors.New("another tag already has this name, merge them instead"))
+	}
+
+	r.store.tags[id] = name
+	return nil
+}
+
+// MergeTags moves every post of the source tag to the target tag and removes the source tag
+func (r *blogRepository) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, id := range []int{sourceID, targetID} {
+		if _, ok := r.store.tags[id]; !ok {
+			return app_err.ErrNotFound
+		}
+	}
+
+	for _, postTags := range r.store.postTags {
+		if postTags[sourceID] {
+			postTags[targetID] = true
+		}
+	}
+	r.deleteTag(sourceID)
+
+	return nil
+}
+
+// attachTags creates the tags that do not exist yet and links all of them to the post, the store must be locked
+func (r *blogRepository) attachTags(postID int, tags []string) {
+	for _, name := range tags {
+		tagID, ok := r.tagID(name)
+		if !ok {
+			tagID = r.addTag(name)
+		}
+
+		if r.store.postTags[postID] == nil {
+			r.store.postTags[postID] = make(map[int]bool)
+		}
+		r.store.postTags[postID][tagID] = true
+	}
+}
+
+func (r *blogRepository) tagID(name string) (int, bool) {
+	for id, existing := range r.store.tags {
+		if existing == name {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (r *blogRepository) addTag(name string) int {
+	r.store.lastTagID++
+	r.store.tags[r.store.lastTagID] = name
+	return r.store.lastTagID
+}
+
+func (r *blogRepository) deleteTag(id int) {
+	delete(r.store.tags, id)
+	for _, postTags := range r.store.postTags {
+		delete(postTags, id)
+	}
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+// CreateUser stores the user with its role, users without one start as commenters
+func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (*response.UserResponse, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.Username == user.Username {
+			return nil, errors.Join(app_err.ErrConflict, errors.New("username already taken"))
+		}
+	}
+
+	if user.Role == "" {
+		user.Role = model.RoleCommenter
+	}
+	r.store.lastUserID++
+	user.ID = r.store.lastUserID
+	user.CreatedAt = now()
+
+	stored := *user
+	r.store.users[stored.ID] = &stored
+	return user.ToUserResponse(), nil
+}
+
+// GetUserByUsername reads the user along with its password hash, it must never be sent back as is
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+
+	return nil, app_err.ErrNotFound
+}
+
+// GetUser reads the user without its password hash
+func (r *userRepository) GetUser(ctx context.Context, id int) (*model.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, app_err.ErrNotFound
+	}
+
+	copied := *user
+	copied.PasswordHash = ""
+	return &copied, nil
+}
+
+func (r *userRepository) GetUsers(ctx context.Context) ([]*response.UserResponse, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	users := make([]*response.UserResponse, 0, len(r.store.users))
+	for _, user := range r.store.users {
+		users = append(users, user.ToUserResponse())
+	}
+	slices.SortFunc(users, func(a, b *response.UserResponse) int {
+		return a.ID - b.ID
+	})
+
+	return users, nil
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id int, role model.Role) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return app_err.ErrNotFound
+	}
+
+	user.Role = role
+	return nil
+}
+
+// DeleteUser removes the user along with their API keys, the posts and comments they wrote are kept without an author
+func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[id]; !ok {
+		return app_err.ErrNotFound
+	}
+
+	delete(r.store.users, id)
+	for keyID, key := range r.store.apiKeys {
+		if key.UserID == id {
+			delete(r.store.apiKeys, keyID)
+		}
+	}
+	for _, post := range r.store.posts {
+		if post.AuthorID != nil && *post.AuthorID == id {
+			post.AuthorID = nil
+		}
+	}
+	for _, c := range r.store.comments {
+		if c.AuthorID != nil && *c.AuthorID == id {
+			c.AuthorID = nil
+		}
+	}
+
+	return nil
+}
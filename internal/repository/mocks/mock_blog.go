@@ -12,7 +12,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	model "github.com/aleszilagyi/prosig-blog/internal/model"
 	response "github.com/aleszilagyi/prosig-blog/internal/response"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -42,61 +44,330 @@ func (m *MockBlogRepository) EXPECT() *MockBlogRepositoryMockRecorder {
 }
 
 // AddComment mocks base method.
-func (m *MockBlogRepository) AddComment(ctx context.Context, blogPostID int, content string) (int, error) {
+func (m *MockBlogRepository) AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddComment", ctx, blogPostID, content)
+	ret := m.ctrl.Call(m, "AddComment", ctx, blogPostID, parentCommentID, authorID, content)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddComment indicates an expected call of AddComment.
-func (mr *MockBlogRepositoryMockRecorder) AddComment(ctx, blogPostID, content any) *gomock.Call {
+func (mr *MockBlogRepositoryMockRecorder) AddComment(ctx, blogPostID, parentCommentID, authorID, content any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockBlogRepository)(nil).AddComment), ctx, blogPostID, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockBlogRepository)(nil).AddComment), ctx, blogPostID, parentCommentID, authorID, content)
 }
 
 // CreatePost mocks base method.
-func (m *MockBlogRepository) CreatePost(ctx context.Context, title, content string) (int, error) {
+func (m *MockBlogRepository) CreatePost(ctx context.Context, post *model.Post) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreatePost", ctx, title, content)
+	ret := m.ctrl.Call(m, "CreatePost", ctx, post)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreatePost indicates an expected call of CreatePost.
-func (mr *MockBlogRepositoryMockRecorder) CreatePost(ctx, title, content any) *gomock.Call {
+func (mr *MockBlogRepositoryMockRecorder) CreatePost(ctx, post any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockBlogRepository)(nil).CreatePost), ctx, title, content)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePost", reflect.TypeOf((*MockBlogRepository)(nil).CreatePost), ctx, post)
+}
+
+// CreateTag mocks base method.
+func (m *MockBlogRepository) CreateTag(ctx context.Context, name string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTag", ctx, name)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTag indicates an expected call of CreateTag.
+func (mr *MockBlogRepositoryMockRecorder) CreateTag(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTag", reflect.TypeOf((*MockBlogRepository)(nil).CreateTag), ctx, name)
+}
+
+// DeleteComment mocks base method.
+func (m *MockBlogRepository) DeleteComment(ctx context.Context, blogPostID, commentID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteComment", ctx, blogPostID, commentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteComment indicates an expected call of DeleteComment.
+func (mr *MockBlogRepositoryMockRecorder) DeleteComment(ctx, blogPostID, commentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteComment", reflect.TypeOf((*MockBlogRepository)(nil).DeleteComment), ctx, blogPostID, commentID)
+}
+
+// DeletePost mocks base method.
+func (m *MockBlogRepository) DeletePost(ctx context.Context, id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePost", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePost indicates an expected call of DeletePost.
+func (mr *MockBlogRepositoryMockRecorder) DeletePost(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePost", reflect.TypeOf((*MockBlogRepository)(nil).DeletePost), ctx, id)
 }
 
 // GetAllPostsWithCommentCount mocks base method.
-func (m *MockBlogRepository) GetAllPostsWithCommentCount(ctx context.Context) ([]*response.PostWithCommentCountResponse, error) {
+func (m *MockBlogRepository) GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) ([]*response.PostWithCommentCountResponse, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllPostsWithCommentCount", ctx)
+	ret := m.ctrl.Call(m, "GetAllPostsWithCommentCount", ctx, page, filter)
 	ret0, _ := ret[0].([]*response.PostWithCommentCountResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllPostsWithCommentCount indicates an expected call of GetAllPostsWithCommentCount.
+func (mr *MockBlogRepositoryMockRecorder) GetAllPostsWithCommentCount(ctx, page, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPostsWithCommentCount", reflect.TypeOf((*MockBlogRepository)(nil).GetAllPostsWithCommentCount), ctx, page, filter)
+}
+
+// GetComment mocks base method.
+func (m *MockBlogRepository) GetComment(ctx context.Context, blogPostID, commentID int) (*response.CommentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComment", ctx, blogPostID, commentID)
+	ret0, _ := ret[0].(*response.CommentResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllPostsWithCommentCount indicates an expected call of GetAllPostsWithCommentCount.
-func (mr *MockBlogRepositoryMockRecorder) GetAllPostsWithCommentCount(ctx any) *gomock.Call {
+// GetComment indicates an expected call of GetComment.
+func (mr *MockBlogRepositoryMockRecorder) GetComment(ctx, blogPostID, commentID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockBlogRepository)(nil).GetComment), ctx, blogPostID, commentID)
+}
+
+// GetComments mocks base method.
+func (m *MockBlogRepository) GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) ([]*response.CommentResponse, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComments", ctx, blogPostID, page, view)
+	ret0, _ := ret[0].([]*response.CommentResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetComments indicates an expected call of GetComments.
+func (mr *MockBlogRepositoryMockRecorder) GetComments(ctx, blogPostID, page, view any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComments", reflect.TypeOf((*MockBlogRepository)(nil).GetComments), ctx, blogPostID, page, view)
+}
+
+// GetPostAuthorID mocks base method.
+func (m *MockBlogRepository) GetPostAuthorID(ctx context.Context, id int) (*int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPostAuthorID", ctx, id)
+	ret0, _ := ret[0].(*int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPostAuthorID indicates an expected call of GetPostAuthorID.
+func (mr *MockBlogRepositoryMockRecorder) GetPostAuthorID(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllPostsWithCommentCount", reflect.TypeOf((*MockBlogRepository)(nil).GetAllPostsWithCommentCount), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostAuthorID", reflect.TypeOf((*MockBlogRepository)(nil).GetPostAuthorID), ctx, id)
 }
 
 // GetPostWithComments mocks base method.
-func (m *MockBlogRepository) GetPostWithComments(ctx context.Context, id int) (*response.PostWithCommentsResponse, error) {
+func (m *MockBlogRepository) GetPostWithComments(ctx context.Context, id int, comments model.Page, view model.CommentView) (*response.PostWithCommentsResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPostWithComments", ctx, id)
+	ret := m.ctrl.Call(m, "GetPostWithComments", ctx, id, comments, view)
 	ret0, _ := ret[0].(*response.PostWithCommentsResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPostWithComments indicates an expected call of GetPostWithComments.
-func (mr *MockBlogRepositoryMockRecorder) GetPostWithComments(ctx, id any) *gomock.Call {
+func (mr *MockBlogRepositoryMockRecorder) GetPostWithComments(ctx, id, comments, view any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostWithComments", reflect.TypeOf((*MockBlogRepository)(nil).GetPostWithComments), ctx, id, comments, view)
+}
+
+// GetReplies mocks base method.
+func (m *MockBlogRepository) GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) ([]*response.CommentResponse, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReplies", ctx, blogPostID, rootCommentID, page)
+	ret0, _ := ret[0].([]*response.CommentResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetReplies indicates an expected call of GetReplies.
+func (mr *MockBlogRepositoryMockRecorder) GetReplies(ctx, blogPostID, rootCommentID, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReplies", reflect.TypeOf((*MockBlogRepository)(nil).GetReplies), ctx, blogPostID, rootCommentID, page)
+}
+
+// GetRevision mocks base method.
+func (m *MockBlogRepository) GetRevision(ctx context.Context, blogPostID, revision int, includeUnpublished bool) (*response.RevisionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", ctx, blogPostID, revision, includeUnpublished)
+	ret0, _ := ret[0].(*response.RevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockBlogRepositoryMockRecorder) GetRevision(ctx, blogPostID, revision, includeUnpublished any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockBlogRepository)(nil).GetRevision), ctx, blogPostID, revision, includeUnpublished)
+}
+
+// GetRevisions mocks base method.
+func (m *MockBlogRepository) GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) ([]*response.RevisionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevisions", ctx, blogPostID, includeUnpublished)
+	ret0, _ := ret[0].([]*response.RevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevisions indicates an expected call of GetRevisions.
+func (mr *MockBlogRepositoryMockRecorder) GetRevisions(ctx, blogPostID, includeUnpublished any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevisions", reflect.TypeOf((*MockBlogRepository)(nil).GetRevisions), ctx, blogPostID, includeUnpublished)
+}
+
+// GetTags mocks base method.
+func (m *MockBlogRepository) GetTags(ctx context.Context) ([]*response.TagResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", ctx)
+	ret0, _ := ret[0].([]*response.TagResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockBlogRepositoryMockRecorder) GetTags(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockBlogRepository)(nil).GetTags), ctx)
+}
+
+// MergeTags mocks base method.
+func (m *MockBlogRepository) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeTags", ctx, sourceID, targetID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeTags indicates an expected call of MergeTags.
+func (mr *MockBlogRepositoryMockRecorder) MergeTags(ctx, sourceID, targetID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeTags", reflect.TypeOf((*MockBlogRepository)(nil).MergeTags), ctx, sourceID, targetID)
+}
+
+// PublishScheduledPosts mocks base method.
+func (m *MockBlogRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishScheduledPosts", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishScheduledPosts indicates an expected call of PublishScheduledPosts.
+func (mr *MockBlogRepositoryMockRecorder) PublishScheduledPosts(ctx, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishScheduledPosts", reflect.TypeOf((*MockBlogRepository)(nil).PublishScheduledPosts), ctx, now)
+}
+
+// RenameTag mocks base method.
+func (m *MockBlogRepository) RenameTag(ctx context.Context, id int, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", ctx, id, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockBlogRepositoryMockRecorder) RenameTag(ctx, id, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockBlogRepository)(nil).RenameTag), ctx, id, name)
+}
+
+// ResolveSlug mocks base method.
+func (m *MockBlogRepository) ResolveSlug(ctx context.Context, slug string) (int, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveSlug", ctx, slug)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ResolveSlug indicates an expected call of ResolveSlug.
+func (mr *MockBlogRepositoryMockRecorder) ResolveSlug(ctx, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveSlug", reflect.TypeOf((*MockBlogRepository)(nil).ResolveSlug), ctx, slug)
+}
+
+// RestoreRevision mocks base method.
+func (m *MockBlogRepository) RestoreRevision(ctx context.Context, blogPostID, revision int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreRevision", ctx, blogPostID, revision)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreRevision indicates an expected call of RestoreRevision.
+func (mr *MockBlogRepositoryMockRecorder) RestoreRevision(ctx, blogPostID, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreRevision", reflect.TypeOf((*MockBlogRepository)(nil).RestoreRevision), ctx, blogPostID, revision)
+}
+
+// SearchPosts mocks base method.
+func (m *MockBlogRepository) SearchPosts(ctx context.Context, terms string, page model.SearchPage) ([]*response.SearchResultResponse, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPosts", ctx, terms, page)
+	ret0, _ := ret[0].([]*response.SearchResultResponse)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchPosts indicates an expected call of SearchPosts.
+func (mr *MockBlogRepositoryMockRecorder) SearchPosts(ctx, terms, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPosts", reflect.TypeOf((*MockBlogRepository)(nil).SearchPosts), ctx, terms, page)
+}
+
+// UpdateComment mocks base method.
+func (m *MockBlogRepository) UpdateComment(ctx context.Context, blogPostID, commentID int, content string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateComment", ctx, blogPostID, commentID, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateComment indicates an expected call of UpdateComment.
+func (mr *MockBlogRepositoryMockRecorder) UpdateComment(ctx, blogPostID, commentID, content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateComment", reflect.TypeOf((*MockBlogRepository)(nil).UpdateComment), ctx, blogPostID, commentID, content)
+}
+
+// UpdatePost mocks base method.
+func (m *MockBlogRepository) UpdatePost(ctx context.Context, id int, changes model.PostChanges) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePost", ctx, id, changes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePost indicates an expected call of UpdatePost.
+func (mr *MockBlogRepositoryMockRecorder) UpdatePost(ctx, id, changes any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPostWithComments", reflect.TypeOf((*MockBlogRepository)(nil).GetPostWithComments), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePost", reflect.TypeOf((*MockBlogRepository)(nil).UpdatePost), ctx, id, changes)
 }
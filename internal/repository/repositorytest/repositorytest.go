@@ -0,0 +1,809 @@
+// Package repositorytest is the conformance suite every implementation of the repositories must pass,
+// so the handlers behave the same whichever storage backs them
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/stretchr/testify/assert"
+)
+
+// Repositories are the implementations under test, all backed by the same storage
+type Repositories struct {
+	Blog    repository.BlogRepository
+	Users   repository.UserRepository
+	APIKeys repository.APIKeyRepository
+}
+
+// Run runs the whole suite, open is called once per test and must hand back repositories over empty storage
+func Run(t *testing.T, open func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repos Repositories)
+	}{
+		{name: "posts are listed newest first", run: testPostsOrdering},
+		{name: "posts are filtered by tag", run: testPostsTagFilter},
+		{name: "comment counts skip deleted comments", run: testCommentCounts},
+		{name: "comments are paginated", run: testCommentsPagination},
+		{name: "threads are capped to the page limit of replies", run: testThreadRepliesCap},
+		{name: "missing items are not found", run: testNotFound},
+		{name: "hidden posts are not found", run: testHiddenPosts},
+		{name: "replies need their parent in the same post", run: testReplies},
+		{name: "deleting a post removes everything it owns", run: testDeletePostCascade},
+		{name: "deleting a comment removes its replies", run: testDeleteCommentCascade},
+		{name: "updates record revisions and slugs", run: testRevisionsAndSlugs},
+		{name: "posts created together with the same title get their own slug", run: testConcurrentSlugs},
+		{name: "tags are created, renamed and merged", run: testTags},
+		{name: "scheduled posts are published when due", run: testPublishScheduledPosts},
+		{name: "publish times keep their instant whatever their offset", run: testPublishAtOffset},
+		{name: "scheduled posts are rescheduled with a publish time alone", run: testReschedule},
+		{name: "users are created with roles", run: testUsers},
+		{name: "deleting a user keeps their content", run: testDeleteUserCascade},
+		{name: "api keys authenticate until revoked or expired", run: testAPIKeys},
+		{name: "api keys expire at their instant whatever their offset", run: testAPIKeyExpiryOffset},
+		{name: "posts are searched by text and comments", run: testSearch},
+		{name: "search snippets escape the text of posts", run: testSearchEscapesSnippets},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+func testPostsOrdering(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	first := createPost(t, repos, "First", model.PostStatusPublished)
+	createPost(t, repos, "Draft", model.PostStatusDraft)
+	second := createPost(t, repos, "Second", model.PostStatusPublished)
+	third := createPost(t, repos, "Third", model.PostStatusPublished)
+
+	posts, next, err := repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 2}, model.TagFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, third, posts[0].ID)
+		assert.Equal(t, second, posts[1].ID)
+	}
+	assert.NotEmpty(t, next)
+
+	cursor, err := model.DecodeCursor(next)
+	assert.NoError(t, err)
+	posts, next, err = repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Cursor: cursor, Limit: 2}, model.TagFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, posts, 1, "drafts are not listed") {
+		assert.Equal(t, first, posts[0].ID)
+		assert.Equal(t, "first", posts[0].Slug)
+	}
+	assert.Empty(t, next)
+}
+
+func testPostsTagFilter(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	both := createPost(t, repos, "Both", model.PostStatusPublished, "go", "sql")
+	onlyGo := createPost(t, repos, "Only go", model.PostStatusPublished, "go")
+	createPost(t, repos, "Untagged", model.PostStatusPublished)
+
+	posts, _, err := repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 10},
+		model.TagFilter{Tags: []string{"go", "sql"}, Match: model.TagMatchAny})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{onlyGo, both}, postIDs(posts))
+
+	posts, _, err = repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 10},
+		model.TagFilter{Tags: []string{"go", "sql"}, Match: model.TagMatchAll})
+	assert.NoError(t, err)
+	if assert.Equal(t, []int{both}, postIDs(posts)) {
+		assert.Equal(t, []string{"go", "sql"}, posts[0].Tags)
+	}
+}
+
+func testCommentCounts(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	postID := createPost(t, repos, "Commented", model.PostStatusPublished)
+	first := addComment(t, repos, postID, nil, author)
+	addComment(t, repos, postID, &first, author)
+	addComment(t, repos, postID, nil, author)
+	other := createPost(t, repos, "Other", model.PostStatusPublished)
+	addComment(t, repos, other, nil, author)
+
+	assert.NoError(t, repos.Blog.DeleteComment(ctx, postID, first))
+
+	posts, _, err := repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 10}, model.TagFilter{})
+	assert.NoError(t, err)
+	counts := map[int]int{}
+	for _, post := range posts {
+		counts[post.ID] = post.CommentCount
+	}
+	assert.Equal(t, map[int]int{postID: 1, other: 1}, counts)
+
+	post, err := repos.Blog.GetPostWithComments(ctx, postID, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, post.CommentCount)
+	assert.Len(t, post.Comments, 1)
+	if assert.NotNil(t, post.Comments[0].Author) {
+		assert.Equal(t, author, post.Comments[0].Author.ID)
+	}
+}
+
+func testCommentsPagination(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	postID := createPost(t, repos, "Commented", model.PostStatusPublished)
+	oldest := addComment(t, repos, postID, nil, author)
+	reply := addComment(t, repos, postID, &oldest, author)
+	newest := addComment(t, repos, postID, nil, author)
+
+	comments, next, err := repos.Blog.GetComments(ctx, postID, model.Page{Limit: 2}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 2) {
+		assert.Equal(t, newest, comments[0].ID)
+		assert.Equal(t, reply, comments[1].ID)
+		assert.Equal(t, 1, comments[1].Depth)
+	}
+
+	cursor, err := model.DecodeCursor(next)
+	assert.NoError(t, err)
+	comments, next, err = repos.Blog.GetComments(ctx, postID, model.Page{Cursor: cursor, Limit: 2}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 1) {
+		assert.Equal(t, oldest, comments[0].ID)
+	}
+	assert.Empty(t, next)
+
+	threads, next, err := repos.Blog.GetComments(ctx, postID, model.Page{Limit: 1}, model.CommentViewTree)
+	assert.NoError(t, err)
+	if assert.Len(t, threads, 1) {
+		assert.Equal(t, newest, threads[0].ID)
+	}
+
+	cursor, err = model.DecodeCursor(next)
+	assert.NoError(t, err)
+	threads, next, err = repos.Blog.GetComments(ctx, postID, model.Page{Cursor: cursor, Limit: 1}, model.CommentViewTree)
+	assert.NoError(t, err)
+	if assert.Len(t, threads, 1) && assert.Len(t, threads[0].Replies, 1) {
+		assert.Equal(t, oldest, threads[0].ID)
+		assert.Equal(t, reply, threads[0].Replies[0].ID)
+	}
+	assert.Empty(t, next)
+}
+
+func testThreadRepliesCap(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "author")
+	postID := createPost(t, repos, "Busy thread", model.PostStatusPublished)
+	root := addComment(t, repos, postID, nil, author)
+	first := addComment(t, repos, postID, &root, author)
+	nested := addComment(t, repos, postID, &first, author)
+	second := addComment(t, repos, postID, &root, author)
+	third := addComment(t, repos, postID, &nested, author)
+	quiet := addComment(t, repos, postID, nil, author)
+
+	threads, _, err := repos.Blog.GetComments(ctx, postID, model.Page{Limit: 2}, model.CommentViewTree)
+	assert.NoError(t, err)
+	if !assert.Len(t, threads, 2) {
+		return
+	}
+	assert.Equal(t, quiet, threads[0].ID)
+	assert.Nil(t, threads[0].RepliesCursor)
+
+	busy := threads[1]
+	assert.Equal(t, root, busy.ID)
+	if assert.Len(t, busy.Replies, 1) && assert.Len(t, busy.Replies[0].Replies, 1) {
+		assert.Equal(t, first, busy.Replies[0].ID)
+		assert.Equal(t, nested, busy.Replies[0].Replies[0].ID)
+		assert.Empty(t, busy.Replies[0].Replies[0].Replies)
+	}
+	if !assert.NotNil(t, busy.RepliesCursor) {
+		return
+	}
+
+	cursor, err := model.DecodeCursor(*busy.RepliesCursor)
+	assert.NoError(t, err)
+	replies, next, err := repos.Blog.GetReplies(ctx, postID, root, model.Page{Cursor: cursor, Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, replies, 1) {
+		assert.Equal(t, second, replies[0].ID)
+		assert.Equal(t, root, *replies[0].ParentCommentID)
+	}
+
+	cursor, err = model.DecodeCursor(next)
+	assert.NoError(t, err)
+	replies, next, err = repos.Blog.GetReplies(ctx, postID, root, model.Page{Cursor: cursor, Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, replies, 1) {
+		assert.Equal(t, third, replies[0].ID)
+		assert.Equal(t, 3, replies[0].Depth)
+	}
+	assert.Empty(t, next)
+
+	// Only the roots of published posts have their replies read
+	_, _, err = repos.Blog.GetReplies(ctx, postID, first, model.Page{Limit: 10})
+	assertNotFound(t, err)
+	draftID := createPost(t, repos, "Draft", model.PostStatusDraft)
+	_, _, err = repos.Blog.GetReplies(ctx, draftID, root, model.Page{Limit: 10})
+	assertNotFound(t, err)
+}
+
+func testNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "author")
+	postID := createPost(t, repos, "Existing", model.PostStatusPublished)
+	missing := postID + 1000
+	title := "New title"
+
+	_, err := repos.Blog.GetPostWithComments(ctx, missing, model.Page{Limit: 10}, model.CommentViewFlat)
+	assertNotFound(t, err)
+	_, _, err = repos.Blog.GetComments(ctx, missing, model.Page{Limit: 10}, model.CommentViewFlat)
+	assertNotFound(t, err)
+	_, err = repos.Blog.GetComment(ctx, postID, missing)
+	assertNotFound(t, err)
+	_, err = repos.Blog.AddComment(ctx, missing, nil, author, "Hello")
+	assertNotFound(t, err)
+	assertNotFound(t, repos.Blog.UpdateComment(ctx, postID, missing, "Hello"))
+	assertNotFound(t, repos.Blog.DeleteComment(ctx, postID, missing))
+	assertNotFound(t, repos.Blog.UpdatePost(ctx, missing, model.PostChanges{Title: &title}))
+	assertNotFound(t, repos.Blog.DeletePost(ctx, missing))
+	_, err = repos.Blog.GetPostAuthorID(ctx, missing)
+	assertNotFound(t, err)
+	_, err = repos.Blog.GetRevisions(ctx, missing, true)
+	assertNotFound(t, err)
+	_, err = repos.Blog.GetRevision(ctx, postID, 2, true)
+	assertNotFound(t, err)
+	_, err = repos.Blog.RestoreRevision(ctx, postID, 2)
+	assertNotFound(t, err)
+	_, _, err = repos.Blog.ResolveSlug(ctx, "missing")
+	assertNotFound(t, err)
+	assertNotFound(t, repos.Blog.RenameTag(ctx, missing, "go"))
+	assertNotFound(t, repos.Blog.MergeTags(ctx, missing, missing+1))
+	_, err = repos.Users.GetUserByUsername(ctx, "missing")
+	assertNotFound(t, err)
+	_, err = repos.Users.GetUser(ctx, missing)
+	assertNotFound(t, err)
+	assertNotFound(t, repos.Users.UpdateUserRole(ctx, missing, model.RoleEditor))
+	assertNotFound(t, repos.Users.DeleteUser(ctx, missing))
+	assertNotFound(t, repos.APIKeys.RevokeAPIKey(ctx, missing))
+}
+
+func testHiddenPosts(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "author")
+	draft := createPost(t, repos, "Draft", model.PostStatusDraft)
+
+	_, err := repos.Blog.GetPostWithComments(ctx, draft, model.Page{Limit: 10}, model.CommentViewFlat)
+	assertNotFound(t, err)
+	_, _, err = repos.Blog.GetComments(ctx, draft, model.Page{Limit: 10}, model.CommentViewFlat)
+	assertNotFound(t, err)
+	_, err = repos.Blog.AddComment(ctx, draft, nil, author, "Hello")
+	assertNotFound(t, err)
+	_, _, err = repos.Blog.ResolveSlug(ctx, "draft")
+	assertNotFound(t, err)
+
+	_, err = repos.Blog.GetRevisions(ctx, draft, false)
+	assertNotFound(t, err)
+	_, err = repos.Blog.GetRevision(ctx, draft, 1, false)
+	assertNotFound(t, err)
+
+	// Hidden posts can still be edited by their author
+	_, err = repos.Blog.GetPostAuthorID(ctx, draft)
+	assert.NoError(t, err)
+	revisions, err := repos.Blog.GetRevisions(ctx, draft, true)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+	_, err = repos.Blog.GetRevision(ctx, draft, 1, true)
+	assert.NoError(t, err)
+
+	// Comments of a post that is no longer published can be neither edited nor deleted
+	archived := createPost(t, repos, "Archived", model.PostStatusPublished)
+	commentID := addComment(t, repos, archived, nil, author)
+	status := model.PostStatusArchived
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, archived, model.PostChanges{Status: &status}))
+	assertNotFound(t, repos.Blog.UpdateComment(ctx, archived, commentID, "Edited"))
+	assertNotFound(t, repos.Blog.DeleteComment(ctx, archived, commentID))
+
+	status = model.PostStatusPublished
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, archived, model.PostChanges{Status: &status}))
+	comment, err := repos.Blog.GetComment(ctx, archived, commentID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Nice post", comment.Content)
+}
+
+func testReplies(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	postID := createPost(t, repos, "Commented", model.PostStatusPublished)
+	other := createPost(t, repos, "Other", model.PostStatusPublished)
+	root := addComment(t, repos, postID, nil, author)
+	reply := addComment(t, repos, postID, &root, author)
+	nested := addComment(t, repos, postID, &reply, author)
+	foreign := addComment(t, repos, other, nil, author)
+
+	comment, err := repos.Blog.GetComment(ctx, postID, nested)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, comment.Depth)
+	assert.Equal(t, &reply, comment.ParentCommentID)
+
+	_, err = repos.Blog.AddComment(ctx, postID, &foreign, author, "Hello")
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	_, err = repos.Blog.GetComment(ctx, other, root)
+	assertNotFound(t, err)
+}
+
+func testDeletePostCascade(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	postID := createPost(t, repos, "Doomed", model.PostStatusPublished, "go")
+	commentID := addComment(t, repos, postID, nil, author)
+	title := "Renamed"
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, postID, model.PostChanges{Title: &title}))
+
+	assert.NoError(t, repos.Blog.DeletePost(ctx, postID))
+
+	_, err := repos.Blog.GetComment(ctx, postID, commentID)
+	assertNotFound(t, err)
+	_, err = repos.Blog.GetRevisions(ctx, postID, true)
+	assertNotFound(t, err)
+	for _, postSlug := range []string{"doomed", "renamed"} {
+		_, _, err = repos.Blog.ResolveSlug(ctx, postSlug)
+		assertNotFound(t, err)
+	}
+	tags, err := repos.Blog.GetTags(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, tags, 1, "tags outlive their posts") {
+		assert.Equal(t, 0, tags[0].PostCount)
+	}
+	assertNotFound(t, repos.Blog.DeletePost(ctx, postID))
+
+	// The slugs of the deleted post are free again
+	reused := createPost(t, repos, "Doomed", model.PostStatusPublished)
+	resolved, current, err := repos.Blog.ResolveSlug(ctx, "doomed")
+	assert.NoError(t, err)
+	assert.Equal(t, reused, resolved)
+	assert.Equal(t, "doomed", current)
+}
+
+func testDeleteCommentCascade(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	postID := createPost(t, repos, "Commented", model.PostStatusPublished)
+	root := addComment(t, repos, postID, nil, author)
+	reply := addComment(t, repos, postID, &root, author)
+	nested := addComment(t, repos, postID, &reply, author)
+	kept := addComment(t, repos, postID, nil, author)
+
+	assert.NoError(t, repos.Blog.DeleteComment(ctx, postID, reply))
+
+	for _, id := range []int{reply, nested} {
+		_, err := repos.Blog.GetComment(ctx, postID, id)
+		assertNotFound(t, err)
+	}
+	assertNotFound(t, repos.Blog.UpdateComment(ctx, postID, nested, "Edited"))
+	assertNotFound(t, repos.Blog.DeleteComment(ctx, postID, reply))
+	_, err := repos.Blog.AddComment(ctx, postID, &reply, author, "Hello")
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "replies to deleted comments are rejected")
+
+	comments, _, err := repos.Blog.GetComments(ctx, postID, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	ids := make([]int, len(comments))
+	for idx, comment := range comments {
+		ids[idx] = comment.ID
+	}
+	assert.Equal(t, []int{kept, root}, ids)
+}
+
+func testRevisionsAndSlugs(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	postID := createPost(t, repos, "Original title", model.PostStatusPublished)
+	title, content := "New title", "New content"
+	status := model.PostStatusPublished
+
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, postID, model.PostChanges{Title: &title}))
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, postID, model.PostChanges{Content: &content}))
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, postID, model.PostChanges{Status: &status}), "status changes keep the revision")
+
+	revisions, err := repos.Blog.GetRevisions(ctx, postID, false)
+	assert.NoError(t, err)
+	numbers := make([]int, len(revisions))
+	for idx, revision := range revisions {
+		numbers[idx] = revision.Revision
+	}
+	assert.Equal(t, []int{3, 2, 1}, numbers)
+
+	resolved, current, err := repos.Blog.ResolveSlug(ctx, "original-title")
+	assert.NoError(t, err)
+	assert.Equal(t, postID, resolved)
+	assert.Equal(t, "new-title", current)
+
+	latest, err := repos.Blog.RestoreRevision(ctx, postID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, latest)
+
+	restored, err := repos.Blog.GetRevision(ctx, postID, latest, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "Original title", restored.Title)
+	assert.Equal(t, "Hello world", restored.Content)
+	assert.Equal(t, 1, *restored.RestoredFromRevision)
+
+	post, err := repos.Blog.GetPostWithComments(ctx, postID, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	assert.Equal(t, "original-title", post.Slug, "a post gets its own previous slug back")
+
+	other := createPost(t, repos, "New title", model.PostStatusPublished)
+	resolved, _, err = repos.Blog.ResolveSlug(ctx, "new-title-2")
+	assert.NoError(t, err)
+	assert.Equal(t, other, resolved, "slugs used before by another post are not reused")
+}
+
+func testConcurrentSlugs(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	const posts = 5
+
+	var wg sync.WaitGroup
+	errs := make([]error, posts)
+	for idx := range posts {
+		wg.Go(func() {
+			_, errs[idx] = repos.Blog.CreatePost(ctx, &model.Post{Title: "Same title", Content: "Hello world", Status: model.PostStatusPublished})
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	page, _, err := repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: posts}, model.TagFilter{})
+	assert.NoError(t, err)
+	slugs := map[string]bool{}
+	for _, post := range page {
+		slugs[post.Slug] = true
+	}
+	assert.Len(t, slugs, posts, "every post should have a slug of its own")
+	assert.True(t, slugs["same-title"])
+}
+
+func testTags(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	createPost(t, repos, "Tagged", model.PostStatusPublished, "golang", "sql")
+	createPost(t, repos, "Hidden", model.PostStatusDraft, "golang")
+	goID, err := repos.Blog.CreateTag(ctx, "go")
+	assert.NoError(t, err)
+
+	_, err = repos.Blog.CreateTag(ctx, "go")
+	assert.True(t, errors.Is(err, app_err.ErrConflict), "error should wrap ErrConflict")
+
+	tags, err := repos.Blog.GetTags(ctx)
+	assert.NoError(t, err)
+	counts := map[string]int{}
+	ids := map[string]int{}
+	names := make([]string, len(tags))
+	for idx, tag := range tags {
+		counts[tag.Name] = tag.PostCount
+		ids[tag.Name] = tag.ID
+		names[idx] = tag.Name
+	}
+	assert.Equal(t, []string{"go", "golang", "sql"}, names)
+	assert.Equal(t, map[string]int{"go": 0, "golang": 1, "sql": 1}, counts, "only published posts are counted")
+
+	err = repos.Blog.RenameTag(ctx, ids["sql"], "go")
+	assert.True(t, errors.Is(err, app_err.ErrConflict), "error should wrap ErrConflict")
+	assert.NoError(t, repos.Blog.RenameTag(ctx, ids["sql"], "postgres"))
+
+	assert.NoError(t, repos.Blog.MergeTags(ctx, ids["golang"], goID))
+	tags, err = repos.Blog.GetTags(ctx)
+	assert.NoError(t, err)
+	counts = map[string]int{}
+	for _, tag := range tags {
+		counts[tag.Name] = tag.PostCount
+	}
+	assert.Equal(t, map[string]int{"go": 1, "postgres": 1}, counts)
+
+	posts, _, err := repos.Blog.GetAllPostsWithCommentCount(ctx, model.Page{Limit: 10}, model.TagFilter{})
+	assert.NoError(t, err)
+	if assert.Len(t, posts, 1) {
+		assert.Equal(t, []string{"go", "postgres"}, posts[0].Tags)
+	}
+}
+
+func testPublishScheduledPosts(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	current := time.Now().UTC()
+	due := createScheduledPost(t, repos, "Due", current.Add(-time.Minute))
+	later := createScheduledPost(t, repos, "Later", current.Add(time.Hour))
+
+	published, err := repos.Blog.PublishScheduledPosts(ctx, current)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	_, err = repos.Blog.GetPostWithComments(ctx, due, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	_, err = repos.Blog.GetPostWithComments(ctx, later, model.Page{Limit: 10}, model.CommentViewFlat)
+	assertNotFound(t, err)
+
+	published, err = repos.Blog.PublishScheduledPosts(ctx, current)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published, "published posts are not published again")
+}
+
+func testPublishAtOffset(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	current := time.Now().UTC().Truncate(time.Second)
+	// An hour from now, written two hours ahead of UTC
+	publishAt := current.Add(time.Hour).In(time.FixedZone("CEST", 2*60*60))
+	postID := createScheduledPost(t, repos, "Offset", publishAt)
+
+	published, err := repos.Blog.PublishScheduledPosts(ctx, current.Add(30*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published, "the post is not due before its instant")
+
+	published, err = repos.Blog.PublishScheduledPosts(ctx, current.Add(90*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published, "the post is due once its instant has passed, not hours later")
+
+	post, err := repos.Blog.GetPostWithComments(ctx, postID, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	if assert.NotNil(t, post.PublishAt) {
+		assert.Equal(t, publishAt.UTC().Format(time.RFC3339), *post.PublishAt)
+	}
+}
+
+func testReschedule(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	current := time.Now().UTC().Truncate(time.Second)
+	scheduled := createScheduledPost(t, repos, "Later", current.Add(time.Hour))
+	draft := createPost(t, repos, "Draft", model.PostStatusDraft)
+
+	publishAt := current.Add(3 * time.Hour)
+	assert.NoError(t, repos.Blog.UpdatePost(ctx, scheduled, model.PostChanges{PublishAt: &publishAt}))
+	err := repos.Blog.UpdatePost(ctx, draft, model.PostChanges{PublishAt: &publishAt})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+	published, err := repos.Blog.PublishScheduledPosts(ctx, current.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published, "the post should no longer be due at its previous publish time")
+
+	published, err = repos.Blog.PublishScheduledPosts(ctx, current.Add(4*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published, "the draft should not have been scheduled")
+
+	post, err := repos.Blog.GetPostWithComments(ctx, scheduled, model.Page{Limit: 10}, model.CommentViewFlat)
+	assert.NoError(t, err)
+	if assert.NotNil(t, post.PublishAt) {
+		assert.Equal(t, publishAt.Format(time.RFC3339), *post.PublishAt)
+	}
+}
+
+func testUsers(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	first, err := repos.Users.CreateUser(ctx, &model.User{Username: "first", DisplayName: "First", PasswordHash: "hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(model.RoleCommenter), first.Role, "users without a role start as commenters, the first one too")
+
+	admin, err := repos.Users.CreateUser(ctx, &model.User{Username: "boss", DisplayName: "Boss", PasswordHash: "hash", Role: model.RoleAdmin})
+	assert.NoError(t, err)
+	assert.Equal(t, string(model.RoleAdmin), admin.Role)
+
+	commenter, err := repos.Users.CreateUser(ctx, &model.User{Username: "second", DisplayName: "Second", PasswordHash: "hash"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(model.RoleCommenter), commenter.Role)
+
+	_, err = repos.Users.CreateUser(ctx, &model.User{Username: "second", DisplayName: "Again", PasswordHash: "hash"})
+	assert.True(t, errors.Is(err, app_err.ErrConflict), "error should wrap ErrConflict")
+
+	assert.NoError(t, repos.Users.UpdateUserRole(ctx, commenter.ID, model.RoleEditor))
+	user, err := repos.Users.GetUserByUsername(ctx, "second")
+	assert.NoError(t, err)
+	assert.Equal(t, model.RoleEditor, user.Role)
+	assert.Equal(t, "hash", user.PasswordHash)
+
+	user, err = repos.Users.GetUser(ctx, commenter.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", user.Username)
+	assert.Equal(t, model.RoleEditor, user.Role)
+	assert.Empty(t, user.PasswordHash)
+
+	users, err := repos.Users.GetUsers(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, first.ID, users[0].ID)
+		assert.Equal(t, admin.ID, users[1].ID)
+		assert.Equal(t, commenter.ID, users[2].ID)
+	}
+}
+
+func testDeleteUserCascade(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	createUser(t, repos, "admin")
+	author := createUser(t, repos, "author")
+	postID := createPostBy(t, repos, &model.Post{Title: "Orphan", Content: "Hello world", Status: model.PostStatusPublished, AuthorID: &author})
+	commentID := addComment(t, repos, postID, nil, author)
+	_, err := repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{UserID: author, Name: "ci", Prefix: "pbk_abc", KeyHash: "author-hash",
+		Scopes: []model.Scope{model.ScopeRead}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, repos.Users.DeleteUser(ctx, author))
+
+	authorID, err := repos.Blog.GetPostAuthorID(ctx, postID)
+	assert.NoError(t, err)
+	assert.Nil(t, authorID)
+	comment, err := repos.Blog.GetComment(ctx, postID, commentID)
+	assert.NoError(t, err)
+	assert.Nil(t, comment.Author)
+
+	keys, err := repos.APIKeys.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys, "the api keys of the user are removed along")
+}
+
+func testAPIKeys(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	userID := createUserWithRole(t, repos, "admin", model.RoleAdmin)
+	current := time.Now().UTC()
+	expiry := current.Add(-time.Minute)
+
+	created, err := repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{UserID: userID, Name: "ci", Prefix: "pbk_abc", KeyHash: "active-hash",
+		Scopes: []model.Scope{model.ScopePostsWrite, model.ScopeRead}})
+	assert.NoError(t, err)
+	_, err = repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{UserID: userID, Name: "old", Prefix: "pbk_def", KeyHash: "expired-hash",
+		Scopes: []model.Scope{model.ScopeRead}, ExpiresAt: &expiry})
+	assert.NoError(t, err)
+	_, err = repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{UserID: userID + 1000, Name: "nobody", Prefix: "pbk_ghi", KeyHash: "orphan-hash",
+		Scopes: []model.Scope{model.ScopeRead}})
+	assertNotFound(t, err)
+
+	key, err := repos.APIKeys.AuthenticateAPIKey(ctx, "active-hash", current)
+	assert.NoError(t, err)
+	assert.Equal(t, created.ID, key.ID)
+	assert.Equal(t, []model.Scope{model.ScopePostsWrite, model.ScopeRead}, key.Scopes)
+	if assert.NotNil(t, key.User) {
+		assert.Equal(t, model.RoleAdmin, key.User.Role)
+	}
+
+	_, err = repos.APIKeys.AuthenticateAPIKey(ctx, "expired-hash", current)
+	assertNotFound(t, err)
+	_, err = repos.APIKeys.AuthenticateAPIKey(ctx, "unknown-hash", current)
+	assertNotFound(t, err)
+
+	assert.NoError(t, repos.APIKeys.RevokeAPIKey(ctx, created.ID))
+	assertNotFound(t, repos.APIKeys.RevokeAPIKey(ctx, created.ID))
+	_, err = repos.APIKeys.AuthenticateAPIKey(ctx, "active-hash", current)
+	assertNotFound(t, err)
+
+	keys, err := repos.APIKeys.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 2, "revoked keys are still listed") {
+		assert.NotNil(t, keys[0].RevokedAt)
+		assert.NotNil(t, keys[0].LastUsedAt)
+	}
+}
+
+func testAPIKeyExpiryOffset(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	userID := createUserWithRole(t, repos, "admin", model.RoleAdmin)
+	current := time.Now().UTC().Truncate(time.Second)
+	// An hour from now, written two hours ahead of UTC
+	expiresAt := current.Add(time.Hour).In(time.FixedZone("CEST", 2*60*60))
+	_, err := repos.APIKeys.CreateAPIKey(ctx, &model.APIKey{UserID: userID, Name: "ci", Prefix: "pbk_abc", KeyHash: "offset-hash",
+		Scopes: []model.Scope{model.ScopeRead}, ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+
+	// Used from a clock behind UTC, the key is still valid half an hour from now
+	key, err := repos.APIKeys.AuthenticateAPIKey(ctx, "offset-hash", current.Add(30*time.Minute).In(time.FixedZone("EST", -5*60*60)))
+	assert.NoError(t, err)
+	if assert.NotNil(t, key) && assert.NotNil(t, key.LastUsedAt) {
+		assert.True(t, key.LastUsedAt.Equal(current.Add(30*time.Minute)))
+	}
+
+	_, err = repos.APIKeys.AuthenticateAPIKey(ctx, "offset-hash", current.Add(90*time.Minute))
+	assertNotFound(t, err)
+
+	keys, err := repos.APIKeys.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, expiresAt.UTC().Format(time.RFC3339), *keys[0].ExpiresAt)
+		assert.Equal(t, current.Add(30*time.Minute).Format(time.RFC3339), *keys[0].LastUsedAt)
+	}
+}
+
+func testSearch(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	author := createUser(t, repos, "commenter")
+	inContent := createPostBy(t, repos, &model.Post{Title: "Clusters", Content: "Running kubernetes at home", Status: model.PostStatusPublished})
+	inComment := createPost(t, repos, "Unrelated", model.PostStatusPublished)
+	addComment(t, repos, inComment, nil, author)
+	_, err := repos.Blog.AddComment(ctx, inComment, nil, author, "Have you tried kubernetes?")
+	assert.NoError(t, err)
+	createPostBy(t, repos, &model.Post{Title: "Hidden", Content: "A kubernetes draft", Status: model.PostStatusDraft})
+
+	results, next, err := repos.Blog.SearchPosts(ctx, "kubernetes", model.SearchPage{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	found := map[int]int{}
+	for _, result := range results {
+		found[result.PostID] = result.MatchingComments
+		assert.Greater(t, result.Rank, float32(0))
+	}
+	assert.Equal(t, map[int]int{inContent: 0, inComment: 1}, found)
+
+	results, next, err = repos.Blog.SearchPosts(ctx, "kubernetes", model.SearchPage{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	cursor, err := model.DecodeSearchCursor(next)
+	assert.NoError(t, err)
+	more, next, err := repos.Blog.SearchPosts(ctx, "kubernetes", model.SearchPage{Cursor: cursor, Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, more, 1) {
+		assert.NotEqual(t, results[0].PostID, more[0].PostID)
+	}
+	assert.Empty(t, next)
+}
+
+func testSearchEscapesSnippets(t *testing.T, repos Repositories) {
+	createPostBy(t, repos, &model.Post{Title: "Kubernetes <b>tips</b>", Content: "Running kubernetes <script>alert(1)</script> at home", Status: model.PostStatusPublished})
+
+	results, _, err := repos.Blog.SearchPosts(context.Background(), "kubernetes", model.SearchPage{Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Contains(t, results[0].TitleHighlight, "<mark>Kubernetes</mark>")
+		assert.Contains(t, results[0].TitleHighlight, "&lt;b&gt;")
+		assert.Contains(t, results[0].Snippet, "<mark>kubernetes</mark>")
+		assert.Contains(t, results[0].Snippet, "&lt;script&gt;")
+		assert.NotContains(t, results[0].Snippet, "<script>")
+	}
+}
+
+func assertNotFound(t *testing.T, err error) {
+	t.Helper()
+	assert.True(t, errors.Is(err, app_err.ErrNotFound), "error should wrap ErrNotFound, got %v", err)
+}
+
+func createUser(t *testing.T, repos Repositories, username string) int {
+	t.Helper()
+	return createUserWithRole(t, repos, username, model.RoleCommenter)
+}
+
+func createUserWithRole(t *testing.T, repos Repositories, username string, role model.Role) int {
+	t.Helper()
+	user, err := repos.Users.CreateUser(context.Background(), &model.User{Username: username, DisplayName: username, PasswordHash: "hash", Role: role})
+	assert.NoError(t, err)
+	return user.ID
+}
+
+func createPost(t *testing.T, repos Repositories, title string, status model.PostStatus, tags ...string) int {
+	t.Helper()
+	return createPostBy(t, repos, &model.Post{Title: title, Content: "Hello world", Status: status, Tags: tags})
+}
+
+func createScheduledPost(t *testing.T, repos Repositories, title string, publishAt time.Time) int {
+	t.Helper()
+	return createPostBy(t, repos, &model.Post{Title: title, Content: "Hello world", Status: model.PostStatusScheduled, PublishAt: &publishAt})
+}
+
+func createPostBy(t *testing.T, repos Repositories, post *model.Post) int {
+	t.Helper()
+	id, err := repos.Blog.CreatePost(context.Background(), post)
+	assert.NoError(t, err)
+	return id
+}
+
+func addComment(t *testing.T, repos Repositories, postID int, parentID *int, authorID int) int {
+	t.Helper()
+	id, err := repos.Blog.AddComment(context.Background(), postID, parentID, authorID, "Nice post")
+	assert.NoError(t, err)
+	return id
+}
+
+func postIDs(posts []*response.PostWithCommentCountResponse) []int {
+	ids := make([]int, len(posts))
+	for idx, post := range posts {
+		ids[idx] = post.ID
+	}
+	return ids
+}
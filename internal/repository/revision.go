@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.uber.org/zap"
+)
+
+const (
+	queryRevisions = `
+		SELECT revision, title, restored_from_revision, created_at
+		FROM post_revisions
+		WHERE blog_post_id = $1
+		ORDER BY revision DESC
+	`
+
+	queryRevision = `
+		SELECT revision, title, content, restored_from_revision, created_at
+		FROM post_revisions
+		WHERE blog_post_id = $1 AND revision = $2
+	`
+
+	// The revisions of unpublished posts are hidden from the readers who cannot edit them
+	queryPublishedRevision = `
+		SELECT r.revision, r.title, r.content, r.restored_from_revision, r.created_at
+		FROM post_revisions r
+		JOIN blog_posts b ON b.id = r.blog_post_id AND b.status = 'published'
+		WHERE r.blog_post_id = $1 AND r.revision = $2
+	`
+
+	queryLatestRevision = `
+		SELECT COALESCE(MAX(revision), 0)
+		FROM post_revisions
+		WHERE blog_post_id = $1
+	`
+
+	queryAddRevision = `
+		INSERT INTO post_revisions (blog_post_id, revision, title, content, restored_from_revision)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+)
+
+// GetRevisions lists the revisions of a post, newest first, without their content. Unless told to include them,
+// the revisions of unpublished posts are not found
+func (r *blogRepository) GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) ([]*response.RevisionResponse, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID))
+
+	query := queryPublishedPostExists
+	if includeUnpublished {
+		query = queryPostExists
+	}
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, blogPostID).Scan(&exists); err != nil {
+		logger.Error("[RepoGetRevisions] failed to check if post exists", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	if !exists {
+		logger.Info("[RepoGetRevisions] could not find the post")
+		return nil, app_err.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, queryRevisions, blogPostID)
+	if err != nil {
+		logger.Error("[RepoGetRevisions] failed to query revisions", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	revisions := []*response.RevisionResponse{}
+	for rows.Next() {
+		revision := model.Revision{PostID: blogPostID}
+		if err := rows.Scan(&revision.Number, &revision.Title, &revision.RestoredFrom, &revision.CreatedAt); err != nil {
+			logger.Error("[RepoGetRevisions] failed to scan revision", zap.Error(err))
+			return nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		revisions = append(revisions, revision.ToRevisionResponse())
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetRevisions] row iteration error", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision reads a revision of a post with its content. Unless told to include them, the revisions of
+// unpublished posts are not found
+func (r *blogRepository) GetRevision(ctx context.Context, blogPostID, revisionNumber int, includeUnpublished bool) (*response.RevisionResponse, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("revision", revisionNumber))
+
+	query := queryPublishedRevision
+	if includeUnpublished {
+		query = queryRevision
+	}
+
+	revision, err := r.getRevision(ctx, r.db, logger, query, blogPostID, revisionNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision.ToRevisionResponse(), nil
+}
+
+// RestoreRevision brings back the title and content of a past revision, recording it as a new revision
+// which is returned
+func (r *blogRepository) RestoreRevision(ctx context.Context, blogPostID, revisionNumber int) (int, error) {
+	logger := log.FromContext(ctx).With(zap.Int("post_id", blogPostID), zap.Int("revision", revisionNumber))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("[RepoRestoreRevision] could not begin transaction", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer tx.Rollback()
+
+	revision, err := r.getRevision(ctx, tx, logger, queryRevision, blogPostID, revisionNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := model.PostChanges{Title: &revision.Title, Content: &revision.Content}
+	latest, err := r.updatePost(ctx, tx, logger, blogPostID, changes, &revision.Number)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("[RepoRestoreRevision] could not commit transaction", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoRestoreRevision] revision restored", zap.Int("latest_revision", latest))
+	return latest, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *blogRepository) getRevision(ctx context.Context, db queryRower, logger *zap.Logger, query string, blogPostID, revisionNumber int) (*model.Revision, error) {
+	revision := &model.Revision{PostID: blogPostID}
+	err := db.QueryRowContext(ctx, query, blogPostID, revisionNumber).Scan(
+		&revision.Number,
+		&revision.Title,
+		&revision.Content,
+		&revision.RestoredFrom,
+		&revision.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetRevision] could not find the revision")
+		return nil, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoGetRevision] failed to query revision", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/slug"
+	"go.uber.org/zap"
+)
+
+const (
+	// Old slugs stay in post_slugs, so they resolve to the post and its current slug
+	queryResolveSlug = `
+		SELECT b.id, b.slug
+		FROM post_slugs s
+		JOIN blog_posts b ON b.id = s.blog_post_id
+		WHERE s.slug = $1 AND b.status = 'published'
+	`
+
+	// Slugs only hold letters, digits and dashes, so the base cannot carry LIKE wildcards
+	querySlugsLike = `
+		SELECT slug, blog_post_id
+		FROM post_slugs
+		WHERE slug = $1 OR slug LIKE $2
+	`
+
+	queryAddSlug = `
+		INSERT INTO post_slugs (slug, blog_post_id)
+		VALUES ($1, $2)
+		ON CONFLICT (slug) DO NOTHING
+	`
+
+	// Held until the transaction ends, the first key sets the slug locks apart from the other advisory locks
+	queryLockSlug = `SELECT pg_advisory_xact_lock(4717, hashtext($1))`
+
+	queryUpdatePostSlug = `
+		UPDATE blog_posts
+		SET slug = $2
+		WHERE id = $1
+	`
+)
+
+// ResolveSlug finds the published post a current or past slug belongs to, along with its current slug
+func (r *blogRepository) ResolveSlug(ctx context.Context, postSlug string) (int, string, error) {
+	logger := log.FromContext(ctx).With(zap.String("slug", postSlug))
+	var id int
+	var currentSlug string
+	err := r.db.QueryRowContext(ctx, queryResolveSlug, postSlug).Scan(&id, &currentSlug)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoResolveSlug] could not find the post")
+		return 0, "", app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoResolveSlug] failed to query slug", zap.Error(err))
+		return 0, "", errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return id, currentSlug, nil
+}
+
+// updateSlug gives the post a slug matching its new title, the previous slug is kept for redirects
+func (r *blogRepository) updateSlug(ctx context.Context, tx *transaction, logger *zap.Logger, postID int, currentSlug, title string) error {
+	postSlug, err := r.freeSlug(ctx, tx, logger, postID, title)
+	if err != nil {
+		return err
+	}
+
+	if postSlug == currentSlug {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, queryAddSlug, postSlug, postID); err != nil {
+		logger.Error("[RepoUpdateSlug] could not persist the slug", zap.Error(err), zap.String("slug", postSlug))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryUpdatePostSlug, postID, postSlug); err != nil {
+		logger.Error("[RepoUpdateSlug] could not update the post slug", zap.Error(err), zap.String("slug", postSlug))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoUpdateSlug] post slug changed", zap.String("previous_slug", currentSlug), zap.String("slug", postSlug))
+	return nil
+}
+
+// freeSlug picks the slug of the title, adding a numeric suffix when another post already used it.
+// A slug the post itself used before is handed back to it. Transactions picking a slug of the same base
+// wait on each other until the first one ends, so they cannot both take the same free slug. SQLite
+// transactions already hold the write lock from their beginning
+func (r *blogRepository) freeSlug(ctx context.Context, tx *transaction, logger *zap.Logger, postID int, title string) (string, error) {
+	base := slug.Make(title)
+	if r.db.dialect != DialectSQLite {
+		if _, err := tx.ExecContext(ctx, queryLockSlug, base); err != nil {
+			logger.Error("[RepoFreeSlug] failed to lock the slug", zap.Error(err), zap.String("slug", base))
+			return "", errors.Join(app_err.ErrInternalServer, err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, querySlugsLike, base, base+"-%")
+	if err != nil {
+		logger.Error("[RepoFreeSlug] failed to query slugs", zap.Error(err))
+		return "", errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	taken := make(map[string]int)
+	for rows.Next() {
+		var existing string
+		var owner int
+		if err := rows.Scan(&existing, &owner); err != nil {
+			logger.Error("[RepoFreeSlug] failed to scan slug", zap.Error(err))
+			return "", errors.Join(app_err.ErrInternalServer, err)
+		}
+		taken[existing] = owner
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoFreeSlug] row iteration error", zap.Error(err))
+		return "", errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	candidate := base
+	for suffix := 2; ; suffix++ {
+		owner, used := taken[candidate]
+		if !used || owner == postID {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
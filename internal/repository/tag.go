@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.uber.org/zap"
+)
+
+const (
+	// Only published posts are counted, so the counts match what the posts listing shows
+	queryTagsWithPostCount = `
+		SELECT t.id, t.name,
+			(SELECT COUNT(*) FROM post_tags pt JOIN blog_posts b ON b.id = pt.blog_post_id
+				WHERE pt.tag_id = t.id AND b.status = 'published') AS post_count
+		FROM tags t
+		ORDER BY t.name
+	`
+
+	queryTagExists = `
+		SELECT EXISTS (SELECT 1 FROM tags WHERE id = $1)
+	`
+
+	queryCreateTag = `
+		INSERT INTO tags (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO NOTHING
+		RETURNING id
+	`
+
+	// Returns the id of the tag whether it was just created or already existed
+	queryUpsertTag = `
+		INSERT INTO tags (name)
+		VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id
+	`
+
+	queryRenameTag = `
+		UPDATE tags
+		SET name = $2
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM tags WHERE name = $2 AND id <> $1)
+	`
+
+	queryAttachTag = `
+		INSERT INTO post_tags (blog_post_id, tag_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	queryMoveTagPosts = `
+		INSERT INTO post_tags (blog_post_id, tag_id)
+		SELECT blog_post_id, $2 FROM post_tags WHERE tag_id = $1
+		ON CONFLICT DO NOTHING
+	`
+
+	queryDeleteTag = `
+		DELETE FROM tags
+		WHERE id = $1
+	`
+
+	queryPostTags = `
+		SELECT pt.blog_post_id, t.name
+		FROM post_tags pt
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE pt.blog_post_id IN (%s)
+		ORDER BY t.name
+	`
+
+	// The tag conditions of the posts listing, the names are filled in as placeholders
+	conditionAnyTag = `
+		AND EXISTS (SELECT 1 FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.blog_post_id = b.id AND t.name IN (%s))
+	`
+
+	conditionAllTags = `
+		AND (SELECT COUNT(*) FROM post_tags pt JOIN tags t ON t.id = pt.tag_id
+			WHERE pt.blog_post_id = b.id AND t.name IN (%s)) = %d
+	`
+)
+
+// GetTags lists every tag by name along with how many published posts carry it
+func (r *blogRepository) GetTags(ctx context.Context) ([]*response.TagResponse, error) {
+	logger := log.FromContext(ctx)
+	rows, err := r.db.QueryContext(ctx, queryTagsWithPostCount)
+	if err != nil {
+		logger.Error("[RepoGetTags] failed to query tags", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	tags := []*response.TagResponse{}
+	for rows.Next() {
+		tag := &model.Tag{}
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.PostCount); err != nil {
+			logger.Error("[RepoGetTags] failed to scan tag", zap.Error(err))
+			return nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		tags = append(tags, tag.ToTagResponse())
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetTags] row iteration error", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return tags, nil
+}
+
+func (r *blogRepository) CreateTag(ctx context.Context, name string) (int, error) {
+	logger := log.FromContext(ctx).With(zap.String("tag_name", name))
+	var id int
+	err := r.db.QueryRowContext(ctx, queryCreateTag, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoCreateTag] tag already exists")
+		return 0, errors.Join(app_err.ErrConflict, errors.New("tag already exists"))
+	}
+	if err != nil {
+		logger.Error("[RepoCreateTag] could not persist the tag", zap.Error(err))
+		return 0, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoCreateTag] tag created", zap.Int("tag_id", id))
+	return id, nil
+}
+
+func (r *blogRepository) RenameTag(ctx context.Context, id int, name string) error {
+	logger := log.FromContext(ctx).With(zap.Int("tag_id", id), zap.String("tag_name", name))
+	result, err := r.db.ExecContext(ctx, queryRenameTag, id, name)
+	if err != nil {
+		logger.Error("[RepoRenameTag] could not rename the tag", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoRenameTag] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, queryTagExists, id).Scan(&exists); err != nil {
+			logger.Error("[RepoRenameTag] failed to check if tag exists", zap.Error(err))
+			return errors.Join(app_err.ErrInternalServer, err)
+		}
+		if !exists {
+			logger.Info("[RepoRenameTag] could not find the tag")
+			return app_err.ErrNotFound
+		}
+		logger.Info("[RepoRenameTag] tag name already taken")
+		return errors.Join(app_err.ErrConflict, errors.New("another tag already has this name, merge them instead"))
+	}
+
+	logger.Info("[RepoRenameTag] tag renamed")
+	return nil
+}
+
+// MergeTags moves every post of the source tag to the target tag and removes the source tag
+func (r *blogRepository) MergeTags(ctx context.Context, sourceID, targetID int) error {
+	logger := log.FromContext(ctx).With(zap.Int("source_tag_id", sourceID), zap.Int("target_tag_id", targetID))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("[RepoMergeTags] could not begin transaction", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range []int{sourceID, targetID} {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, queryTagExists, id).Scan(&exists); err != nil {
+			logger.Error("[RepoMergeTags] failed to check if tag exists", zap.Error(err))
+			return errors.Join(app_err.ErrInternalServer, err)
+		}
+		if !exists {
+			logger.Info("[RepoMergeTags] could not find the tag", zap.Int("tag_id", id))
+			return app_err.ErrNotFound
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, queryMoveTagPosts, sourceID, targetID); err != nil {
+		logger.Error("[RepoMergeTags] could not move the posts", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, queryDeleteTag, sourceID); err != nil {
+		logger.Error("[RepoMergeTags] could not delete the source tag", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("[RepoMergeTags] could not commit transaction", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoMergeTags] tags merged")
+	return nil
+}
+
+// attachTags creates the tags that do not exist yet and links all of them to the post
+func (r *blogRepository) attachTags(ctx context.Context, tx *transaction, logger *zap.Logger, postID int, tags []string) error {
+	for _, name := range tags {
+		var tagID int
+		if err := tx.QueryRowContext(ctx, queryUpsertTag, name).Scan(&tagID); err != nil {
+			logger.Error("[RepoAttachTags] could not persist the tag", zap.Error(err), zap.String("tag_name", name))
+			return errors.Join(app_err.ErrInternalServer, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, queryAttachTag, postID, tagID); err != nil {
+			logger.Error("[RepoAttachTags] could not attach the tag", zap.Error(err), zap.String("tag_name", name))
+			return errors.Join(app_err.ErrInternalServer, err)
+		}
+	}
+
+	return nil
+}
+
+// getPostTags reads the tag names of each of the given posts, sorted by name
+func (r *blogRepository) getPostTags(ctx context.Context, logger *zap.Logger, postIDs []int) (map[int][]string, error) {
+	tags := make(map[int][]string, len(postIDs))
+	if len(postIDs) == 0 {
+		return tags, nil
+	}
+
+	args := make([]any, len(postIDs))
+	for idx, id := range postIDs {
+		args[idx] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(queryPostTags, placeholders(1, len(args))), args...)
+	if err != nil {
+		logger.Error("[RepoGetPostTags] failed to query post tags", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID int
+		var name string
+		if err := rows.Scan(&postID, &name); err != nil {
+			logger.Error("[RepoGetPostTags] failed to scan post tag", zap.Error(err))
+			return nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		tags[postID] = append(tags[postID], name)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetPostTags] row iteration error", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return tags, nil
+}
+
+// tagCondition builds the condition restricting the posts listing to the filtered tags, with its placeholders
+// numbered from first
+func tagCondition(filter model.TagFilter, first int) (string, []any) {
+	if len(filter.Tags) == 0 {
+		return "", nil
+	}
+
+	args := make([]any, len(filter.Tags))
+	for idx, tag := range filter.Tags {
+		args[idx] = tag
+	}
+
+	names := placeholders(first, len(args))
+	if filter.Match == model.TagMatchAll {
+		return fmt.Sprintf(conditionAllTags, names, len(args)), args
+	}
+	return fmt.Sprintf(conditionAnyTag, names), args
+}
+
+// placeholders renders count numbered placeholders starting at first, as in "$3, $4, $5"
+func placeholders(first, count int) string {
+	params := make([]string, count)
+	for idx := range params {
+		params[idx] = fmt.Sprintf("$%d", first+idx)
+	}
+	return strings.Join(params, ", ")
+}
@@ -0,0 +1,232 @@
+//go:generate mockgen -destination ./mocks/mock_user.go -package mocks github.com/aleszilagyi/prosig-blog/internal/repository UserRepository
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.uber.org/zap"
+)
+
+const (
+	queryCreateUser = `
+		INSERT INTO users (username, display_name, password_hash, role)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	queryUserByUsername = `
+		SELECT id, username, display_name, role, password_hash, created_at
+		FROM users
+		WHERE username = $1
+	`
+
+	queryUserByID = `
+		SELECT id, username, display_name, role, created_at
+		FROM users
+		WHERE id = $1
+	`
+
+	queryUsers = `
+		SELECT id, username, display_name, role, created_at
+		FROM users
+		ORDER BY id
+	`
+
+	queryUpdateUserRole = `
+		UPDATE users
+		SET role = $2
+		WHERE id = $1
+	`
+
+	queryDeleteUser = `
+		DELETE FROM users
+		WHERE id = $1
+	`
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *model.User) (*response.UserResponse, error)
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	GetUser(ctx context.Context, id int) (*model.User, error)
+	GetUsers(ctx context.Context) ([]*response.UserResponse, error)
+	UpdateUserRole(ctx context.Context, id int, role model.Role) error
+	DeleteUser(ctx context.Context, id int) error
+}
+
+type userRepository struct {
+	db *database
+}
+
+func NewUserRepository(db *sql.DB, dialect Dialect) UserRepository {
+	return &userRepository{db: newDatabase(db, dialect)}
+}
+
+// CreateUser stores the user with its role, users without one start as commenters
+func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (*response.UserResponse, error) {
+	logger := log.FromContext(ctx).With(zap.String("username", user.Username))
+	if user.Role == "" {
+		user.Role = model.RoleCommenter
+	}
+
+	err := r.db.QueryRowContext(ctx, queryCreateUser, user.Username, user.DisplayName, user.PasswordHash, user.Role).Scan(&user.ID, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoCreateUser] username already taken")
+		return nil, errors.Join(app_err.ErrConflict, errors.New("username already taken"))
+	}
+	if err != nil {
+		logger.Error("[RepoCreateUser] could not persist the user", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	logger.Info("[RepoCreateUser] user created", zap.Int("user_id", user.ID), zap.String("role", string(user.Role)))
+	return user.ToUserResponse(), nil
+}
+
+// GetUserByUsername reads the user along with its password hash, it must never be sent back as is
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	logger := log.FromContext(ctx).With(zap.String("username", username))
+	user := &model.User{}
+	err := r.db.QueryRowContext(ctx, queryUserByUsername, username).Scan(
+		&user.ID,
+		&user.Username,
+		&user.DisplayName,
+		&user.Role,
+		&user.PasswordHash,
+		&user.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetUserByUsername] could not find the user")
+		return nil, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoGetUserByUsername] failed to query user", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return user, nil
+}
+
+// GetUser reads the user without its password hash
+func (r *userRepository) GetUser(ctx context.Context, id int) (*model.User, error) {
+	logger := log.FromContext(ctx).With(zap.Int("user_id", id))
+	user := &model.User{}
+	err := r.db.QueryRowContext(ctx, queryUserByID, id).Scan(
+		&user.ID,
+		&user.Username,
+		&user.DisplayName,
+		&user.Role,
+		&user.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("[RepoGetUser] could not find the user")
+		return nil, app_err.ErrNotFound
+	}
+	if err != nil {
+		logger.Error("[RepoGetUser] failed to query user", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) GetUsers(ctx context.Context) ([]*response.UserResponse, error) {
+	logger := log.FromContext(ctx)
+	rows, err := r.db.QueryContext(ctx, queryUsers)
+	if err != nil {
+		logger.Error("[RepoGetUsers] failed to query users", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+	defer rows.Close()
+
+	users := []*response.UserResponse{}
+	for rows.Next() {
+		user := &model.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.DisplayName, &user.Role, &user.CreatedAt); err != nil {
+			logger.Error("[RepoGetUsers] failed to scan user", zap.Error(err))
+			return nil, errors.Join(app_err.ErrInternalServer, err)
+		}
+		users = append(users, user.ToUserResponse())
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("[RepoGetUsers] row iteration error", zap.Error(err))
+		return nil, errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id int, role model.Role) error {
+	logger := log.FromContext(ctx).With(zap.Int("user_id", id), zap.String("role", string(role)))
+	result, err := r.db.ExecContext(ctx, queryUpdateUserRole, id, role)
+	if err != nil {
+		logger.Error("[RepoUpdateUserRole] could not update the user role", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoUpdateUserRole] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoUpdateUserRole] could not find the user")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoUpdateUserRole] user role updated")
+	return nil
+}
+
+// DeleteUser removes the user, the posts and comments they wrote are kept without an author
+func (r *userRepository) DeleteUser(ctx context.Context, id int) error {
+	logger := log.FromContext(ctx).With(zap.Int("user_id", id))
+	result, err := r.db.ExecContext(ctx, queryDeleteUser, id)
+	if err != nil {
+		logger.Error("[RepoDeleteUser] could not delete the user", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("[RepoDeleteUser] could not read affected rows", zap.Error(err))
+		return errors.Join(app_err.ErrInternalServer, err)
+	}
+
+	if affected == 0 {
+		logger.Info("[RepoDeleteUser] could not find the user")
+		return app_err.ErrNotFound
+	}
+
+	logger.Info("[RepoDeleteUser] user deleted")
+	return nil
+}
+
+// authorColumns receives the author of a post or a comment, whose columns are all null when it has none
+type authorColumns struct {
+	ID          *int
+	Username    *string
+	DisplayName *string
+}
+
+func (a *authorColumns) toAuthor() *model.Author {
+	if a.ID == nil {
+		return nil
+	}
+	author := &model.Author{ID: *a.ID}
+	if a.Username != nil {
+		author.Username = *a.Username
+	}
+	if a.DisplayName != nil {
+		author.DisplayName = *a.DisplayName
+	}
+	return author
+}
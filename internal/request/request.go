@@ -2,36 +2,539 @@ package request
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+
+	// EmbeddedCommentsLimit caps how many comments are returned together with a post,
+	// the remaining ones are read through the comments endpoint
+	EmbeddedCommentsLimit = 10
+
+	// DefaultMaxCommentDepth is used when no positive max_comment_depth is configured
+	DefaultMaxCommentDepth = 5
+
+	// MaxPostTitleLength matches the title column, longer titles are rejected before reaching the database
+	MaxPostTitleLength = 255
+
+	MaxTagNameLength = 50
+	MaxPostTags      = 10
+
+	MinUsernameLength = 3
+	MaxUsernameLength = 50
+	MinPasswordLength = 8
+	// bcrypt ignores anything past 72 bytes
+	MaxPasswordLength    = 72
+	MaxDisplayNameLength = 100
+
+	MaxAPIKeyNameLength = 100
+
+	MaxSearchQueryLength = 200
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_.-]+$`)
+
+// The rules of the fields several requests share
+var (
+	postTitleRules      = []rule{required, notBlank, maxLength(MaxPostTitleLength)}
+	postContentRules    = []rule{required, notBlank}
+	commentContentRules = []rule{required, notBlank}
+	tagNameRules        = []rule{required, maxLength(MaxTagNameLength)}
+
+	postStatusRule = oneOf(string(model.PostStatusDraft), string(model.PostStatusPublished),
+		string(model.PostStatusScheduled), string(model.PostStatusArchived))
 )
 
 type CreateBlogPostRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"post_content"`
+	Title     string     `json:"title"`
+	Content   string     `json:"post_content"`
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+	Tags      []string   `json:"tags"`
+}
+
+type UpdateBlogPostRequest struct {
+	Title     *string    `json:"title"`
+	Content   *string    `json:"post_content"`
+	Status    *string    `json:"status"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// ToPost builds the post to be created, posts are published right away unless told otherwise
+func (req *CreateBlogPostRequest) ToPost() *model.Post {
+	status := model.PostStatus(req.Status)
+	if status == "" {
+		status = model.PostStatusPublished
+	}
+
+	return &model.Post{
+		Title:     req.Title,
+		Content:   req.Content,
+		Status:    status,
+		PublishAt: utc(req.PublishAt),
+		Tags:      model.NormalizeTags(req.Tags),
+	}
+}
+
+func (req *UpdateBlogPostRequest) ToPostChanges() model.PostChanges {
+	changes := model.PostChanges{
+		Title:     req.Title,
+		Content:   req.Content,
+		PublishAt: utc(req.PublishAt),
+	}
+	if req.Status != nil {
+		status := model.PostStatus(*req.Status)
+		changes.Status = &status
+	}
+	return changes
 }
 
 type AddCommentRequest struct {
+	Content         string `json:"comment_content"`
+	ParentCommentID *int   `json:"parent_comment_id"`
+}
+
+type PageQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type PostsQuery struct {
+	PageQuery
+	Tags  []string `form:"tag"`
+	Match string   `form:"match"`
+}
+
+type CommentsQuery struct {
+	PageQuery
+	View string `form:"view"`
+}
+
+// SearchQuery pages through ranked results, its cursor is only valid for the same search terms
+type SearchQuery struct {
+	Query  string `form:"q"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type RevisionDiffQuery struct {
+	From int `form:"from"`
+	To   int `form:"to"`
+}
+
+type UpdateCommentRequest struct {
 	Content string `json:"comment_content"`
 }
 
+// TagRequest carries the name of a tag to be created or the new name of a tag to be renamed
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+type RegisterUserRequest struct {
+	Username    string `json:"username"`
+	Password    string `json:"password"`
+	DisplayName string `json:"display_name"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// CreateAPIKeyRequest describes a new API key, it acts for the admin creating it unless another user is given
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	UserID    *int       `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ToAPIKey builds the key to be stored for the given owner, deduplicating its scopes
+func (req *CreateAPIKeyRequest) ToAPIKey(userID int, prefix, keyHash string) *model.APIKey {
+	scopes := []model.Scope{}
+	seen := map[model.Scope]bool{}
+	for _, value := range req.Scopes {
+		scope := model.Scope(value)
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &model.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		ExpiresAt: utc(req.ExpiresAt),
+	}
+}
+
+// ToUser builds the user to be registered, the display name defaults to the username. Users registering
+// themselves are commenters until an admin changes their role
+func (req *RegisterUserRequest) ToUser(passwordHash string) *model.User {
+	displayName := strings.TrimSpace(req.DisplayName)
+	if displayName == "" {
+		displayName = req.Username
+	}
+
+	return &model.User{
+		Username:     req.Username,
+		DisplayName:  displayName,
+		PasswordHash: passwordHash,
+		Role:         model.RoleCommenter,
+	}
+}
+
+type MergeTagRequest struct {
+	IntoTagID int `json:"into_tag_id"`
+}
+
 func ValidateCreateBlogPost(req *CreateBlogPostRequest) error {
-	var err error
-	if req.Content == "" {
-		return errors.Join(app_err.ErrInvalidInput, errors.New("post content cannot be empty"))
+	var v violations
+	v.check(
+		newField("title", "post title", req.Title, postTitleRules...),
+		newField("post_content", "post content", req.Content, postContentRules...),
+	)
+
+	if req.Status != "" || req.PublishAt != nil {
+		checkPostStatus(&v, req.Status, req.PublishAt)
+	}
+
+	if req.Tags != nil {
+		checkTags(&v, "tags", req.Tags)
+	}
+
+	return v.err()
+}
+
+// ValidateReplaceBlogPost validates a full replacement (PUT), where title and content are required
+func ValidateReplaceBlogPost(req *UpdateBlogPostRequest) error {
+	var v violations
+	v.check(
+		newField("title", "post title", valueOf(req.Title), postTitleRules...),
+		newField("post_content", "post content", valueOf(req.Content), postContentRules...),
+	)
+	checkPostStatusChange(&v, req)
+
+	return v.err()
+}
+
+// ValidateUpdateBlogPost validates a partial update (PATCH), where omitted fields are kept as they are
+func ValidateUpdateBlogPost(req *UpdateBlogPostRequest) error {
+	if req.Title == nil && req.Content == nil && req.Status == nil && req.PublishAt == nil {
+		return errors.Join(app_err.ErrInvalidInput, errors.New("at least one field must be provided"))
 	}
 
-	if req.Title == "" {
-		return errors.Join(app_err.ErrInvalidInput, errors.New("post title cannot be empty"))
+	var v violations
+	v.check(
+		optionalField("title", "post title", req.Title, postTitleRules...),
+		optionalField("post_content", "post content", req.Content, postContentRules...),
+	)
+	checkPostStatusChange(&v, req)
+
+	return v.err()
+}
+
+// checkPostStatusChange checks the new status of the post. A publish time alone reschedules the post,
+// which the repository only accepts while the post is scheduled
+func checkPostStatusChange(v *violations, req *UpdateBlogPostRequest) {
+	if req.Status == nil {
+		if req.PublishAt != nil && !req.PublishAt.After(time.Now()) {
+			v.add("publish_at", CodeInvalid, "publish_at must be in the future")
+		}
+		return
 	}
 
-	return err
+	checkPostStatus(v, *req.Status, req.PublishAt)
+}
+
+// checkPostStatus checks the status is known and that only scheduled posts, and all of them, have a future publish time
+func checkPostStatus(v *violations, status string, publishAt *time.Time) {
+	if !model.PostStatus(status).IsValid() {
+		v.check(newField("status", "post status", status, postStatusRule))
+		return
+	}
+
+	switch {
+	case model.PostStatus(status) != model.PostStatusScheduled:
+		if publishAt != nil {
+			v.add("publish_at", CodeInvalid, "publish_at requires the scheduled status")
+		}
+	case publishAt == nil:
+		v.add("publish_at", CodeRequired, "scheduled posts require publish_at")
+	case !publishAt.After(time.Now()):
+		v.add("publish_at", CodeInvalid, "publish_at must be in the future")
+	}
 }
 
 func ValidateAddComment(req *AddCommentRequest) error {
-	if req.Content == "" {
-		return errors.Join(app_err.ErrInvalidInput, errors.New("comment content cannot be empty"))
+	var v violations
+	v.check(newField("comment_content", "comment content", req.Content, commentContentRules...))
+
+	if req.ParentCommentID != nil && *req.ParentCommentID <= 0 {
+		v.add("parent_comment_id", CodeInvalid, "invalid parent comment id")
+	}
+
+	return v.err()
+}
+
+// ValidateReplyDepth checks that a reply to a comment at parentDepth does not go deeper than maxDepth,
+// or DefaultMaxCommentDepth when it is not positive. Root comments are at depth 0
+func ValidateReplyDepth(parentDepth, maxDepth int) error {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxCommentDepth
+	}
+	if parentDepth+1 > maxDepth {
+		return errors.Join(app_err.ErrInvalidInput, fmt.Errorf("replies cannot be nested deeper than %d levels", maxDepth))
 	}
 
 	return nil
 }
+
+func ValidateUpdateComment(req *UpdateCommentRequest) error {
+	var v violations
+	v.check(newField("comment_content", "comment content", req.Content, commentContentRules...))
+
+	return v.err()
+}
+
+// ValidatePageQuery checks the pagination query parameters and turns them into a page to be read
+func ValidatePageQuery(query *PageQuery) (model.Page, error) {
+	limit, err := validatePageLimit(query.Limit)
+	if err != nil {
+		return model.Page{}, err
+	}
+
+	page := model.Page{Limit: limit}
+
+	if query.Cursor != "" {
+		cursor, err := model.DecodeCursor(query.Cursor)
+		if err != nil {
+			return model.Page{}, errors.Join(app_err.ErrInvalidInput, errors.New("invalid cursor"))
+		}
+		page.Cursor = cursor
+	}
+
+	return page, nil
+}
+
+// ValidateSearchQuery returns the trimmed search terms along with the page of results to read
+func ValidateSearchQuery(query *SearchQuery) (string, model.SearchPage, error) {
+	terms := strings.TrimSpace(query.Query)
+	if terms == "" {
+		return "", model.SearchPage{}, errors.Join(app_err.ErrInvalidInput, errors.New("search query cannot be empty"))
+	}
+
+	if utf8.RuneCountInString(terms) > MaxSearchQueryLength {
+		return "", model.SearchPage{}, errors.Join(app_err.ErrInvalidInput,
+			fmt.Errorf("search query cannot be longer than %d characters", MaxSearchQueryLength))
+	}
+
+	limit, err := validatePageLimit(query.Limit)
+	if err != nil {
+		return "", model.SearchPage{}, err
+	}
+
+	page := model.SearchPage{Limit: limit}
+	if query.Cursor != "" {
+		cursor, err := model.DecodeSearchCursor(query.Cursor)
+		if err != nil {
+			return "", model.SearchPage{}, errors.Join(app_err.ErrInvalidInput, errors.New("invalid cursor"))
+		}
+		page.Cursor = cursor
+	}
+
+	return terms, page, nil
+}
+
+func validatePageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultPageLimit, nil
+	}
+
+	if limit < 0 || limit > MaxPageLimit {
+		return 0, errors.Join(app_err.ErrInvalidInput, fmt.Errorf("limit must be between 1 and %d", MaxPageLimit))
+	}
+
+	return limit, nil
+}
+
+// ValidateCommentView parses how comments should be laid out, defaulting to a flat list
+func ValidateCommentView(view string) (model.CommentView, error) {
+	switch model.CommentView(view) {
+	case "", model.CommentViewFlat:
+		return model.CommentViewFlat, nil
+	case model.CommentViewTree:
+		return model.CommentViewTree, nil
+	default:
+		return "", errors.Join(app_err.ErrInvalidInput, fmt.Errorf("view must be either %q or %q", model.CommentViewFlat, model.CommentViewTree))
+	}
+}
+
+func ValidateCommentsQuery(query *CommentsQuery) (model.Page, model.CommentView, error) {
+	page, err := ValidatePageQuery(&query.PageQuery)
+	if err != nil {
+		return model.Page{}, "", err
+	}
+
+	view, err := ValidateCommentView(query.View)
+	if err != nil {
+		return model.Page{}, "", err
+	}
+
+	return page, view, nil
+}
+
+func ValidateRevisionDiffQuery(query *RevisionDiffQuery) error {
+	if query.From <= 0 || query.To <= 0 {
+		return errors.Join(app_err.ErrInvalidInput, errors.New("from and to must be valid revision numbers"))
+	}
+
+	return nil
+}
+
+func ValidateTag(req *TagRequest) error {
+	var v violations
+	v.check(newField("name", "tag name", model.NormalizeTagName(req.Name), tagNameRules...))
+
+	return v.err()
+}
+
+// ValidateMergeTag checks the tag being merged has a distinct target tag
+func ValidateMergeTag(sourceID int, req *MergeTagRequest) error {
+	if req.IntoTagID <= 0 {
+		return errors.Join(app_err.ErrInvalidInput, errors.New("invalid into_tag_id"))
+	}
+
+	if req.IntoTagID == sourceID {
+		return errors.Join(app_err.ErrInvalidInput, errors.New("a tag cannot be merged into itself"))
+	}
+
+	return nil
+}
+
+// ValidatePostsQuery checks the posts listing query parameters, matching any of the filtered tags by default
+func ValidatePostsQuery(query *PostsQuery) (model.Page, model.TagFilter, error) {
+	page, err := ValidatePageQuery(&query.PageQuery)
+	if err != nil {
+		return model.Page{}, model.TagFilter{}, err
+	}
+
+	filter := model.TagFilter{Match: model.TagMatch(query.Match)}
+	switch filter.Match {
+	case "":
+		filter.Match = model.TagMatchAny
+	case model.TagMatchAny, model.TagMatchAll:
+	default:
+		return model.Page{}, model.TagFilter{}, errors.Join(app_err.ErrInvalidInput,
+			fmt.Errorf("match must be either %q or %q", model.TagMatchAny, model.TagMatchAll))
+	}
+
+	if len(query.Tags) > 0 {
+		var v violations
+		checkTags(&v, "tag", query.Tags)
+		if err := v.err(); err != nil {
+			return model.Page{}, model.TagFilter{}, err
+		}
+		filter.Tags = model.NormalizeTags(query.Tags)
+	}
+
+	return page, filter, nil
+}
+
+// checkTags checks the number of tags and every tag name, the names as they are stored
+func checkTags(v *violations, path string, tags []string) {
+	if len(tags) > MaxPostTags {
+		v.add(path, CodeTooMany, fmt.Sprintf("at most %d tags are allowed", MaxPostTags))
+	}
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = model.NormalizeTagName(tag)
+	}
+	v.check(eachField(path, "tag name", names, tagNameRules...)...)
+}
+
+func ValidateRegisterUser(req *RegisterUserRequest) error {
+	var v violations
+	v.check(
+		newField("username", "username", req.Username, minLength(MinUsernameLength), maxLength(MaxUsernameLength),
+			allowedCharacters(usernamePattern, "lowercase letters, digits, dots, dashes and underscores")),
+		newField("password", "password", req.Password, minLength(MinPasswordLength), maxBytes(MaxPasswordLength)),
+		newField("display_name", "display name", req.DisplayName, maxLength(MaxDisplayNameLength)),
+	)
+
+	return v.err()
+}
+
+func ValidateLogin(req *LoginRequest) error {
+	var v violations
+	v.check(
+		newField("username", "username", req.Username, required),
+		newField("password", "password", req.Password, required),
+	)
+
+	return v.err()
+}
+
+func ValidateUpdateUserRole(req *UpdateUserRoleRequest) error {
+	var v violations
+	v.check(newField("role", "role", req.Role, oneOf(string(model.RoleAdmin), string(model.RoleEditor),
+		string(model.RoleAuthor), string(model.RoleCommenter))))
+
+	return v.err()
+}
+
+func ValidateCreateAPIKey(req *CreateAPIKeyRequest) error {
+	var v violations
+	v.check(newField("name", "api key name", strings.TrimSpace(req.Name), required, maxLength(MaxAPIKeyNameLength)))
+
+	if len(req.Scopes) == 0 {
+		v.add("scopes", CodeRequired, "api keys require at least one scope")
+	}
+	v.check(eachField("scopes", "scope", req.Scopes,
+		oneOf(string(model.ScopePostsWrite), string(model.ScopeCommentsModerate), string(model.ScopeRead)))...)
+
+	if req.UserID != nil && *req.UserID <= 0 {
+		v.add("user_id", CodeInvalid, "user_id must be a positive integer")
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		v.add("expires_at", CodeInvalid, "expires_at must be in the future")
+	}
+
+	return v.err()
+}
+
+// utc moves a time given with any offset to UTC, as times are stored
+func utc(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	converted := value.UTC()
+	return &converted
+}
+
+// valueOf reads a field that may have been left out, as empty
+func valueOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
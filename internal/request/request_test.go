@@ -4,8 +4,10 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,6 +70,137 @@ func TestValidateCreateBlogPost(t *testing.T) {
 	}
 }
 
+func TestValidateCreateBlogPost_Status(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		status    string
+		publishAt *time.Time
+		errMsg    string
+	}{
+		{name: "draft", status: "draft"},
+		{name: "scheduled in the future", status: "scheduled", publishAt: &future},
+		{name: "unknown status", status: "deleted", errMsg: "post status must be one of"},
+		{name: "scheduled without publish_at", status: "scheduled", errMsg: "scheduled posts require publish_at"},
+		{name: "scheduled in the past", status: "scheduled", publishAt: &past, errMsg: "publish_at must be in the future"},
+		{name: "publish_at without scheduled status", status: "draft", publishAt: &future, errMsg: "publish_at requires the scheduled status"},
+		{name: "publish_at without status", publishAt: &future, errMsg: "post status must be one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCreateBlogPost(&CreateBlogPostRequest{
+				Title:     "Hello",
+				Content:   "This is a blog post",
+				Status:    tt.status,
+				PublishAt: tt.publishAt,
+			})
+
+			if tt.errMsg != "" {
+				assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCreateBlogPostRequest_ToPost(t *testing.T) {
+	post := (&CreateBlogPostRequest{Title: "Hello", Content: "World"}).ToPost()
+	assert.Equal(t, model.PostStatusPublished, post.Status)
+
+	post = (&CreateBlogPostRequest{Title: "Hello", Content: "World", Status: "draft"}).ToPost()
+	assert.Equal(t, model.PostStatusDraft, post.Status)
+
+	publishAt := time.Date(2030, 1, 2, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	post = (&CreateBlogPostRequest{Title: "Hello", Content: "World", Status: "scheduled", PublishAt: &publishAt}).ToPost()
+	assert.Equal(t, time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC), *post.PublishAt, "publish times are kept in UTC")
+
+	changes := (&UpdateBlogPostRequest{PublishAt: &publishAt}).ToPostChanges()
+	assert.Equal(t, time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC), *changes.PublishAt)
+}
+
+func TestValidateUpdateBlogPost(t *testing.T) {
+	title, content, empty := "Hello", "This is a blog post", ""
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		req       *UpdateBlogPostRequest
+		replace   bool
+		wantError bool
+		errMsg    string
+	}{
+		{
+			name:    "valid replacement",
+			req:     &UpdateBlogPostRequest{Title: &title, Content: &content},
+			replace: true,
+		},
+		{
+			name:      "replacement without title",
+			req:       &UpdateBlogPostRequest{Content: &content},
+			replace:   true,
+			wantError: true,
+			errMsg:    "post title cannot be empty",
+		},
+		{
+			name:      "replacement with empty content",
+			req:       &UpdateBlogPostRequest{Title: &title, Content: &empty},
+			replace:   true,
+			wantError: true,
+			errMsg:    "post content cannot be empty",
+		},
+		{
+			name: "valid partial update",
+			req:  &UpdateBlogPostRequest{Title: &title},
+		},
+		{
+			name:      "partial update without fields",
+			req:       &UpdateBlogPostRequest{},
+			wantError: true,
+			errMsg:    "at least one field must be provided",
+		},
+		{
+			name:      "partial update with empty title",
+			req:       &UpdateBlogPostRequest{Title: &empty},
+			wantError: true,
+			errMsg:    "post title cannot be empty",
+		},
+		{
+			name: "reschedule with publish_at alone",
+			req:  &UpdateBlogPostRequest{PublishAt: &future},
+		},
+		{
+			name:      "reschedule in the past",
+			req:       &UpdateBlogPostRequest{PublishAt: &past},
+			wantError: true,
+			errMsg:    "publish_at must be in the future",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.replace {
+				err = ValidateReplaceBlogPost(tt.req)
+			} else {
+				err = ValidateUpdateBlogPost(tt.req)
+			}
+
+			if tt.wantError {
+				assert.Error(t, err, "expected an error")
+				assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+				assert.True(t, strings.Contains(err.Error(), tt.errMsg), "error message should contain: %s", tt.errMsg)
+			} else {
+				assert.NoError(t, err, "expected no error")
+			}
+		})
+	}
+}
+
 func TestValidateAddComment(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -90,6 +223,23 @@ func TestValidateAddComment(t *testing.T) {
 			wantError: true,
 			errMsg:    "comment content cannot be empty",
 		},
+		{
+			name: "valid reply",
+			req: &AddCommentRequest{
+				Content:         "I agree",
+				ParentCommentID: func() *int { id := 1; return &id }(),
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid parent comment id",
+			req: &AddCommentRequest{
+				Content:         "I agree",
+				ParentCommentID: func() *int { id := 0; return &id }(),
+			},
+			wantError: true,
+			errMsg:    "invalid parent comment id",
+		},
 	}
 
 	for _, tt := range tests {
@@ -106,3 +256,289 @@ func TestValidateAddComment(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateUpdateComment(t *testing.T) {
+	assert.NoError(t, ValidateUpdateComment(&UpdateCommentRequest{Content: "Edited comment"}))
+
+	err := ValidateUpdateComment(&UpdateCommentRequest{Content: ""})
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	assert.Contains(t, err.Error(), "comment content cannot be empty")
+}
+
+func TestValidatePageQuery(t *testing.T) {
+	cursor := &model.Cursor{CreatedAt: time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC), ID: 7}
+
+	t.Run("defaults to the first page", func(t *testing.T) {
+		page, err := ValidatePageQuery(&PageQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPageLimit, page.Limit)
+		assert.Nil(t, page.Cursor)
+	})
+
+	t.Run("decodes the cursor", func(t *testing.T) {
+		page, err := ValidatePageQuery(&PageQuery{Cursor: cursor.Encode(), Limit: 5})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, page.Limit)
+		assert.Equal(t, cursor.ID, page.Cursor.ID)
+	})
+
+	t.Run("rejects an invalid cursor", func(t *testing.T) {
+		_, err := ValidatePageQuery(&PageQuery{Cursor: "garbage"})
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	})
+
+	t.Run("rejects a limit out of range", func(t *testing.T) {
+		_, err := ValidatePageQuery(&PageQuery{Limit: MaxPageLimit + 1})
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+		_, err = ValidatePageQuery(&PageQuery{Limit: -1})
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	})
+}
+
+func TestValidateReplyDepth(t *testing.T) {
+	assert.NoError(t, ValidateReplyDepth(0, 1))
+	assert.NoError(t, ValidateReplyDepth(3, 5))
+
+	err := ValidateReplyDepth(5, 5)
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	assert.Contains(t, err.Error(), "replies cannot be nested deeper than 5 levels")
+}
+
+func TestValidateReplyDepth_Unconfigured(t *testing.T) {
+	assert.NoError(t, ValidateReplyDepth(0, 0), "replies should be allowed when no depth is configured")
+	assert.NoError(t, ValidateReplyDepth(DefaultMaxCommentDepth-1, -1))
+
+	err := ValidateReplyDepth(DefaultMaxCommentDepth, 0)
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateCommentView(t *testing.T) {
+	view, err := ValidateCommentView("")
+	assert.NoError(t, err)
+	assert.Equal(t, model.CommentViewFlat, view)
+
+	view, err = ValidateCommentView("tree")
+	assert.NoError(t, err)
+	assert.Equal(t, model.CommentViewTree, view)
+
+	_, err = ValidateCommentView("graph")
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateRevisionDiffQuery(t *testing.T) {
+	assert.NoError(t, ValidateRevisionDiffQuery(&RevisionDiffQuery{From: 1, To: 3}))
+
+	err := ValidateRevisionDiffQuery(&RevisionDiffQuery{From: 1})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+	err = ValidateRevisionDiffQuery(&RevisionDiffQuery{From: -1, To: 2})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidatePostsQuery(t *testing.T) {
+	page, filter, err := ValidatePostsQuery(&PostsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPageLimit, page.Limit)
+	assert.Equal(t, model.TagFilter{Match: model.TagMatchAny}, filter)
+
+	_, filter, err = ValidatePostsQuery(&PostsQuery{Tags: []string{"Go", "go", "sql"}, Match: "all"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TagFilter{Tags: []string{"go", "sql"}, Match: model.TagMatchAll}, filter)
+
+	_, _, err = ValidatePostsQuery(&PostsQuery{Tags: []string{"go"}, Match: "most"})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+	_, _, err = ValidatePostsQuery(&PostsQuery{Tags: []string{""}})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateTag(t *testing.T) {
+	assert.NoError(t, ValidateTag(&TagRequest{Name: "go"}))
+
+	err := ValidateTag(&TagRequest{Name: "   "})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+	err = ValidateTag(&TagRequest{Name: strings.Repeat("a", MaxTagNameLength+1)})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateMergeTag(t *testing.T) {
+	assert.NoError(t, ValidateMergeTag(2, &MergeTagRequest{IntoTagID: 1}))
+
+	err := ValidateMergeTag(2, &MergeTagRequest{IntoTagID: 2})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+
+	err = ValidateMergeTag(2, &MergeTagRequest{})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateRegisterUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegisterUserRequest
+		wantErr bool
+	}{
+		{name: "valid", req: RegisterUserRequest{Username: "ada.l", Password: "long enough"}},
+		{name: "short username", req: RegisterUserRequest{Username: "ad", Password: "long enough"}, wantErr: true},
+		{name: "uppercase username", req: RegisterUserRequest{Username: "Ada", Password: "long enough"}, wantErr: true},
+		{name: "short password", req: RegisterUserRequest{Username: "ada", Password: "short"}, wantErr: true},
+		{name: "long password", req: RegisterUserRequest{Username: "ada", Password: strings.Repeat("a", MaxPasswordLength+1)}, wantErr: true},
+		{name: "long display name", req: RegisterUserRequest{Username: "ada", Password: "long enough", DisplayName: strings.Repeat("a", MaxDisplayNameLength+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRegisterUser(&tt.req)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegisterUserRequest_ToUser(t *testing.T) {
+	user := (&RegisterUserRequest{Username: "ada", Password: "secret"}).ToUser("hash")
+	assert.Equal(t, &model.User{Username: "ada", DisplayName: "ada", PasswordHash: "hash", Role: model.RoleCommenter}, user)
+
+	user = (&RegisterUserRequest{Username: "ada", DisplayName: " Ada Lovelace "}).ToUser("hash")
+	assert.Equal(t, "Ada Lovelace", user.DisplayName)
+}
+
+func TestValidateLogin(t *testing.T) {
+	assert.NoError(t, ValidateLogin(&LoginRequest{Username: "ada", Password: "secret"}))
+
+	err := ValidateLogin(&LoginRequest{Username: "ada"})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateUpdateUserRole(t *testing.T) {
+	assert.NoError(t, ValidateUpdateUserRole(&UpdateUserRoleRequest{Role: "editor"}))
+
+	for _, role := range []string{"", "owner", "Admin"} {
+		err := ValidateUpdateUserRole(&UpdateUserRoleRequest{Role: role})
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "role %q should be rejected", role)
+	}
+}
+
+func TestValidateCreateAPIKey(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+	userID, badUserID := 3, 0
+
+	assert.NoError(t, ValidateCreateAPIKey(&CreateAPIKeyRequest{Name: "ci", Scopes: []string{"posts:write", "read"}}))
+	assert.NoError(t, ValidateCreateAPIKey(&CreateAPIKeyRequest{Name: "ci", Scopes: []string{"read"}, UserID: &userID, ExpiresAt: &future}))
+
+	invalid := []*CreateAPIKeyRequest{
+		{Name: " ", Scopes: []string{"read"}},
+		{Name: strings.Repeat("k", MaxAPIKeyNameLength+1), Scopes: []string{"read"}},
+		{Name: "ci"},
+		{Name: "ci", Scopes: []string{"write"}},
+		{Name: "ci", Scopes: []string{"read"}, UserID: &badUserID},
+		{Name: "ci", Scopes: []string{"read"}, ExpiresAt: &past},
+	}
+	for _, req := range invalid {
+		err := ValidateCreateAPIKey(req)
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "request %+v should be rejected", req)
+	}
+}
+
+func TestCreateAPIKeyRequest_ToAPIKey(t *testing.T) {
+	req := &CreateAPIKeyRequest{Name: " ci ", Scopes: []string{"read", "posts:write", "read"}}
+
+	key := req.ToAPIKey(4, "pbk_abcdefgh", "hash")
+
+	assert.Equal(t, 4, key.UserID)
+	assert.Equal(t, "ci", key.Name)
+	assert.Equal(t, []model.Scope{model.ScopeRead, model.ScopePostsWrite}, key.Scopes)
+	assert.Equal(t, "hash", key.KeyHash)
+
+	expiresAt := time.Date(2030, 1, 2, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	req.ExpiresAt = &expiresAt
+	key = req.ToAPIKey(4, "pbk_abcdefgh", "hash")
+	assert.Equal(t, time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC), *key.ExpiresAt, "expiry times are kept in UTC")
+}
+
+func TestValidateSearchQuery(t *testing.T) {
+	terms, page, err := ValidateSearchQuery(&SearchQuery{Query: "  go generics "})
+	assert.NoError(t, err)
+	assert.Equal(t, "go generics", terms)
+	assert.Equal(t, DefaultPageLimit, page.Limit)
+	assert.Nil(t, page.Cursor)
+
+	cursor := &model.SearchCursor{Rank: 0.25, ID: 3}
+	_, page, err = ValidateSearchQuery(&SearchQuery{Query: "go", Cursor: cursor.Encode(), Limit: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, page.Limit)
+	assert.Equal(t, cursor, page.Cursor)
+
+	invalid := []*SearchQuery{
+		{Query: "   "},
+		{Query: strings.Repeat("q", MaxSearchQueryLength+1)},
+		{Query: "go", Limit: MaxPageLimit + 1},
+		{Query: "go", Cursor: "not a cursor"},
+	}
+	for _, query := range invalid {
+		_, _, err := ValidateSearchQuery(query)
+		assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "query %+v should be rejected", query)
+	}
+}
+
+func TestValidateSearchQuery_LengthInCharacters(t *testing.T) {
+	query := strings.Repeat("é", MaxSearchQueryLength)
+	_, _, err := ValidateSearchQuery(&SearchQuery{Query: query})
+	assert.NoError(t, err)
+
+	_, _, err = ValidateSearchQuery(&SearchQuery{Query: query + "é"})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateCreateBlogPost_ReportsEveryViolation(t *testing.T) {
+	err := ValidateCreateBlogPost(&CreateBlogPostRequest{
+		Title:   strings.Repeat("t", MaxPostTitleLength+1),
+		Content: "  \n ",
+		Status:  "deleted",
+		Tags:    []string{"go", " ", strings.Repeat("g", MaxTagNameLength+1)},
+	})
+
+	var appErr *app_err.Error
+	assert.True(t, errors.As(err, &appErr), "error should be an application error")
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	assert.Equal(t, []app_err.Violation{
+		{Field: "title", Code: CodeTooLong, Message: "post title cannot be longer than 255 characters"},
+		{Field: "post_content", Code: CodeBlank, Message: "post content cannot be blank"},
+		{Field: "status", Code: CodeNotAllowed, Message: `post status must be one of "draft", "published", "scheduled" or "archived"`},
+		{Field: "tags[1]", Code: CodeRequired, Message: "tag name cannot be empty"},
+		{Field: "tags[2]", Code: CodeTooLong, Message: "tag name cannot be longer than 50 characters"},
+	}, appErr.Violations)
+}
+
+func TestValidateCreateBlogPost_TitleLengthInCharacters(t *testing.T) {
+	title := strings.Repeat("é", MaxPostTitleLength)
+	assert.NoError(t, ValidateCreateBlogPost(&CreateBlogPostRequest{Title: title, Content: "content"}))
+
+	err := ValidateCreateBlogPost(&CreateBlogPostRequest{Title: title + "é", Content: "content"})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+}
+
+func TestValidateUpdateBlogPost_LongTitle(t *testing.T) {
+	title := strings.Repeat("t", MaxPostTitleLength+1)
+
+	err := ValidateUpdateBlogPost(&UpdateBlogPostRequest{Title: &title})
+	assert.True(t, errors.Is(err, app_err.ErrInvalidInput), "error should wrap ErrInvalidInput")
+	assert.Contains(t, err.Error(), "post title cannot be longer than 255 characters")
+}
+
+func TestValidateRegisterUser_ReportsEveryViolation(t *testing.T) {
+	err := ValidateRegisterUser(&RegisterUserRequest{Username: "A", Password: "short"})
+
+	var appErr *app_err.Error
+	assert.True(t, errors.As(err, &appErr), "error should be an application error")
+	assert.Equal(t, []app_err.Violation{
+		{Field: "username", Code: CodeTooShort, Message: "username must be at least 3 characters"},
+		{Field: "password", Code: CodeTooShort, Message: "password must be at least 8 characters"},
+	}, appErr.Violations)
+}
@@ -0,0 +1,167 @@
+package request
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+)
+
+// The codes of the rules a field can break, clients branch on them rather than on the message
+const (
+	CodeRequired          = "required"
+	CodeBlank             = "blank"
+	CodeTooShort          = "too_short"
+	CodeTooLong           = "too_long"
+	CodeInvalidCharacters = "invalid_characters"
+	CodeNotAllowed        = "not_allowed"
+	CodeTooMany           = "too_many"
+	CodeInvalid           = "invalid"
+)
+
+// rule checks the value of a field, returning the code and message of the violation when the value breaks it
+// and an empty code otherwise. The label names the field in the message
+type rule func(label, value string) (code, message string)
+
+// field declares the rules a field of a request must follow, the path is its JSON path, like tags[1]
+type field struct {
+	path    string
+	label   string
+	value   string
+	omitted bool
+	rules   []rule
+}
+
+// required rejects empty values
+func required(label, value string) (string, string) {
+	if value == "" {
+		return CodeRequired, label + " cannot be empty"
+	}
+	return "", ""
+}
+
+// notBlank rejects values made only of whitespace
+func notBlank(label, value string) (string, string) {
+	if value != "" && strings.TrimSpace(value) == "" {
+		return CodeBlank, label + " cannot be blank"
+	}
+	return "", ""
+}
+
+// minLength rejects values shorter than n characters
+func minLength(n int) rule {
+	return func(label, value string) (string, string) {
+		if utf8.RuneCountInString(value) < n {
+			return CodeTooShort, fmt.Sprintf("%s must be at least %d characters", label, n)
+		}
+		return "", ""
+	}
+}
+
+// maxLength rejects values longer than n characters, as the database counts them
+func maxLength(n int) rule {
+	return func(label, value string) (string, string) {
+		if utf8.RuneCountInString(value) > n {
+			return CodeTooLong, fmt.Sprintf("%s cannot be longer than %d characters", label, n)
+		}
+		return "", ""
+	}
+}
+
+// maxBytes rejects values longer than n bytes, for the values that are hashed rather than stored
+func maxBytes(n int) rule {
+	return func(label, value string) (string, string) {
+		if len(value) > n {
+			return CodeTooLong, fmt.Sprintf("%s cannot be longer than %d bytes", label, n)
+		}
+		return "", ""
+	}
+}
+
+// allowedCharacters rejects values the pattern does not match, the description tells what it allows
+func allowedCharacters(pattern *regexp.Regexp, description string) rule {
+	return func(label, value string) (string, string) {
+		if !pattern.MatchString(value) {
+			return CodeInvalidCharacters, label + " can only contain " + description
+		}
+		return "", ""
+	}
+}
+
+// oneOf rejects values other than the given ones
+func oneOf(values ...string) rule {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	allowed := quoted[len(quoted)-1]
+	if len(quoted) > 1 {
+		allowed = strings.Join(quoted[:len(quoted)-1], ", ") + " or " + allowed
+	}
+
+	return func(label, value string) (string, string) {
+		if !slices.Contains(values, value) {
+			return CodeNotAllowed, label + " must be one of " + allowed
+		}
+		return "", ""
+	}
+}
+
+func newField(path, label, value string, rules ...rule) field {
+	return field{path: path, label: label, value: value, rules: rules}
+}
+
+// optionalField is left unchecked when the request leaves it out
+func optionalField(path, label string, value *string, rules ...rule) field {
+	if value == nil {
+		return field{path: path, omitted: true}
+	}
+	return newField(path, label, *value, rules...)
+}
+
+// eachField applies the rules to every item of a list, each one with its index in its path
+func eachField(path, label string, values []string, rules ...rule) []field {
+	fields := make([]field, len(values))
+	for i, value := range values {
+		fields[i] = newField(fmt.Sprintf("%s[%d]", path, i), label, value, rules...)
+	}
+	return fields
+}
+
+// violations collects every rule the fields of a request break, so that they are all reported at once
+type violations []app_err.Violation
+
+// check reports the first rule each field breaks
+func (v *violations) check(fields ...field) {
+	for _, f := range fields {
+		if f.omitted {
+			continue
+		}
+		for _, r := range f.rules {
+			if code, message := r(f.label, f.value); code != "" {
+				v.add(f.path, code, message)
+				break
+			}
+		}
+	}
+}
+
+func (v *violations) add(path, code, message string) {
+	*v = append(*v, app_err.Violation{Field: path, Code: code, Message: message})
+}
+
+// err returns the invalid input error listing the violations, or nil when there are none
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Message
+	}
+	return app_err.New(app_err.ErrInvalidInput, app_err.CodeInvalidInput, strings.Join(messages, "; ")).WithViolations(v...)
+}
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"regexp"
+	"testing"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  rule
+		value string
+		code  string
+	}{
+		{name: "required", rule: required, value: "x"},
+		{name: "required empty", rule: required, value: "", code: CodeRequired},
+		{name: "not blank", rule: notBlank, value: " x "},
+		{name: "not blank empty", rule: notBlank, value: ""},
+		{name: "not blank whitespace", rule: notBlank, value: " \t\n", code: CodeBlank},
+		{name: "min length in characters", rule: minLength(3), value: "äöü"},
+		{name: "min length", rule: minLength(3), value: "ab", code: CodeTooShort},
+		{name: "max length in characters", rule: maxLength(3), value: "äöü"},
+		{name: "max length", rule: maxLength(3), value: "abcd", code: CodeTooLong},
+		{name: "max bytes", rule: maxBytes(3), value: "äö", code: CodeTooLong},
+		{name: "allowed characters", rule: allowedCharacters(regexp.MustCompile(`^[a-z]+$`), "letters"), value: "abc"},
+		{name: "disallowed characters", rule: allowedCharacters(regexp.MustCompile(`^[a-z]+$`), "letters"), value: "a c", code: CodeInvalidCharacters},
+		{name: "one of", rule: oneOf("a", "b"), value: "b"},
+		{name: "not one of", rule: oneOf("a", "b"), value: "c", code: CodeNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := tt.rule("field", tt.value)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestViolations(t *testing.T) {
+	var v violations
+	assert.NoError(t, v.err())
+
+	title := ""
+	v.check(
+		newField("name", "name", "", required, minLength(2)),
+		optionalField("title", "title", nil, required),
+		optionalField("subtitle", "subtitle", &title, required),
+	)
+	v.check(eachField("tags", "tag", []string{"go", ""}, required)...)
+
+	assert.Equal(t, violations{
+		{Field: "name", Code: CodeRequired, Message: "name cannot be empty"},
+		{Field: "subtitle", Code: CodeRequired, Message: "subtitle cannot be empty"},
+		{Field: "tags[1]", Code: CodeRequired, Message: "tag cannot be empty"},
+	}, v, "only the first rule a field breaks should be reported")
+
+	err := v.err()
+	problem := app_err.From(err)
+	assert.Equal(t, app_err.CodeInvalidInput, problem.Code)
+	assert.Equal(t, "name cannot be empty; subtitle cannot be empty; tag cannot be empty", problem.Detail)
+	assert.Len(t, problem.Violations, 3)
+}
@@ -5,27 +5,121 @@ type ResponseDataWrapper[T any] struct {
 }
 
 type CommentResponse struct {
-	ID        int    `json:"id"`
-	Content   string `json:"content"`
-	CreatedAt string `json:"created_ad"`
+	ID              int                `json:"id"`
+	ParentCommentID *int               `json:"parent_comment_id"`
+	Depth           int                `json:"depth"`
+	Author          *AuthorResponse    `json:"author"`
+	Content         string             `json:"content"`
+	CreatedAt       string             `json:"created_ad"`
+	EditedAt        *string            `json:"edited_at,omitempty"`
+	Replies         []*CommentResponse `json:"replies,omitempty"`
+	RepliesCursor   *string            `json:"replies_cursor,omitempty"`
 }
 
 type PostWithCommentCountResponse struct {
-	ID           int    `json:"id"`
-	Title        string `json:"title"`
-	Content      string `json:"content"`
-	CreatedAt    string `json:"created_at"`
-	UpdatedAt    string `json:"updated_at"`
-	CommentCount int    `json:"comment_count"`
+	ID           int             `json:"id"`
+	Title        string          `json:"title"`
+	Slug         string          `json:"slug"`
+	Author       *AuthorResponse `json:"author"`
+	Content      string          `json:"content"`
+	CreatedAt    string          `json:"created_at"`
+	UpdatedAt    string          `json:"updated_at"`
+	Status       string          `json:"status"`
+	PublishAt    *string         `json:"publish_at,omitempty"`
+	Tags         []string        `json:"tags"`
+	CommentCount int             `json:"comment_count"`
 }
 
 type PostWithCommentsResponse struct {
-	ID        int                `json:"id"`
-	Title     string             `json:"title"`
-	Content   string             `json:"content"`
-	CreatedAt string             `json:"created_at"`
-	UpdatedAt string             `json:"updated_at"`
-	Comments  []*CommentResponse `json:"comments"`
+	ID                 int                `json:"id"`
+	Title              string             `json:"title"`
+	Slug               string             `json:"slug"`
+	Author             *AuthorResponse    `json:"author"`
+	Content            string             `json:"content"`
+	CreatedAt          string             `json:"created_at"`
+	UpdatedAt          string             `json:"updated_at"`
+	Status             string             `json:"status"`
+	PublishAt          *string            `json:"publish_at,omitempty"`
+	Tags               []string           `json:"tags"`
+	CommentCount       int                `json:"comment_count"`
+	Comments           []*CommentResponse `json:"comments"`
+	NextCommentsCursor *string            `json:"next_comments_cursor"`
+}
+
+type AuthorResponse struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+type UserResponse struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type TokenResponse struct {
+	Token     string `json:"token"`
+	TokenType string `json:"token_type"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+type SearchResultResponse struct {
+	PostID           int             `json:"post_id"`
+	Title            string          `json:"title"`
+	Slug             string          `json:"slug"`
+	Author           *AuthorResponse `json:"author"`
+	Rank             float32         `json:"rank"`
+	TitleHighlight   string          `json:"title_highlight"`
+	Snippet          string          `json:"snippet"`
+	MatchingComments int             `json:"matching_comments"`
+	CreatedAt        string          `json:"created_at"`
+}
+
+type APIKeyResponse struct {
+	ID         int      `json:"id"`
+	UserID     int      `json:"user_id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is the only time the key itself is sent back, it cannot be read again
+type CreatedAPIKeyResponse struct {
+	Key string `json:"key"`
+	*APIKeyResponse
+}
+
+type TagResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	PostCount int    `json:"post_count"`
+}
+
+type RevisionResponse struct {
+	Revision             int    `json:"revision"`
+	Title                string `json:"title"`
+	Content              string `json:"content,omitempty"`
+	RestoredFromRevision *int   `json:"restored_from_revision,omitempty"`
+	CreatedAt            string `json:"created_at"`
+}
+
+type DiffLineResponse struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type RevisionDiffResponse struct {
+	FromRevision int                 `json:"from_revision"`
+	ToRevision   int                 `json:"to_revision"`
+	Title        []*DiffLineResponse `json:"title"`
+	Content      []*DiffLineResponse `json:"content"`
 }
 
 func WrapResponse(data map[string]interface{}) *ResponseDataWrapper[map[string]interface{}] {
@@ -33,3 +127,36 @@ func WrapResponse(data map[string]interface{}) *ResponseDataWrapper[map[string]i
 		Data: data,
 	}
 }
+
+// CheckResponse is the outcome of a single readiness check, with the figures it looked at
+type CheckResponse struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status string                    `json:"status"`
+	Checks map[string]*CheckResponse `json:"checks"`
+}
+
+// ProblemContentType is the media type of the error responses, RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// ProblemResponse describes why a request failed as an RFC 7807 problem, extended with the stable code
+// of the problem and, for invalid input, every field at fault
+type ProblemResponse struct {
+	Type       string               `json:"type"`
+	Title      string               `json:"title"`
+	Status     int                  `json:"status"`
+	Detail     string               `json:"detail,omitempty"`
+	Instance   string               `json:"instance,omitempty"`
+	Code       string               `json:"code"`
+	Violations []*ViolationResponse `json:"violations,omitempty"`
+}
+
+type ViolationResponse struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,265 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/handler"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// The problems of requests that cannot be authenticated
+var (
+	errAuthenticationRequired = app_err.New(app_err.ErrUnauthorized, "authentication_required", "authentication required")
+	errInvalidToken           = app_err.New(app_err.ErrUnauthorized, "invalid_token", "invalid or expired token")
+	errInvalidAPIKey          = app_err.New(app_err.ErrUnauthorized, "invalid_api_key", "invalid, expired or revoked api key")
+)
+
+const (
+	apiKeyHeader    = "X-API-Key"
+	requestIDHeader = "X-Request-ID"
+	// problemTypePrefix makes the code of a problem its type, a URI as RFC 7807 asks
+	problemTypePrefix = "urn:prosig-blog:problem:"
+	// maxRequestIDLength bounds the request ids taken from callers, longer ones are replaced
+	maxRequestIDLength = 128
+)
+
+// requestLogger gives every request an id, the caller's X-Request-ID when it is a sensible one, and echoes it back.
+// The logger the handlers and repositories retrieve from the request context carries the id, the route and
+// the ids of the trace, so the lines of a request can be told apart from the others
+func requestLogger(base *zap.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(requestIDHeader, requestID)
+
+		fields := []zap.Field{zap.String("request_id", requestID), zap.String("route", routeOf(ctx))}
+		if span := trace.SpanContextFromContext(ctx.Request.Context()); span.IsValid() {
+			fields = append(fields, zap.String("trace_id", span.TraceID().String()), zap.String("span_id", span.SpanID().String()))
+		}
+
+		ctx.Request = ctx.Request.WithContext(log.WithContext(ctx.Request.Context(), base.With(fields...)))
+		ctx.Next()
+	}
+}
+
+// routeOf returns the route template that matched the request, which keeps ids out of the logs, or its path
+// when no route matched
+func routeOf(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+	return ctx.Request.URL.Path
+}
+
+// accessLog logs every request once it is handled, with the logger of the request so the line carries its id
+// and route. The requests whose route or path is skipped are left out, and only a sample of the successful
+// requests is logged when the ratio is under one
+func accessLog(cfg config.AccessLogConfig) gin.HandlerFunc {
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skip[path] = true
+	}
+	sampleAll := cfg.SuccessSampleRatio <= 0 || cfg.SuccessSampleRatio >= 1
+
+	return func(ctx *gin.Context) {
+		if skip[ctx.FullPath()] || skip[ctx.Request.URL.Path] {
+			ctx.Next()
+			return
+		}
+
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		if status < http.StatusBadRequest && !sampleAll && rand.Float64() >= cfg.SuccessSampleRatio {
+			return
+		}
+
+		fields := []zap.Field{
+			zap.String("method", ctx.Request.Method),
+			zap.Int("http_status", status),
+			zap.Duration("latency", time.Since(start)),
+			zap.Int("bytes", max(ctx.Writer.Size(), 0)),
+			zap.String("client_ip", ctx.ClientIP()),
+			zap.String("user_agent", ctx.Request.UserAgent()),
+		}
+		logger := log.FromContext(ctx.Request.Context())
+		if status >= http.StatusInternalServerError {
+			logger.Error("[MiddlewareAccessLog] request failed", fields...)
+			return
+		}
+		logger.Info("[MiddlewareAccessLog] request handled", fields...)
+	}
+}
+
+// recovery answers 500 to the requests whose handler panicked, logging the panic with the logger of the request
+func recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		status := http.StatusInternalServerError
+		log.FromContext(ctx.Request.Context()).Error("[MiddlewareRecovery] recovered from a panic",
+			zap.Any("recover", recovered),
+			zap.Int("http_status", status),
+		)
+		handler.AbortWithError(ctx, app_err.ErrInternalServer)
+	})
+}
+
+// problemDetails renders the error the request was stopped with, when nothing was written yet, as an
+// application/problem+json document. Errors that are not application errors are not disclosed
+func problemDetails() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		problem := app_err.From(ctx.Errors.Last().Err)
+		resp := &response.ProblemResponse{
+			Type:     problemTypePrefix + problem.Code,
+			Title:    problem.Title,
+			Status:   problem.Status,
+			Detail:   problem.Detail,
+			Instance: ctx.Request.URL.Path,
+			Code:     problem.Code,
+		}
+		for _, violation := range problem.Violations {
+			resp.Violations = append(resp.Violations, &response.ViolationResponse{
+				Field:   violation.Field,
+				Code:    violation.Code,
+				Message: violation.Message,
+			})
+		}
+
+		ctx.Header("Content-Type", response.ProblemContentType)
+		ctx.JSON(problem.Status, resp)
+	}
+}
+
+// validRequestID only accepts ids that cannot forge or garble a log line
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// authenticate only lets requests carrying a valid bearer token or API key through, making their user available
+// to handlers. API keys come in the X-API-Key header or as a bearer token, told apart from session tokens by their prefix.
+// The user of a session token is read on every request, so role changes and deletions apply before the token expires
+func authenticate(tokens *auth.TokenManager, users repository.UserRepository, keys repository.APIKeyRepository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := log.FromContext(ctx.Request.Context())
+		if key := ctx.GetHeader(apiKeyHeader); key != "" {
+			authenticateAPIKey(ctx, keys, key)
+			return
+		}
+
+		token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !found || token == "" {
+			status := http.StatusUnauthorized
+			logger.Info("[MiddlewareAuthenticate] missing bearer token", zap.Int("http_status", status))
+			handler.AbortWithError(ctx, errAuthenticationRequired)
+			return
+		}
+
+		if auth.IsAPIKey(token) {
+			authenticateAPIKey(ctx, keys, token)
+			return
+		}
+
+		principal, err := tokens.Parse(token)
+		if err != nil {
+			status := http.StatusUnauthorized
+			logger.Info("[MiddlewareAuthenticate] invalid token", zap.Error(err), zap.Int("http_status", status))
+			handler.AbortWithError(ctx, errInvalidToken)
+			return
+		}
+
+		authenticateUser(ctx, users, principal)
+	}
+}
+
+// identify lets anonymous requests through and authenticates the ones carrying a token or an API key as authenticate
+// does, for the public routes that show more to the users allowed to see it
+func identify(tokens *auth.TokenManager, users repository.UserRepository, keys repository.APIKeyRepository) gin.HandlerFunc {
+	authenticated := authenticate(tokens, users, keys)
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader(apiKeyHeader) == "" && ctx.GetHeader("Authorization") == "" {
+			ctx.Next()
+			return
+		}
+		authenticated(ctx)
+	}
+}
+
+// authenticateUser lets the request of a session token through on behalf of its user as they are now,
+// the token of a deleted user is no longer valid
+func authenticateUser(ctx *gin.Context, users repository.UserRepository, principal *auth.Principal) {
+	logger := log.FromContext(ctx.Request.Context()).With(zap.Int("user_id", principal.UserID))
+	user, err := users.GetUser(ctx.Request.Context(), principal.UserID)
+	if errors.Is(err, app_err.ErrNotFound) {
+		status := http.StatusUnauthorized
+		logger.Info("[MiddlewareAuthenticate] token of a deleted user", zap.Int("http_status", status))
+		handler.AbortWithError(ctx, errInvalidToken)
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		logger.Error("[MiddlewareAuthenticate] failed to read the user", zap.Error(err), zap.Int("http_status", status))
+		handler.AbortWithError(ctx, err)
+		return
+	}
+
+	auth.SetPrincipal(ctx, &auth.Principal{UserID: user.ID, Username: user.Username, Role: user.Role})
+	ctx.Next()
+}
+
+func authenticateAPIKey(ctx *gin.Context, keys repository.APIKeyRepository, key string) {
+	logger := log.FromContext(ctx.Request.Context())
+	apiKey, err := keys.AuthenticateAPIKey(ctx.Request.Context(), auth.HashAPIKey(key), time.Now().UTC())
+	if errors.Is(err, app_err.ErrNotFound) {
+		status := http.StatusUnauthorized
+		logger.Info("[MiddlewareAuthenticate] invalid api key", zap.Int("http_status", status))
+		handler.AbortWithError(ctx, errInvalidAPIKey)
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		logger.Error("[MiddlewareAuthenticate] failed to check api key", zap.Error(err), zap.Int("http_status", status))
+		handler.AbortWithError(ctx, err)
+		return
+	}
+
+	logger.Debug("[MiddlewareAuthenticate] authenticated with api key",
+		zap.Int("api_key_id", apiKey.ID),
+		zap.Int("user_id", apiKey.UserID),
+	)
+	auth.SetPrincipal(ctx, &auth.Principal{
+		UserID:   apiKey.User.ID,
+		Username: apiKey.User.Username,
+		Role:     apiKey.User.Role,
+		Scopes:   apiKey.Scopes,
+	})
+	ctx.Next()
+}
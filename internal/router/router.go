@@ -1,21 +1,107 @@
 package router
 
 import (
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
 	"github.com/aleszilagyi/prosig-blog/internal/handler"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/metrics"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/tracing"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(handler handler.BlogHandler) *gin.Engine {
-	r := gin.Default()
+// newEngine sets up the middlewares every router shares, tracing the request first, then giving it its logger,
+// so the access log and the metrics see the request as it was answered, errors and panics included
+func newEngine() *gin.Engine {
+	r := gin.New()
+	r.Use(
+		tracing.Middleware(),
+		requestLogger(log.GetLogger()),
+		accessLog(config.GetConfigs().LoggerConfig.AccessLog),
+		metrics.Middleware(),
+		problemDetails(),
+		recovery(),
+	)
+	r.NoRoute(handler.RouteNotFound)
+	return r
+}
+
+func SetupRouter(
+	handler handler.BlogHandler,
+	authHandler handler.AuthHandler,
+	userHandler handler.UserHandler,
+	apiKeyHandler handler.APIKeyHandler,
+	healthHandler handler.HealthHandler,
+	tokens *auth.TokenManager,
+	users repository.UserRepository,
+	keys repository.APIKeyRepository,
+) *gin.Engine {
+	r := newEngine()
+
+	// The probes and the metrics sit outside of the API, for the orchestrator to call
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	api := r.Group("/api")
 	{
-		api.POST("/posts", handler.CreateBlogPost)
-		api.POST("/posts/:id/comments", handler.AddComment)
+		api.POST("/auth/register", authHandler.Register)
+		api.POST("/auth/login", authHandler.Login)
+
 		api.GET("/posts", handler.GetAllPostsWithCommentCount)
 		api.GET("/posts/:id", handler.GetPostWithComments)
+		api.GET("/posts/by-slug/:slug", handler.GetPostBySlug)
+		api.GET("/posts/:id/comments", handler.GetComments)
+		api.GET("/posts/:id/comments/:commentId/replies", handler.GetReplies)
+		api.GET("/tags", handler.GetTags)
+		api.GET("/search", handler.SearchPosts)
+	}
+
+	// Revisions are public for published posts, the users allowed to update a post also see them while it is not
+	revisions := api.Group("/posts/:id/revisions", identify(tokens, users, keys))
+	{
+		revisions.GET("", handler.GetRevisions)
+		revisions.GET("/diff", handler.DiffRevisions)
+		revisions.GET("/:rev", handler.GetRevision)
 	}
 
+	// Every write, and the admin endpoints, require an authenticated user
+	write := api.Group("", authenticate(tokens, users, keys))
+	{
+		write.POST("/posts", handler.CreateBlogPost)
+		write.PUT("/posts/:id", handler.UpdateBlogPost)
+		write.PATCH("/posts/:id", handler.UpdateBlogPost)
+		write.DELETE("/posts/:id", handler.DeleteBlogPost)
+		write.POST("/posts/:id/comments", handler.AddComment)
+		write.PATCH("/posts/:id/comments/:commentId", handler.UpdateComment)
+		write.DELETE("/posts/:id/comments/:commentId", handler.DeleteComment)
+		write.POST("/posts/:id/revisions/:rev/restore", handler.RestoreRevision)
+		write.POST("/tags", handler.CreateTag)
+		write.PATCH("/tags/:tagId", handler.RenameTag)
+		write.POST("/tags/:tagId/merge", handler.MergeTags)
+
+		write.GET("/admin/users", userHandler.GetUsers)
+		write.PATCH("/admin/users/:userId/role", userHandler.UpdateUserRole)
+		write.DELETE("/admin/users/:userId", userHandler.DeleteUser)
+
+		write.POST("/admin/api-keys", apiKeyHandler.CreateAPIKey)
+		write.GET("/admin/api-keys", apiKeyHandler.GetAPIKeys)
+		write.DELETE("/admin/api-keys/:keyId", apiKeyHandler.RevokeAPIKey)
+	}
+
+	return r
+}
+
+// SetupUnavailableRouter serves the service while its database cannot be reached, only liveness and metrics
+// answer and every other request is told to come back after retryAfter
+func SetupUnavailableRouter(healthHandler handler.HealthHandler, retryAfter time.Duration) *gin.Engine {
+	r := newEngine()
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.NoRoute(handler.Unavailable(retryAfter))
 	return r
 }
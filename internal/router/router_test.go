@@ -2,25 +2,91 @@ package router
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/auth"
 	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
 	"github.com/aleszilagyi/prosig-blog/internal/handler"
+	"github.com/aleszilagyi/prosig-blog/internal/health"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
 	"github.com/aleszilagyi/prosig-blog/internal/repository/mocks"
 	"github.com/aleszilagyi/prosig-blog/internal/request"
 	"github.com/aleszilagyi/prosig-blog/internal/response"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+const testUserID = 42
+
+var testTokens = auth.NewTokenManager("test-secret", time.Hour)
+
+// signedIn holds the role of every user a test token was issued to, as the users authenticate reads on each request
+var signedIn = &signedInUsers{roles: map[int]model.Role{}}
+
+type signedInUsers struct {
+	repository.UserRepository
+	mu    sync.Mutex
+	roles map[int]model.Role
+}
+
+func (u *signedInUsers) GetUser(ctx context.Context, id int) (*model.User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	role, ok := u.roles[id]
+	if !ok {
+		return nil, app_err.ErrNotFound
+	}
+	return &model.User{ID: id, Username: "tester", Role: role}, nil
+}
+
+// authorize signs the request in as the test user, an admin, write routes reject anonymous requests
+func authorize(req *http.Request) {
+	authorizeAs(req, testUserID, model.RoleAdmin)
+}
+
+func authorizeAs(req *http.Request, userID int, role model.Role) {
+	token, _, err := testTokens.Issue(userID, "tester", role)
+	if err != nil {
+		panic(err)
+	}
+	signedIn.mu.Lock()
+	signedIn.roles[userID] = role
+	signedIn.mu.Unlock()
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+func setupTestRouter(h handler.BlogHandler, users repository.UserRepository, keys repository.APIKeyRepository) *gin.Engine {
+	return SetupRouter(h, handler.NewAuthHandler(users, testTokens), handler.NewUserHandler(users), handler.NewAPIKeyHandler(keys), handler.NewHealthHandler(health.NewChecker(nil, "memory")), testTokens, signedIn, keys)
+}
+
+// problemOf reads the problem document the router answered with
+func problemOf(t *testing.T, w *httptest.ResponseRecorder) *response.ProblemResponse {
+	assert.Equal(t, response.ProblemContentType, w.Header().Get("Content-Type"))
+	problem := &response.ProblemResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), problem))
+	assert.Equal(t, w.Code, problem.Status)
+	return problem
+}
+
 func TestMain(m *testing.M) {
 	config.LoadConfig()
 	code := m.Run()
@@ -34,7 +100,9 @@ func TestSetupRouter_CreateBlogPost(t *testing.T) {
 
 	mockRepo := mocks.NewMockBlogRepository(ctrl)
 	h := handler.NewBlogHandler(mockRepo)
-	r := SetupRouter(h)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	authorID := testUserID
 
 	t.Run("success", func(t *testing.T) {
 		reqBody := request.CreateBlogPostRequest{
@@ -44,11 +112,12 @@ func TestSetupRouter_CreateBlogPost(t *testing.T) {
 		body, _ := json.Marshal(reqBody)
 
 		mockRepo.EXPECT().
-			CreatePost(gomock.Any(), reqBody.Title, reqBody.Content).
+			CreatePost(gomock.Any(), &model.Post{Title: reqBody.Title, Content: reqBody.Content, Status: model.PostStatusPublished, AuthorID: &authorID}).
 			Return(123, nil)
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 
 		r.ServeHTTP(w, req)
@@ -61,9 +130,81 @@ func TestSetupRouter_CreateBlogPost(t *testing.T) {
 		assert.EqualValues(t, 123, resp["post_id"])
 	})
 
+	t.Run("success - scheduled post", func(t *testing.T) {
+		publishAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+		reqBody := request.CreateBlogPostRequest{
+			Title:     "Coming soon",
+			Content:   "My Content",
+			Status:    string(model.PostStatusScheduled),
+			PublishAt: &publishAt,
+		}
+		body, _ := json.Marshal(reqBody)
+
+		mockRepo.EXPECT().
+			CreatePost(gomock.Any(), gomock.Cond(func(post *model.Post) bool {
+				return post.Status == model.PostStatusScheduled && post.PublishAt.Equal(publishAt)
+			})).
+			Return(124, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("scheduled post without publish_at", func(t *testing.T) {
+		body := `{"title": "Coming soon", "post_content": "My Content", "status": "scheduled"}`
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "scheduled posts require publish_at")
+	})
+
+	t.Run("success - with tags", func(t *testing.T) {
+		body := `{"title": "Tagged", "post_content": "My Content", "tags": ["Go", " postgres", "go"]}`
+
+		mockRepo.EXPECT().
+			CreatePost(gomock.Any(), &model.Post{Title: "Tagged", Content: "My Content", Status: model.PostStatusPublished, Tags: []string{"go", "postgres"}, AuthorID: &authorID}).
+			Return(125, nil)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("empty tag", func(t *testing.T) {
+		body := `{"title": "Tagged", "post_content": "My Content", "tags": [" "]}`
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "tag name cannot be empty")
+	})
+
 	t.Run("malformed JSON", func(t *testing.T) {
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer([]byte("{invalid-json")))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 
 		r.ServeHTTP(w, req)
@@ -80,6 +221,7 @@ func TestSetupRouter_CreateBlogPost(t *testing.T) {
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 
 		r.ServeHTTP(w, req)
@@ -95,11 +237,12 @@ func TestSetupRouter_CreateBlogPost(t *testing.T) {
 		body, _ := json.Marshal(reqBody)
 
 		mockRepo.EXPECT().
-			CreatePost(gomock.Any(), reqBody.Title, reqBody.Content).
+			CreatePost(gomock.Any(), gomock.Any()).
 			Return(0, errors.New("db error"))
 
 		w := httptest.NewRecorder()
 		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 
 		r.ServeHTTP(w, req)
@@ -115,7 +258,7 @@ func TestAddCommentRoute(t *testing.T) {
 
 	mockRepo := mocks.NewMockBlogRepository(ctrl)
 	h := handler.NewBlogHandler(mockRepo)
-	r := SetupRouter(h)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
 
 	t.Run("success - adds a comment", func(t *testing.T) {
 		reqBody := map[string]interface{}{
@@ -125,10 +268,11 @@ func TestAddCommentRoute(t *testing.T) {
 
 		mockRepo.
 			EXPECT().
-			AddComment(gomock.Any(), 1, "Nice post!").
+			AddComment(gomock.Any(), 1, nil, testUserID, "Nice post!").
 			Return(10, nil)
 
 		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 
@@ -138,8 +282,74 @@ func TestAddCommentRoute(t *testing.T) {
 		assert.Contains(t, resp.Body.String(), `"comment_id":10`)
 	})
 
+	t.Run("success - replies to a comment", func(t *testing.T) {
+		parentID := 10
+		body, _ := json.Marshal(request.AddCommentRequest{Content: "I agree", ParentCommentID: &parentID})
+
+		mockRepo.
+			EXPECT().
+			GetComment(gomock.Any(), 1, parentID).
+			Return(&response.CommentResponse{ID: parentID, Depth: 0}, nil)
+		mockRepo.
+			EXPECT().
+			AddComment(gomock.Any(), 1, &parentID, testUserID, "I agree").
+			Return(11, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"comment_id":11`)
+	})
+
+	t.Run("error - parent comment from another post", func(t *testing.T) {
+		parentID := 20
+		body, _ := json.Marshal(request.AddCommentRequest{Content: "I agree", ParentCommentID: &parentID})
+
+		mockRepo.
+			EXPECT().
+			GetComment(gomock.Any(), 1, parentID).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "parent comment not found in this post")
+	})
+
+	t.Run("error - reply nested too deep", func(t *testing.T) {
+		parentID := 30
+		maxDepth := config.GetConfigs().BlogConfig.MaxCommentDepth
+		body, _ := json.Marshal(request.AddCommentRequest{Content: "I agree", ParentCommentID: &parentID})
+
+		mockRepo.
+			EXPECT().
+			GetComment(gomock.Any(), 1, parentID).
+			Return(&response.CommentResponse{ID: parentID, Depth: maxDepth}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "replies cannot be nested deeper")
+	})
+
 	t.Run("error - malformed json", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBufferString(`invalid_json`))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 
@@ -152,6 +362,7 @@ func TestAddCommentRoute(t *testing.T) {
 		reqBody := map[string]interface{}{"comment_content": "Hello"}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/api/posts/invalid/comments", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 
@@ -166,6 +377,7 @@ func TestAddCommentRoute(t *testing.T) {
 		reqBody := map[string]interface{}{"comment_content": ""}
 		body, _ := json.Marshal(reqBody)
 		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 
@@ -182,10 +394,11 @@ func TestAddCommentRoute(t *testing.T) {
 
 		mockRepo.
 			EXPECT().
-			AddComment(gomock.Any(), 1, "Good job").
+			AddComment(gomock.Any(), 1, nil, testUserID, "Good job").
 			Return(0, app_err.ErrInternalServer)
 
 		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
+		authorize(req)
 		req.Header.Set("Content-Type", "application/json")
 		resp := httptest.NewRecorder()
 
@@ -203,7 +416,7 @@ func TestGetPostWithComments(t *testing.T) {
 
 	mockRepo := mocks.NewMockBlogRepository(ctrl)
 	h := handler.NewBlogHandler(mockRepo)
-	r := SetupRouter(h)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
 
 	t.Run("GetPostWithComments - success", func(t *testing.T) {
 		mockPost := &response.PostWithCommentsResponse{
@@ -219,7 +432,7 @@ func TestGetPostWithComments(t *testing.T) {
 
 		mockRepo.
 			EXPECT().
-			GetPostWithComments(gomock.Any(), 1).
+			GetPostWithComments(gomock.Any(), 1, model.Page{Limit: request.EmbeddedCommentsLimit}, model.CommentViewFlat).
 			Return(mockPost, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
@@ -235,6 +448,45 @@ func TestGetPostWithComments(t *testing.T) {
 		assert.Equal(t, mockPost.ID, body["post"].ID)
 	})
 
+	t.Run("GetPostWithComments - tree view", func(t *testing.T) {
+		parentID := 1
+		mockPost := &response.PostWithCommentsResponse{
+			ID: 3,
+			Comments: []*response.CommentResponse{
+				{ID: 1, Content: "Root", Replies: []*response.CommentResponse{
+					{ID: 2, ParentCommentID: &parentID, Depth: 1, Content: "Reply"},
+				}},
+			},
+		}
+
+		mockRepo.
+			EXPECT().
+			GetPostWithComments(gomock.Any(), 3, gomock.Any(), model.CommentViewTree).
+			Return(mockPost, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3?view=tree", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string]response.PostWithCommentsResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Len(t, body["post"].Comments[0].Replies, 1)
+		assert.Equal(t, 1, body["post"].Comments[0].Replies[0].Depth)
+	})
+
+	t.Run("GetPostWithComments - invalid view", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1?view=graph", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
 	t.Run("GetPostWithComments - invalid post id", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, "/api/posts/abc", nil)
 		resp := httptest.NewRecorder()
@@ -248,7 +500,7 @@ func TestGetPostWithComments(t *testing.T) {
 	t.Run("GetPostWithComments - repo error", func(t *testing.T) {
 		mockRepo.
 			EXPECT().
-			GetPostWithComments(gomock.Any(), 2).
+			GetPostWithComments(gomock.Any(), 2, gomock.Any(), gomock.Any()).
 			Return(nil, app_err.ErrInternalServer)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/posts/2", nil)
@@ -261,6 +513,135 @@ func TestGetPostWithComments(t *testing.T) {
 	})
 }
 
+func TestGetCommentsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success - first page", func(t *testing.T) {
+		mockComments := []*response.CommentResponse{
+			{ID: 3, Content: "Third"},
+			{ID: 2, Content: "Second"},
+		}
+
+		mockRepo.
+			EXPECT().
+			GetComments(gomock.Any(), 1, model.Page{Limit: 2}, model.CommentViewFlat).
+			Return(mockComments, "next-page", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments?limit=2", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body struct {
+			Comments   []response.CommentResponse `json:"comments"`
+			NextCursor *string                    `json:"next_cursor"`
+		}
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Len(t, body.Comments, 2)
+		assert.Equal(t, "next-page", *body.NextCursor)
+	})
+
+	t.Run("error - invalid post id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/abc/comments", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid post id")
+	})
+
+	t.Run("error - invalid cursor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments?cursor=garbage", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - post not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetComments(gomock.Any(), 99, gomock.Any(), gomock.Any()).
+			Return(nil, "", app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/99/comments", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestGetRepliesRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success - following replies", func(t *testing.T) {
+		cursor := &model.Cursor{CreatedAt: time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC), ID: 4}
+		mockRepo.
+			EXPECT().
+			GetReplies(gomock.Any(), 1, 2, model.Page{Cursor: cursor, Limit: 5}).
+			Return([]*response.CommentResponse{{ID: 5, Content: "Fifth"}}, "", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments/2/replies?limit=5&cursor="+cursor.Encode(), nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body struct {
+			Replies    []response.CommentResponse `json:"replies"`
+			NextCursor *string                    `json:"next_cursor"`
+		}
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Len(t, body.Replies, 1)
+		assert.Nil(t, body.NextCursor)
+	})
+
+	t.Run("error - invalid comment id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments/abc/replies", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid comment id")
+	})
+
+	t.Run("error - thread not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetReplies(gomock.Any(), 1, 99, gomock.Any()).
+			Return(nil, "", app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments/99/replies", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
 func TestGetAllPostsWithCommentCount(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	ctrl := gomock.NewController(t)
@@ -268,7 +649,7 @@ func TestGetAllPostsWithCommentCount(t *testing.T) {
 
 	mockRepo := mocks.NewMockBlogRepository(ctrl)
 	h := handler.NewBlogHandler(mockRepo)
-	r := SetupRouter(h)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
 
 	t.Run("GetAllPostsWithCommentCount - success", func(t *testing.T) {
 		mockPosts := []*response.PostWithCommentCountResponse{
@@ -278,8 +659,8 @@ func TestGetAllPostsWithCommentCount(t *testing.T) {
 
 		mockRepo.
 			EXPECT().
-			GetAllPostsWithCommentCount(gomock.Any()).
-			Return(mockPosts, nil)
+			GetAllPostsWithCommentCount(gomock.Any(), model.Page{Limit: request.DefaultPageLimit}, model.TagFilter{Match: model.TagMatchAny}).
+			Return(mockPosts, "", nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
 		resp := httptest.NewRecorder()
@@ -288,24 +669,2029 @@ func TestGetAllPostsWithCommentCount(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.Code)
 
-		var body map[string][]response.PostWithCommentCountResponse
+		var body struct {
+			Posts      []response.PostWithCommentCountResponse `json:"posts"`
+			NextCursor *string                                 `json:"next_cursor"`
+		}
 		err := json.Unmarshal(resp.Body.Bytes(), &body)
 		assert.NoError(t, err)
-		assert.Len(t, body["posts"], 2)
+		assert.Len(t, body.Posts, 2)
+		assert.Nil(t, body.NextCursor)
 	})
 
-	t.Run("GetAllPostsWithCommentCount - repo error", func(t *testing.T) {
+	t.Run("GetAllPostsWithCommentCount - next page", func(t *testing.T) {
+		cursor := &model.Cursor{CreatedAt: time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC), ID: 5}
+		mockPosts := []*response.PostWithCommentCountResponse{
+			{ID: 4, Title: "Post 4", CommentCount: 1},
+		}
+
 		mockRepo.
 			EXPECT().
-			GetAllPostsWithCommentCount(gomock.Any()).
-			Return(nil, errors.New("db error"))
+			GetAllPostsWithCommentCount(gomock.Any(), gomock.Cond(func(page model.Page) bool {
+				return page.Limit == 1 && page.Cursor != nil && page.Cursor.ID == cursor.ID
+			}), gomock.Any()).
+			Return(mockPosts, "next-page", nil)
 
-		req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?limit=1&cursor="+cursor.Encode(), nil)
 		resp := httptest.NewRecorder()
 
 		r.ServeHTTP(resp, req)
 
-		assert.Equal(t, http.StatusInternalServerError, resp.Code)
-		assert.Contains(t, resp.Body.String(), "internal server error")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"next_cursor":"next-page"`)
 	})
+
+	t.Run("GetAllPostsWithCommentCount - filtered by tags", func(t *testing.T) {
+		filter := model.TagFilter{Tags: []string{"go", "postgres"}, Match: model.TagMatchAll}
+		mockPosts := []*response.PostWithCommentCountResponse{
+			{ID: 3, Title: "Post 3", Tags: []string{"go", "postgres"}},
+		}
+
+		mockRepo.
+			EXPECT().
+			GetAllPostsWithCommentCount(gomock.Any(), model.Page{Limit: request.DefaultPageLimit}, filter).
+			Return(mockPosts, "", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?tag=Go&tag=postgres&match=all", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"tags":["go","postgres"]`)
+	})
+
+	t.Run("GetAllPostsWithCommentCount - invalid match", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?tag=go&match=some", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("GetAllPostsWithCommentCount - invalid cursor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?cursor=not-a-cursor", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid cursor")
+	})
+
+	t.Run("GetAllPostsWithCommentCount - limit out of range", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?limit=1000", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("GetAllPostsWithCommentCount - non numeric limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts?limit=abc", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("GetAllPostsWithCommentCount - repo error", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetAllPostsWithCommentCount(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, "", errors.New("db error"))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Contains(t, resp.Body.String(), "internal server error")
+	})
+}
+
+func TestUpdateBlogPostRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success - PUT replaces the post", func(t *testing.T) {
+		title, content := "New Title", "New Content"
+		body, _ := json.Marshal(request.UpdateBlogPostRequest{Title: &title, Content: &content})
+
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, model.PostChanges{Title: &title, Content: &content}).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/posts/1", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"post_id":1`)
+	})
+
+	t.Run("success - PATCH updates only the title", func(t *testing.T) {
+		title := "Fixed typo"
+		body, _ := json.Marshal(map[string]interface{}{"title": title})
+
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, model.PostChanges{Title: &title}).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("success - PATCH archives the post", func(t *testing.T) {
+		archived := model.PostStatusArchived
+
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, model.PostChanges{Status: &archived}).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{"status": "archived"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("success - PATCH reschedules with publish_at alone", func(t *testing.T) {
+		publishAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, model.PostChanges{PublishAt: &publishAt}).
+			Return(nil)
+
+		body := fmt.Sprintf(`{"publish_at": %q}`, publishAt.Format(time.RFC3339))
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("error - PATCH reschedules a post that is not scheduled", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, gomock.Any()).
+			Return(errors.Join(app_err.ErrInvalidInput, errors.New("publish_at requires the scheduled status")))
+
+		body := fmt.Sprintf(`{"publish_at": %q}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "publish_at requires the scheduled status")
+	})
+
+	t.Run("error - PATCH with unknown status", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{"status": "deleted"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - PUT with missing content", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"title": "Only title"})
+
+		req := httptest.NewRequest(http.MethodPut, "/api/posts/1", bytes.NewBuffer(body))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "post content cannot be empty")
+	})
+
+	t.Run("error - PATCH without fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - malformed json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`invalid_json`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - invalid post id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/abc", bytes.NewBufferString(`{"title": "Title"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid post id")
+	})
+
+	t.Run("error - post not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 99, gomock.Any()).
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/99", bytes.NewBufferString(`{"title": "Title"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestDeleteBlogPostRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeletePost(gomock.Any(), 1).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Empty(t, resp.Body.String())
+	})
+
+	t.Run("error - invalid post id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/abc", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - post not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeletePost(gomock.Any(), 2).
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/2", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Contains(t, resp.Body.String(), app_err.ErrNotFound.Error())
+	})
+
+	t.Run("error - repository fails", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeletePost(gomock.Any(), 3).
+			Return(app_err.ErrInternalServer)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/3", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestUpdateCommentRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success - edits a comment", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			UpdateComment(gomock.Any(), 1, 10, "Edited").
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1/comments/10", bytes.NewBufferString(`{"comment_content": "Edited"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"comment_id":10`)
+	})
+
+	t.Run("error - invalid comment id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1/comments/abc", bytes.NewBufferString(`{"comment_content": "Edited"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid comment id")
+	})
+
+	t.Run("error - empty content", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1/comments/10", bytes.NewBufferString(`{"comment_content": ""}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - comment not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			UpdateComment(gomock.Any(), 1, 11, "Edited").
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1/comments/11", bytes.NewBufferString(`{"comment_content": "Edited"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestDeleteCommentRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeleteComment(gomock.Any(), 1, 10).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/10", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+
+	t.Run("error - invalid post id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/abc/comments/10", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid post id")
+	})
+
+	t.Run("error - comment not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeleteComment(gomock.Any(), 1, 11).
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/11", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestGetRevisionsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		revisions := []*response.RevisionResponse{
+			{Revision: 2, Title: "Edited", CreatedAt: "2025-01-02T00:00:00Z"},
+			{Revision: 1, Title: "Original", CreatedAt: "2025-01-01T00:00:00Z"},
+		}
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 1, false).
+			Return(revisions, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string][]*response.RevisionResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Equal(t, revisions, body["revisions"])
+	})
+
+	t.Run("error - post not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 2, false).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/2/revisions", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("get single revision", func(t *testing.T) {
+		revision := &response.RevisionResponse{Revision: 1, Title: "Original", Content: "Body", CreatedAt: "2025-01-01T00:00:00Z"}
+		mockRepo.
+			EXPECT().
+			GetRevision(gomock.Any(), 1, 1, false).
+			Return(revision, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/1", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string]*response.RevisionResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Equal(t, revision, body["revision"])
+	})
+
+	t.Run("error - draft revisions hidden from anonymous callers", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, false).
+			Return(nil, app_err.ErrNotFound)
+		mockRepo.
+			EXPECT().
+			GetRevision(gomock.Any(), 3, 1, false).
+			Return(nil, app_err.ErrNotFound)
+
+		for _, target := range []string{"/api/posts/3/revisions", "/api/posts/3/revisions/1"} {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			resp := httptest.NewRecorder()
+
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusNotFound, resp.Code, target)
+		}
+	})
+
+	t.Run("draft revisions shown to users allowed to update the post", func(t *testing.T) {
+		authorID := 7
+		mockRepo.EXPECT().GetPostAuthorID(gomock.Any(), 3).Return(&authorID, nil)
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, true).
+			Return([]*response.RevisionResponse{{Revision: 1, Title: "Draft"}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		authorizeAs(req, authorID, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("error - draft revisions hidden from other authors", func(t *testing.T) {
+		authorID := 7
+		mockRepo.EXPECT().GetPostAuthorID(gomock.Any(), 3).Return(&authorID, nil)
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, false).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		authorizeAs(req, 8, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("error - invalid token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		req.Header.Set("Authorization", "Bearer garbage")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("error - invalid revision", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/abc", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid revision")
+	})
+}
+
+func TestDiffRevisionsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetRevision(gomock.Any(), 1, 1, false).
+			Return(&response.RevisionResponse{Revision: 1, Title: "Title", Content: "first\nsecond"}, nil)
+		mockRepo.
+			EXPECT().
+			GetRevision(gomock.Any(), 1, 2, false).
+			Return(&response.RevisionResponse{Revision: 2, Title: "Title", Content: "first\nchanged"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff?from=1&to=2", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string]*response.RevisionDiffResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, body["diff"].FromRevision)
+		assert.Equal(t, 2, body["diff"].ToRevision)
+		assert.Equal(t, []*response.DiffLineResponse{
+			{Op: "equal", Text: "first"},
+			{Op: "delete", Text: "second"},
+			{Op: "insert", Text: "changed"},
+		}, body["diff"].Content)
+	})
+
+	t.Run("error - missing revisions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff?from=1", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("error - revision not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetRevision(gomock.Any(), 1, 1, false).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff?from=1&to=9", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestRestoreRevisionRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			RestoreRevision(gomock.Any(), 1, 2).
+			Return(5, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/revisions/2/restore", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"post_id": 1, "revision": 5}`, resp.Body.String())
+	})
+
+	t.Run("error - revision not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			RestoreRevision(gomock.Any(), 1, 9).
+			Return(0, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts/1/revisions/9/restore", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestTagsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("get tags", func(t *testing.T) {
+		tags := []*response.TagResponse{
+			{ID: 1, Name: "go", PostCount: 3},
+			{ID: 2, Name: "postgres", PostCount: 0},
+		}
+		mockRepo.
+			EXPECT().
+			GetTags(gomock.Any()).
+			Return(tags, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tags", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var body map[string][]*response.TagResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &body)
+		assert.NoError(t, err)
+		assert.Equal(t, tags, body["tags"])
+	})
+
+	t.Run("create tag", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			CreateTag(gomock.Any(), "go").
+			Return(1, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/tags", bytes.NewBufferString(`{"name": " Go "}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.JSONEq(t, `{"tag_id": 1}`, resp.Body.String())
+	})
+
+	t.Run("create tag - already exists", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			CreateTag(gomock.Any(), "go").
+			Return(0, app_err.ErrConflict)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/tags", bytes.NewBufferString(`{"name": "go"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("create tag - empty name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/tags", bytes.NewBufferString(`{"name": ""}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("rename tag", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			RenameTag(gomock.Any(), 1, "golang").
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/tags/1", bytes.NewBufferString(`{"name": "golang"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("rename tag - not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			RenameTag(gomock.Any(), 9, "golang").
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/tags/9", bytes.NewBufferString(`{"name": "golang"}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("merge tags", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			MergeTags(gomock.Any(), 2, 1).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/tags/2/merge", bytes.NewBufferString(`{"into_tag_id": 1}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"tag_id": 1}`, resp.Body.String())
+	})
+
+	t.Run("merge tags - into itself", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/tags/2/merge", bytes.NewBufferString(`{"into_tag_id": 2}`))
+		authorize(req)
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestGetPostBySlugRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("success", func(t *testing.T) {
+		post := &response.PostWithCommentsResponse{ID: 1, Title: "My First Post", Slug: "my-first-post"}
+		mockRepo.
+			EXPECT().
+			ResolveSlug(gomock.Any(), "my-first-post").
+			Return(1, "my-first-post", nil)
+		mockRepo.
+			EXPECT().
+			GetPostWithComments(gomock.Any(), 1, model.Page{Limit: request.EmbeddedCommentsLimit}, model.CommentViewFlat).
+			Return(post, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/by-slug/my-first-post", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"slug":"my-first-post"`)
+	})
+
+	t.Run("old slug redirects", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			ResolveSlug(gomock.Any(), "my-old-title").
+			Return(1, "my-first-post", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/by-slug/my-old-title?view=tree", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, resp.Code)
+		assert.Equal(t, "/api/posts/by-slug/my-first-post?view=tree", resp.Header().Get("Location"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			ResolveSlug(gomock.Any(), "missing").
+			Return(0, "", app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/by-slug/missing", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("numeric ids still reach the post", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetPostWithComments(gomock.Any(), 7, gomock.Any(), gomock.Any()).
+			Return(&response.PostWithCommentsResponse{ID: 7}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
+
+func TestAuthRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mockUsers, mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("register", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			CreateUser(gomock.Any(), gomock.Cond(func(user *model.User) bool {
+				return user.Username == "ada" && user.DisplayName == "Ada" && auth.CheckPassword(user.PasswordHash, "long enough")
+			})).
+			Return(&response.UserResponse{ID: 1, Username: "ada", DisplayName: "Ada"}, nil)
+
+		body := `{"username": "ada", "password": "long enough", "display_name": "Ada"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+		assert.NotContains(t, resp.Body.String(), "password")
+	})
+
+	t.Run("register - username taken", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			CreateUser(gomock.Any(), gomock.Any()).
+			Return(nil, app_err.ErrConflict)
+
+		body := `{"username": "ada", "password": "long enough"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusConflict, resp.Code)
+	})
+
+	t.Run("register - short password", func(t *testing.T) {
+		body := `{"username": "ada", "password": "short"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	hash, err := auth.HashPassword("long enough")
+	assert.NoError(t, err)
+	user := &model.User{ID: 7, Username: "ada", DisplayName: "Ada", PasswordHash: hash}
+
+	t.Run("login", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			GetUserByUsername(gomock.Any(), "ada").
+			Return(user, nil)
+
+		body := `{"username": "ada", "password": "long enough"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var data map[string]response.TokenResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Bearer", data["token"].TokenType)
+
+		principal, err := testTokens.Parse(data["token"].Token)
+		assert.NoError(t, err)
+		assert.Equal(t, 7, principal.UserID)
+	})
+
+	t.Run("login - wrong password", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			GetUserByUsername(gomock.Any(), "ada").
+			Return(user, nil)
+
+		body := `{"username": "ada", "password": "not the one"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid username or password")
+	})
+
+	t.Run("login - unknown user", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			GetUserByUsername(gomock.Any(), "nobody").
+			Return(nil, app_err.ErrNotFound)
+
+		body := `{"username": "nobody", "password": "long enough"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid username or password")
+	})
+
+	t.Run("write route without token", func(t *testing.T) {
+		body := `{"title": "My Title", "post_content": "My Content"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("write route with invalid token", func(t *testing.T) {
+		other, _, err := auth.NewTokenManager("other-secret", time.Hour).Issue(testUserID, "tester", model.RoleAdmin)
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
+		req.Header.Set("Authorization", "Bearer "+other)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Contains(t, resp.Body.String(), "invalid or expired token")
+	})
+}
+
+func TestAuthenticate_ReadsTheUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	users := mocks.NewMockUserRepository(ctrl)
+	keys := mocks.NewMockAPIKeyRepository(ctrl)
+	r := SetupRouter(handler.NewBlogHandler(mockRepo), handler.NewAuthHandler(users, testTokens), handler.NewUserHandler(users),
+		handler.NewAPIKeyHandler(keys), handler.NewHealthHandler(health.NewChecker(nil, "memory")), testTokens, users, keys)
+
+	newPost := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(`{"title": "My Title", "post_content": "My Content"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	token, _, err := testTokens.Issue(testUserID, "tester", model.RoleAdmin)
+	assert.NoError(t, err)
+
+	t.Run("demoted user is held to their current role", func(t *testing.T) {
+		users.EXPECT().GetUser(gomock.Any(), testUserID).
+			Return(&model.User{ID: testUserID, Username: "tester", Role: model.RoleCommenter}, nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, newPost(token))
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("deleted user is no longer authenticated", func(t *testing.T) {
+		users.EXPECT().GetUser(gomock.Any(), testUserID).Return(nil, app_err.ErrNotFound)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, newPost(token))
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+		assert.Equal(t, "invalid_token", problemOf(t, resp).Code)
+	})
+
+	t.Run("user cannot be read", func(t *testing.T) {
+		users.EXPECT().GetUser(gomock.Any(), testUserID).Return(nil, app_err.ErrInternalServer)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, newPost(token))
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestAuthorizationPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	otherUserID := testUserID + 1
+
+	t.Run("commenter cannot create posts", func(t *testing.T) {
+		body := `{"title": "My Title", "post_content": "My Content"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleCommenter)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("author updates own post", func(t *testing.T) {
+		ownerID := testUserID
+		mockRepo.
+			EXPECT().
+			GetPostAuthorID(gomock.Any(), 1).
+			Return(&ownerID, nil)
+		mockRepo.
+			EXPECT().
+			UpdatePost(gomock.Any(), 1, gomock.Any()).
+			Return(nil)
+
+		body := `{"title": "New Title"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("author cannot update someone else's post", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetPostAuthorID(gomock.Any(), 1).
+			Return(&otherUserID, nil)
+
+		body := `{"title": "New Title"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("author cannot delete a post without an author", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetPostAuthorID(gomock.Any(), 1).
+			Return(nil, nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
+		authorizeAs(req, testUserID, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("editor deletes any post", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			DeletePost(gomock.Any(), 1).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
+		authorizeAs(req, testUserID, model.RoleEditor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+
+	t.Run("editor cannot edit someone else's comment", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetComment(gomock.Any(), 1, 2).
+			Return(&response.CommentResponse{ID: 2, Author: &response.AuthorResponse{ID: otherUserID}}, nil)
+
+		body := `{"comment_content": "Edited"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/posts/1/comments/2", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleEditor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("commenter deletes own comment", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetComment(gomock.Any(), 1, 2).
+			Return(&response.CommentResponse{ID: 2, Author: &response.AuthorResponse{ID: testUserID}}, nil)
+		mockRepo.
+			EXPECT().
+			DeleteComment(gomock.Any(), 1, 2).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/2", nil)
+		authorizeAs(req, testUserID, model.RoleCommenter)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+
+	t.Run("author cannot manage tags", func(t *testing.T) {
+		body := `{"name": "go"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/tags", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleAuthor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
+
+func TestAdminUsersRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsers := mocks.NewMockUserRepository(ctrl)
+	h := handler.NewBlogHandler(mocks.NewMockBlogRepository(ctrl))
+	r := setupTestRouter(h, mockUsers, mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("list users", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			GetUsers(gomock.Any()).
+			Return([]*response.UserResponse{{ID: 1, Username: "ada", Role: "admin"}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"role":"admin"`)
+	})
+
+	t.Run("editor cannot list users", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		authorizeAs(req, testUserID, model.RoleEditor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("update role", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			UpdateUserRole(gomock.Any(), 7, model.RoleEditor).
+			Return(nil)
+
+		body := `{"role": "editor"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/users/7/role", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("update role - unknown role", func(t *testing.T) {
+		body := `{"role": "owner"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/users/7/role", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("update own role", func(t *testing.T) {
+		body := `{"role": "commenter"}`
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/users/42/role", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			DeleteUser(gomock.Any(), 7).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/users/7", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+
+	t.Run("delete unknown user", func(t *testing.T) {
+		mockUsers.
+			EXPECT().
+			DeleteUser(gomock.Any(), 99).
+			Return(app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/users/99", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+	h := handler.NewBlogHandler(mocks.NewMockBlogRepository(ctrl))
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mockKeys)
+
+	t.Run("create key for the admin", func(t *testing.T) {
+		var storedHash string
+		mockKeys.
+			EXPECT().
+			CreateAPIKey(gomock.Any(), gomock.Cond(func(key *model.APIKey) bool {
+				storedHash = key.KeyHash
+				return key.UserID == testUserID && key.Name == "ci" &&
+					len(key.Scopes) == 1 && key.Scopes[0] == model.ScopePostsWrite
+			})).
+			Return(&response.APIKeyResponse{ID: 1, UserID: testUserID, Name: "ci", Scopes: []string{"posts:write"}}, nil)
+
+		body := `{"name": "ci", "scopes": ["posts:write", "posts:write"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+
+		var data map[string]response.CreatedAPIKeyResponse
+		err := json.Unmarshal(resp.Body.Bytes(), &data)
+		assert.NoError(t, err)
+		assert.True(t, auth.IsAPIKey(data["api_key"].Key))
+		assert.Equal(t, storedHash, auth.HashAPIKey(data["api_key"].Key), "only the hash of the returned key is stored")
+		assert.Equal(t, 1, data["api_key"].ID)
+	})
+
+	t.Run("create key for another user", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			CreateAPIKey(gomock.Any(), gomock.Cond(func(key *model.APIKey) bool {
+				return key.UserID == 7
+			})).
+			Return(nil, app_err.ErrNotFound)
+
+		body := `{"name": "import bot", "scopes": ["posts:write"], "user_id": 7}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("create key - unknown scope", func(t *testing.T) {
+		body := `{"name": "ci", "scopes": ["posts:admin"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("editor cannot create keys", func(t *testing.T) {
+		body := `{"name": "ci", "scopes": ["posts:write"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		authorizeAs(req, testUserID, model.RoleEditor)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("list keys", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			GetAPIKeys(gomock.Any()).
+			Return([]*response.APIKeyResponse{{ID: 1, Name: "ci", Prefix: "pbk_abcdefgh", Scopes: []string{"posts:write"}}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/api-keys", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"prefix":"pbk_abcdefgh"`)
+		assert.NotContains(t, resp.Body.String(), `"key"`)
+	})
+
+	t.Run("revoke key", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			RevokeAPIKey(gomock.Any(), 1).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/api-keys/1", nil)
+		authorize(req)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	})
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	mockKeys := mocks.NewMockAPIKeyRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mockKeys)
+
+	key, _, hash, err := auth.GenerateAPIKey()
+	assert.NoError(t, err)
+
+	botID := 9
+	apiKey := func(scopes ...model.Scope) *model.APIKey {
+		return &model.APIKey{
+			ID:     1,
+			UserID: botID,
+			Scopes: scopes,
+			User:   &model.User{ID: botID, Username: "import-bot", Role: model.RoleAuthor},
+		}
+	}
+	body := `{"title": "Imported", "post_content": "From the importer"}`
+
+	t.Run("X-API-Key header", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(apiKey(model.ScopePostsWrite), nil)
+		mockRepo.
+			EXPECT().
+			CreatePost(gomock.Any(), gomock.Cond(func(post *model.Post) bool {
+				return post.AuthorID != nil && *post.AuthorID == botID
+			})).
+			Return(5, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+	})
+
+	t.Run("bearer api key", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(apiKey(model.ScopePostsWrite), nil)
+		mockRepo.
+			EXPECT().
+			CreatePost(gomock.Any(), gomock.Any()).
+			Return(6, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusCreated, resp.Code)
+	})
+
+	t.Run("missing scope", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(apiKey(model.ScopeRead), nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		assert.Contains(t, resp.Body.String(), "posts:write")
+	})
+
+	t.Run("read key sees the revisions of its user's draft", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(apiKey(model.ScopeRead), nil)
+		mockRepo.
+			EXPECT().
+			GetPostAuthorID(gomock.Any(), 3).
+			Return(&botID, nil)
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, true).
+			Return([]*response.RevisionResponse{{Revision: 1, Title: "Draft"}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("anonymous caller does not see the revisions of the draft", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, false).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("key without the read scope does not see the revisions of the draft", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(apiKey(model.ScopePostsWrite), nil)
+		mockRepo.
+			EXPECT().
+			GetRevisions(gomock.Any(), 3, false).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/posts/3/revisions", nil)
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("revoked or expired key", func(t *testing.T) {
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(nil, app_err.ErrNotFound)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("keys cannot manage api keys", func(t *testing.T) {
+		admin := apiKey(model.ScopePostsWrite, model.ScopeCommentsModerate, model.ScopeRead)
+		admin.User.Role = model.RoleAdmin
+		mockKeys.
+			EXPECT().
+			AuthenticateAPIKey(gomock.Any(), hash, gomock.Any()).
+			Return(admin, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/api-keys", nil)
+		req.Header.Set("X-API-Key", key)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
+
+func TestSearchPostsRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	h := handler.NewBlogHandler(mockRepo)
+	r := setupTestRouter(h, mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	t.Run("first page", func(t *testing.T) {
+		nextCursor := (&model.SearchCursor{Rank: 0.5, ID: 3}).Encode()
+		mockRepo.
+			EXPECT().
+			SearchPosts(gomock.Any(), "go generics", model.SearchPage{Limit: 2}).
+			Return([]*response.SearchResultResponse{
+				{PostID: 4, Title: "Go generics", Snippet: "<mark>Go</mark> <mark>generics</mark> in practice", Rank: 0.9},
+				{PostID: 3, Title: "Type parameters", Snippet: "Since <mark>Go</mark> 1.18", Rank: 0.5, MatchingComments: 1},
+			}, nextCursor, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=go+generics&limit=2", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		var data struct {
+			Results    []response.SearchResultResponse `json:"results"`
+			NextCursor *string                         `json:"next_cursor"`
+		}
+		err := json.Unmarshal(resp.Body.Bytes(), &data)
+		assert.NoError(t, err)
+		assert.Len(t, data.Results, 2)
+		assert.Equal(t, 4, data.Results[0].PostID)
+		assert.Equal(t, nextCursor, *data.NextCursor)
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		cursor := &model.SearchCursor{Rank: 0.5, ID: 3}
+		mockRepo.
+			EXPECT().
+			SearchPosts(gomock.Any(), "go", model.SearchPage{Cursor: cursor, Limit: request.DefaultPageLimit}).
+			Return([]*response.SearchResultResponse{}, "", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=go&cursor="+cursor.Encode(), nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"next_cursor":null`)
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("repository failure", func(t *testing.T) {
+		mockRepo.
+			EXPECT().
+			SearchPosts(gomock.Any(), "go", gomock.Any()).
+			Return(nil, "", app_err.ErrInternalServer)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/search?q=go", nil)
+		resp := httptest.NewRecorder()
+
+		r.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
+
+func TestSetupRouter_Probes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	checker := health.NewChecker(nil, "memory")
+	keys := mocks.NewMockAPIKeyRepository(ctrl)
+	users := mocks.NewMockUserRepository(ctrl)
+	router := SetupRouter(handler.NewBlogHandler(mocks.NewMockBlogRepository(ctrl)), handler.NewAuthHandler(users, testTokens),
+		handler.NewUserHandler(users), handler.NewAPIKeyHandler(keys), handler.NewHealthHandler(checker), testTokens, users, keys)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"up"}`, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get(requestIDHeader), "every response should carry its request id")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var ready response.ReadinessResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ready))
+	assert.Equal(t, health.StatusUp, ready.Status)
+	assert.Equal(t, health.StatusUp, ready.Checks["database"].Status)
+
+	checker.ShuttingDown()
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var notReady response.ReadinessResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &notReady))
+	assert.Equal(t, health.StatusDown, notReady.Status)
+	assert.Equal(t, health.StatusDown, notReady.Checks["shutdown"].Status)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "liveness should not depend on readiness")
+}
+
+func TestSetupUnavailableRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := SetupUnavailableRouter(handler.NewHealthHandler(nil), 10*time.Second)
+
+	for _, target := range []string{"/api/posts", "/readyz"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, target)
+		assert.Equal(t, "10", w.Header().Get("Retry-After"), target)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"), target)
+		assert.JSONEq(t, `{"type":"urn:prosig-blog:problem:service_unavailable","title":"Service unavailable","status":503,
+			"detail":"service unavailable","instance":"`+target+`","code":"service_unavailable"}`, w.Body.String(), target)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBufferString(`{}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "the process should still be alive")
+}
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+	r := gin.New()
+	r.Use(requestLogger(zap.New(core)))
+	r.GET("/posts/:id", func(ctx *gin.Context) {
+		log.FromContext(ctx.Request.Context()).Info("[HandlerTest] handled")
+		ctx.Status(http.StatusOK)
+	})
+
+	t.Run("propagates the caller's id", func(t *testing.T) {
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+		req = req.WithContext(trace.ContextWithSpanContext(req.Context(), spanCtx))
+		req.Header.Set(requestIDHeader, "req-123")
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, "req-123", w.Header().Get(requestIDHeader))
+		entries := logs.TakeAll()
+		assert.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "req-123", fields["request_id"])
+		assert.Equal(t, "/posts/:id", fields["route"])
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace_id"])
+		assert.Equal(t, "00f067aa0ba902b7", fields["span_id"])
+	})
+
+	t.Run("assigns an id", func(t *testing.T) {
+		for _, header := range []string{"", "forged\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+			req.Header.Set(requestIDHeader, header)
+			r.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(requestIDHeader)
+			assert.NotEqual(t, header, requestID)
+			assert.NoError(t, uuid.Validate(requestID))
+			entries := logs.TakeAll()
+			assert.Len(t, entries, 1)
+			assert.Equal(t, requestID, entries[0].ContextMap()["request_id"])
+			assert.NotContains(t, entries[0].ContextMap(), "trace_id", "there is no trace to refer to")
+		}
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+	newRouter := func(cfg config.AccessLogConfig) *gin.Engine {
+		r := gin.New()
+		r.Use(requestLogger(zap.New(core)), accessLog(cfg), problemDetails(), recovery())
+		r.GET("/healthz", func(ctx *gin.Context) {
+			ctx.Status(http.StatusOK)
+		})
+		r.GET("/posts/:id", func(ctx *gin.Context) {
+			ctx.String(http.StatusOK, "hello")
+		})
+		r.GET("/boom", func(ctx *gin.Context) {
+			panic("boom")
+		})
+		return r
+	}
+	serve := func(r *gin.Engine, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("User-Agent", "access-log-test")
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("logs the request", func(t *testing.T) {
+		r := newRouter(config.AccessLogConfig{SkipPaths: []string{"/healthz"}})
+
+		serve(r, "/posts/7")
+		entries := logs.TakeAll()
+		assert.Len(t, entries, 1)
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, http.MethodGet, fields["method"])
+		assert.Equal(t, "/posts/:id", fields["route"])
+		assert.NotContains(t, fields, "path")
+		assert.Equal(t, int64(http.StatusOK), fields["http_status"])
+		assert.Equal(t, int64(len("hello")), fields["bytes"])
+		assert.Equal(t, "192.0.2.1", fields["client_ip"])
+		assert.Equal(t, "access-log-test", fields["user_agent"])
+		assert.Contains(t, fields, "latency")
+		assert.Contains(t, fields, "request_id")
+
+		serve(r, "/healthz")
+		assert.Empty(t, logs.TakeAll(), "skipped paths should not be logged")
+	})
+
+	t.Run("skips routes", func(t *testing.T) {
+		r := newRouter(config.AccessLogConfig{SkipPaths: []string{"/posts/:id"}})
+
+		serve(r, "/posts/7")
+		assert.Empty(t, logs.TakeAll(), "skipped routes should not be logged")
+	})
+
+	t.Run("logs panics as failures", func(t *testing.T) {
+		r := newRouter(config.AccessLogConfig{})
+
+		w := serve(r, "/boom")
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, "internal_error", problemOf(t, w).Code)
+		entries := logs.TakeAll()
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "[MiddlewareRecovery] recovered from a panic", entries[0].Message)
+		assert.Equal(t, zapcore.ErrorLevel, entries[1].Level)
+		assert.Equal(t, int64(http.StatusInternalServerError), entries[1].ContextMap()["http_status"])
+	})
+
+	t.Run("samples successful requests", func(t *testing.T) {
+		r := newRouter(config.AccessLogConfig{SuccessSampleRatio: 1e-12})
+
+		for range 50 {
+			serve(r, "/posts/7")
+		}
+		assert.Empty(t, logs.TakeAll(), "successful requests should be sampled")
+
+		serve(r, "/nowhere")
+		entries := logs.TakeAll()
+		assert.Len(t, entries, 1, "failed requests should always be logged")
+		assert.Equal(t, int64(http.StatusNotFound), entries[0].ContextMap()["http_status"])
+		assert.Equal(t, "/nowhere", entries[0].ContextMap()["route"], "unmatched requests should log their path")
+	})
+}
+
+func TestProblemDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	r := setupTestRouter(handler.NewBlogHandler(mockRepo), mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		body      string
+		anonymous bool
+		setup     func()
+		status    int
+		code      string
+		detail    string
+	}{
+		{name: "malformed json", method: http.MethodPost, target: "/api/posts", body: `{"title":`,
+			status: http.StatusBadRequest, code: "malformed_json", detail: "malformed json"},
+		{name: "invalid input", method: http.MethodPost, target: "/api/posts", body: `{"title":"","post_content":"Content"}`,
+			status: http.StatusBadRequest, code: app_err.CodeInvalidInput, detail: "post title cannot be empty"},
+		{name: "invalid path parameter", method: http.MethodGet, target: "/api/posts/abc",
+			status: http.StatusBadRequest, code: "invalid_post_id", detail: "invalid post id"},
+		{name: "anonymous write", method: http.MethodDelete, target: "/api/posts/1", anonymous: true,
+			status: http.StatusUnauthorized, code: "authentication_required", detail: "authentication required"},
+		{name: "not found", method: http.MethodGet, target: "/api/posts/1/revisions/2",
+			setup: func() {
+				mockRepo.EXPECT().GetRevision(gomock.Any(), 1, 2, true).Return(nil, app_err.ErrNotFound)
+			},
+			status: http.StatusNotFound, code: app_err.CodeNotFound, detail: "resource not found"},
+		{name: "conflict", method: http.MethodPost, target: "/api/tags", body: `{"name":"go"}`,
+			setup: func() {
+				mockRepo.EXPECT().CreateTag(gomock.Any(), "go").Return(0, errors.Join(app_err.ErrConflict, errors.New("tag already exists")))
+			},
+			status: http.StatusConflict, code: app_err.CodeConflict, detail: "tag already exists"},
+		{name: "internal error", method: http.MethodGet, target: "/api/tags",
+			setup: func() {
+				mockRepo.EXPECT().GetTags(gomock.Any()).Return(nil, errors.New("pq: connection refused"))
+			},
+			status: http.StatusInternalServerError, code: app_err.CodeInternal, detail: "internal server error"},
+		{name: "unknown route", method: http.MethodGet, target: "/api/nowhere",
+			status: http.StatusNotFound, code: "route_not_found", detail: "no route matches the request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if !tt.anonymous {
+				authorize(req)
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			problem := problemOf(t, w)
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, "urn:prosig-blog:problem:"+tt.code, problem.Type)
+			assert.Equal(t, tt.detail, problem.Detail)
+			assert.Equal(t, tt.target, problem.Instance)
+			assert.NotEmpty(t, problem.Title)
+		})
+	}
+}
+
+func TestProblemDetails_Violations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the repository is not expected to be called, an overlong title never reaches the database
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	r := setupTestRouter(handler.NewBlogHandler(mockRepo), mocks.NewMockUserRepository(ctrl), mocks.NewMockAPIKeyRepository(ctrl))
+
+	body, _ := json.Marshal(request.CreateBlogPostRequest{
+		Title:   strings.Repeat("t", request.MaxPostTitleLength+1),
+		Content: "   ",
+		Tags:    []string{"go", ""},
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	authorize(req)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := problemOf(t, w)
+	assert.Equal(t, app_err.CodeInvalidInput, problem.Code)
+	assert.Equal(t, []*response.ViolationResponse{
+		{Field: "title", Code: request.CodeTooLong, Message: "post title cannot be longer than 255 characters"},
+		{Field: "post_content", Code: request.CodeBlank, Message: "post content cannot be blank"},
+		{Field: "tags[1]", Code: request.CodeRequired, Message: "tag name cannot be empty"},
+	}, problem.Violations)
 }
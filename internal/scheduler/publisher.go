@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"go.uber.org/zap"
+)
+
+// DefaultPublishInterval is used when no valid interval is configured
+const DefaultPublishInterval = 30 * time.Second
+
+// Publisher periodically publishes the scheduled posts whose publish time has come.
+// Every replica can run its own publisher, the repository flips each post exactly once
+type Publisher struct {
+	repo     repository.BlogRepository
+	interval time.Duration
+	now      func() time.Time
+}
+
+func NewPublisher(repo repository.BlogRepository, interval time.Duration) *Publisher {
+	if interval <= 0 {
+		interval = DefaultPublishInterval
+	}
+
+	return &Publisher{
+		repo:     repo,
+		interval: interval,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Run publishes due posts right away and then on every tick, until the context is cancelled
+func (p *Publisher) Run(ctx context.Context) {
+	logger := log.GetLogger().With(zap.Duration("publish_interval", p.interval))
+	logger.Info("[SchedulerPublisher] starting scheduled posts publisher")
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.publishDuePosts(ctx, logger)
+
+		select {
+		case <-ctx.Done():
+			logger.Info("[SchedulerPublisher] stopping scheduled posts publisher")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) publishDuePosts(ctx context.Context, logger *zap.Logger) {
+	published, err := p.repo.PublishScheduledPosts(ctx, p.now())
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Error("[SchedulerPublisher] failed to publish scheduled posts", zap.Error(err))
+		}
+		return
+	}
+
+	if published > 0 {
+		logger.Info("[SchedulerPublisher] published scheduled posts", zap.Int("published_posts", published))
+	}
+}
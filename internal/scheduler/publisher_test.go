@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestPublisher_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2025, 10, 21, 12, 0, 0, 0, time.UTC)
+	mockRepo := mocks.NewMockBlogRepository(ctrl)
+	publisher := NewPublisher(mockRepo, time.Millisecond)
+	publisher.now = func() time.Time { return now }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	mockRepo.
+		EXPECT().
+		PublishScheduledPosts(gomock.Any(), now).
+		DoAndReturn(func(context.Context, time.Time) (int, error) {
+			calls++
+			switch calls {
+			case 1:
+				return 2, nil
+			case 2:
+				return 0, errors.New("db error")
+			default:
+				cancel()
+				return 0, nil
+			}
+		}).
+		MinTimes(3)
+
+	done := make(chan struct{})
+	go func() {
+		publisher.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher did not stop after the context was cancelled")
+	}
+
+	assert.GreaterOrEqual(t, calls, 3)
+}
@@ -0,0 +1,115 @@
+// Package server runs the HTTP API with timeouts and shuts it down gracefully
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"go.uber.org/zap"
+)
+
+// The defaults are used for the timeouts that are not configured with a positive duration
+const (
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultReadTimeout       = 15 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 2 * time.Minute
+	DefaultShutdownTimeout   = 20 * time.Second
+)
+
+type Server struct {
+	http            *http.Server
+	shutdownTimeout time.Duration
+}
+
+func New(cfg config.AppConfig, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              fmt.Sprintf(":%d", cfg.Port),
+			Handler:           handler,
+			ReadHeaderTimeout: orDefault(cfg.ReadHeaderTimeout, DefaultReadHeaderTimeout),
+			ReadTimeout:       orDefault(cfg.ReadTimeout, DefaultReadTimeout),
+			WriteTimeout:      orDefault(cfg.WriteTimeout, DefaultWriteTimeout),
+			IdleTimeout:       orDefault(cfg.IdleTimeout, DefaultIdleTimeout),
+		},
+		shutdownTimeout: orDefault(cfg.ShutdownTimeout, DefaultShutdownTimeout),
+	}
+}
+
+// Run serves until the context is cancelled, then stops accepting connections and waits for the requests
+// in flight to finish, giving up once the shutdown timeout has passed
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.http.Addr, err)
+	}
+	return s.serve(ctx, listener)
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	logger := log.GetLogger().With(zap.String("addr", listener.Addr().String()))
+	logger.Info("[Server] serving http requests")
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.http.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	logger.Info("[Server] draining connections", zap.Duration("shutdown_timeout", s.shutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		// Whatever is still running is cut off
+		s.http.Close()
+		return fmt.Errorf("failed to drain connections: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+
+	logger.Info("[Server] stopped serving http requests")
+	return nil
+}
+
+func orDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// SwappableHandler serves with one handler until another replaces it, so the service can listen before
+// everything it serves is ready
+type SwappableHandler struct {
+	current atomic.Pointer[http.Handler]
+}
+
+func NewSwappableHandler(initial http.Handler) *SwappableHandler {
+	h := &SwappableHandler{}
+	h.Swap(initial)
+	return h
+}
+
+// Swap serves the following requests with next, the requests in flight finish with the handler they started on
+func (h *SwappableHandler) Swap(next http.Handler) {
+	h.current.Store(&next)
+}
+
+func (h *SwappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.current.Load()).ServeHTTP(w, r)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestNew_Timeouts(t *testing.T) {
+	server := New(config.AppConfig{Port: 8080, ReadTimeout: 3 * time.Second, ShutdownTimeout: time.Second}, http.NotFoundHandler())
+
+	assert.Equal(t, ":8080", server.http.Addr)
+	assert.Equal(t, 3*time.Second, server.http.ReadTimeout)
+	assert.Equal(t, DefaultReadHeaderTimeout, server.http.ReadHeaderTimeout)
+	assert.Equal(t, DefaultWriteTimeout, server.http.WriteTimeout)
+	assert.Equal(t, DefaultIdleTimeout, server.http.IdleTimeout)
+	assert.Equal(t, time.Second, server.shutdownTimeout)
+}
+
+// slowServer serves a handler that takes delay to answer, and tells when a request has started
+func slowServer(t *testing.T, delay, shutdownTimeout time.Duration) (*Server, net.Listener, chan struct{}) {
+	started := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	return New(config.AppConfig{ShutdownTimeout: shutdownTimeout}, handler), listener, started
+}
+
+func TestServer_DrainsRequestsOnShutdown(t *testing.T) {
+	server, listener, started := slowServer(t, 200*time.Millisecond, 5*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.serve(ctx, listener)
+	}()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, http.StatusOK, <-status, "the request in flight should be answered")
+	assert.NoError(t, <-served)
+
+	_, err := http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err, "new connections should be refused")
+}
+
+func TestServer_ShutdownDeadline(t *testing.T) {
+	server, listener, started := slowServer(t, 2*time.Second, 50*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.serve(ctx, listener)
+	}()
+
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	start := time.Now()
+	err := <-served
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "error should wrap context.DeadlineExceeded")
+	assert.Less(t, time.Since(start), time.Second, "shutdown should give up at its deadline")
+}
+
+func TestSwappableHandler(t *testing.T) {
+	handler := NewSwappableHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	handler.Swap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
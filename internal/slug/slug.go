@@ -0,0 +1,82 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// MaxLength keeps slugs readable, longer titles are cut at a word boundary
+	MaxLength = 80
+
+	// Fallback is used for titles without any letter or digit that can be transliterated
+	Fallback = "post"
+)
+
+// Letters that do not decompose into an ASCII base letter and a combining mark
+var transliterations = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'Æ': "ae",
+	'œ': "oe",
+	'Œ': "oe",
+	'ø': "o",
+	'Ø': "o",
+	'đ': "d",
+	'Đ': "d",
+	'ð': "d",
+	'Ð': "d",
+	'þ': "th",
+	'Þ': "th",
+	'ł': "l",
+	'Ł': "l",
+	'ı': "i",
+	'&': "and",
+}
+
+// Make turns a title into a lowercase ASCII slug, words being joined by dashes
+func Make(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(title) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		if t, ok := transliterations[r]; ok {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteString(t)
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+
+		dash = true
+	}
+
+	slug := b.String()
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+		if idx := strings.LastIndexByte(slug, '-'); idx > 0 {
+			slug = slug[:idx]
+		}
+	}
+
+	if slug == "" {
+		return Fallback
+	}
+	return slug
+}
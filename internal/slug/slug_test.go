@@ -0,0 +1,39 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "plain title", title: "My First Post", want: "my-first-post"},
+		{name: "punctuation and spaces", title: "  Hello,   World!  ", want: "hello-world"},
+		{name: "accents", title: "Café à la crème", want: "cafe-a-la-creme"},
+		{name: "special letters", title: "Straße Ørsted Łódź", want: "strasse-orsted-lodz"},
+		{name: "ampersand", title: "Go & Postgres", want: "go-and-postgres"},
+		{name: "digits", title: "Top 10 tips for 2025", want: "top-10-tips-for-2025"},
+		{name: "compatibility characters", title: "ﬁnal ½", want: "final-1-2"},
+		{name: "nothing to keep", title: "日本語 !!", want: Fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Make(tt.title))
+		})
+	}
+}
+
+func TestMake_Length(t *testing.T) {
+	slug := Make(strings.Repeat("word ", 40))
+
+	assert.LessOrEqual(t, len(slug), MaxLength)
+	assert.False(t, strings.HasSuffix(slug, "-"))
+	assert.True(t, strings.HasPrefix(slug, "word-word"))
+}
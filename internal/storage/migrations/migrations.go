@@ -0,0 +1,340 @@
+// Package migrations holds the versioned schema of the database, embedded in the binary, and applies it.
+// Each driver has its own directory of versions, each a pair of NNNN_name.up.sql and NNNN_name.down.sql files,
+// applied in version order
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"go.uber.org/zap"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock held while migrating, so replicas starting together apply each version once
+const lockKey = 4_717_328_051
+
+const (
+	queryCreateMigrationsTable = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`
+
+	queryAppliedMigrations = `
+		SELECT version, applied_at
+		FROM schema_migrations
+	`
+
+	queryAddMigration = `
+		INSERT INTO schema_migrations (version, name)
+		VALUES ($1, $2)
+	`
+
+	queryRemoveMigration = `
+		DELETE FROM schema_migrations
+		WHERE version = $1
+	`
+
+	queryLock   = `SELECT pg_advisory_lock($1)`
+	queryUnlock = `SELECT pg_advisory_unlock($1)`
+)
+
+// lockingDrivers are the drivers whose databases are shared by replicas and so are migrated under the advisory lock,
+// a SQLite database belongs to a single process
+var lockingDrivers = map[string]bool{"postgres": true}
+
+var filePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status tells whether a migration has been applied, and when
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load returns the embedded migrations of the driver in the order they are applied
+func Load(driver string) ([]Migration, error) {
+	fsys, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations of driver %q: %w", driver, err)
+	}
+	return load(fsys)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := filePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named both %q and %q", version, migration.Name, match[2])
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", entry.Name(), err)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns how many were applied
+func Up(ctx context.Context, db *sql.DB, driver string) (int, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	err = withLock(ctx, db, driver, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			if err := apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the given number of most recently applied migrations and returns how many were reverted
+func Down(ctx context.Context, db *sql.DB, driver string, steps int) (int, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	err = withLock(ctx, db, driver, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for idx := len(migrations) - 1; idx >= 0 && reverted < steps; idx-- {
+			migration := migrations[idx]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			if err := apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// GetStatus lists every known migration along with when it was applied, nil when it is pending
+func GetStatus(ctx context.Context, db *sql.DB, driver string) ([]Status, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	err = withLock(ctx, db, driver, func(conn *sql.Conn) error {
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		statuses = make([]Status, len(migrations))
+		for idx, migration := range migrations {
+			statuses[idx] = Status{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				statuses[idx].AppliedAt = &appliedAt
+			}
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Pending counts the migrations not applied yet. It only reads, without the lock, so it can be polled
+// while another replica is migrating
+func Pending(ctx context.Context, db *sql.DB, driver string) (int, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	done, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, migration := range migrations {
+		if _, ok := done[migration.Version]; !ok {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// withLock runs fn on a single connection holding the migrations advisory lock, which is tied to the session,
+// for the drivers that have one
+func withLock(ctx context.Context, db *sql.DB, driver string, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	if lockingDrivers[driver] {
+		if _, err := conn.ExecContext(ctx, queryLock, lockKey); err != nil {
+			return fmt.Errorf("failed to acquire the migrations lock: %w", err)
+		}
+		defer func() {
+			// The lock is released with the session anyway, so a failed unlock is only logged
+			if _, err := conn.ExecContext(context.Background(), queryUnlock, lockKey); err != nil {
+				log.GetLogger().Error("[Migrations] failed to release the migrations lock", zap.Error(err))
+			}
+		}()
+	}
+
+	if _, err := conn.ExecContext(ctx, queryCreateMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create the migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, queryAppliedMigrations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// apply runs the up, or down, script of the migration and records it in the same transaction,
+// so a failing script leaves neither the schema nor the migrations table changed
+func apply(ctx context.Context, conn *sql.Conn, migration Migration, down bool) error {
+	logger := log.GetLogger().With(zap.Int("version", migration.Version), zap.String("name", migration.Name), zap.Bool("down", down))
+	script, record, args := migration.Up, queryAddMigration, []any{migration.Version, migration.Name}
+	if down {
+		script, record, args = migration.Down, queryRemoveMigration, []any{migration.Version}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		logger.Error("[Migrations] migration failed", zap.Error(err))
+		return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+
+	logger.Info("[Migrations] migration applied")
+	return nil
+}
@@ -0,0 +1,172 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	for _, driver := range []string{"postgres", "sqlite"} {
+		t.Run(driver, func(t *testing.T) {
+			migrations, err := Load(driver)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, migrations)
+
+			for idx, migration := range migrations {
+				assert.Equal(t, idx+1, migration.Version, "versions should start at 1 and have no gaps")
+				assert.NotEmpty(t, migration.Up)
+				assert.NotEmpty(t, migration.Down)
+			}
+		})
+	}
+}
+
+func TestLoad_UnknownDriver(t *testing.T) {
+	_, err := Load("mysql")
+	assert.Error(t, err)
+}
+
+func TestLoad_Order(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0010_later.up.sql":    {Data: []byte("SELECT 10")},
+		"0010_later.down.sql":  {Data: []byte("SELECT -10")},
+		"0002_second.up.sql":   {Data: []byte("SELECT 2")},
+		"0002_second.down.sql": {Data: []byte("SELECT -2")},
+		"0001_first.up.sql":    {Data: []byte("SELECT 1")},
+		"0001_first.down.sql":  {Data: []byte("SELECT -1")},
+	}
+
+	migrations, err := load(fsys)
+
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 3)
+	assert.Equal(t, []int{1, 2, 10}, []int{migrations[0].Version, migrations[1].Version, migrations[2].Version})
+	assert.Equal(t, "second", migrations[1].Name)
+	assert.Equal(t, "SELECT 2", migrations[1].Up)
+	assert.Equal(t, "SELECT -2", migrations[1].Down)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"missing down", fstest.MapFS{
+			"0001_first.up.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"unexpected name", fstest.MapFS{
+			"first.sql": {Data: []byte("SELECT 1")},
+		}},
+		{"mismatched names", fstest.MapFS{
+			"0001_first.up.sql":     {Data: []byte("SELECT 1")},
+			"0001_initial.down.sql": {Data: []byte("SELECT -1")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpDown_SQLite(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "blog.db")+"?_pragma=foreign_keys(1)")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := Load("sqlite")
+	assert.NoError(t, err)
+
+	applied, err := Up(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	pending, err := Pending(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, pending)
+
+	reverted, err := Down(ctx, db, "sqlite", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, reverted)
+
+	pending, err = Pending(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pending)
+
+	statuses, err := GetStatus(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	reverted, err = Down(ctx, db, "sqlite", len(migrations))
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, reverted)
+}
+
+// A database created from the initial schema before there were migrations is adopted, keeping its posts and comments
+func TestUp_SQLiteAdoptsInitialSchema(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "blog.db")+"?_pragma=foreign_keys(1)")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := Load("sqlite")
+	assert.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, migrations[0].Up)
+	assert.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO blog_posts (title, content) VALUES ('First', 'Hello'), ('Second', 'World')`)
+	assert.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO comments (blog_post_id, content) VALUES (1, 'Nice')`)
+	assert.NoError(t, err)
+
+	applied, err := Up(ctx, db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	var postSlug, status string
+	err = db.QueryRowContext(ctx, `SELECT slug, status FROM blog_posts WHERE id = 2`).Scan(&postSlug, &status)
+	assert.NoError(t, err)
+	assert.Equal(t, "post-2", postSlug)
+	assert.Equal(t, "published", status)
+
+	var slugs, revisions, comments int
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM post_slugs`).Scan(&slugs))
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM post_revisions WHERE revision = 1`).Scan(&revisions))
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE depth = 0`).Scan(&comments))
+	assert.Equal(t, 2, slugs)
+	assert.Equal(t, 2, revisions)
+	assert.Equal(t, 1, comments)
+
+	reverted, err := Down(ctx, db, "sqlite", len(migrations)-1)
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, reverted)
+
+	var posts int
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM blog_posts`).Scan(&posts))
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments`).Scan(&comments))
+	assert.Equal(t, 2, posts)
+	assert.Equal(t, 1, comments, "reverting to the initial schema should keep the comments")
+
+	_, err = db.ExecContext(ctx, `DELETE FROM blog_posts WHERE id = 1`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments`).Scan(&comments))
+	assert.Equal(t, 0, comments, "comments should still be deleted along with their post")
+}
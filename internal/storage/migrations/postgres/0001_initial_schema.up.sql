@@ -1,7 +1,6 @@
-DROP TABLE IF EXISTS comments;
-DROP TABLE IF EXISTS blog_posts;
-
-CREATE TABLE blog_posts (
+-- The schema the blog started with, the one databases created before migrations were introduced already have.
+-- It is only created when missing, so running the migrations adopts such a database as it is
+CREATE TABLE IF NOT EXISTS blog_posts (
     id SERIAL PRIMARY KEY,
     title VARCHAR(255) NOT NULL,
     content TEXT NOT NULL,
@@ -9,7 +8,7 @@ CREATE TABLE blog_posts (
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE TABLE comments (
+CREATE TABLE IF NOT EXISTS comments (
     id SERIAL PRIMARY KEY,
     blog_post_id INTEGER NOT NULL,
     content TEXT NOT NULL,
@@ -20,4 +19,4 @@ CREATE TABLE comments (
         ON DELETE CASCADE
 );
 
-CREATE INDEX idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC);
@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS post_slugs;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS post_revisions;
+
+DROP INDEX IF EXISTS idx_blog_posts_created_at_id;
+DROP INDEX IF EXISTS idx_comments_post_id_created_at;
+CREATE INDEX idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC);
+
+ALTER TABLE comments
+    DROP COLUMN IF EXISTS parent_comment_id,
+    DROP COLUMN IF EXISTS root_comment_id,
+    DROP COLUMN IF EXISTS depth,
+    DROP COLUMN IF EXISTS author_id,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE blog_posts
+    DROP COLUMN IF EXISTS slug,
+    DROP COLUMN IF EXISTS author_id,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS publish_at;
+
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS users;
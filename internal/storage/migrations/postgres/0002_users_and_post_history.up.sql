@@ -0,0 +1,99 @@
+-- Everything added to the blog since the initial schema. Each change is skipped when it is already there,
+-- so a database created from a later version of the old init script is adopted as well
+
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'commenter'
+        CHECK (role IN ('admin', 'editor', 'author', 'commenter')),
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keys for machine clients acting on behalf of a user, only the SHA-256 hash of each key is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Posts written before there were states stay published
+ALTER TABLE blog_posts
+    ADD COLUMN IF NOT EXISTS slug VARCHAR(100) NULL,
+    ADD COLUMN IF NOT EXISTS author_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'published'
+        CHECK (status IN ('draft', 'published', 'scheduled', 'archived')),
+    ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP NULL;
+
+ALTER TABLE comments
+    ADD COLUMN IF NOT EXISTS parent_comment_id INTEGER NULL REFERENCES comments(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS root_comment_id INTEGER NULL REFERENCES comments(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS author_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL;
+
+-- Append-only history of the title and content of every post
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id SERIAL PRIMARY KEY,
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    restored_from_revision INTEGER NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_post_revisions_post_revision UNIQUE (blog_post_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (blog_post_id, tag_id)
+);
+
+-- Every slug a post ever had, so old permalinks keep resolving to the post
+CREATE TABLE IF NOT EXISTS post_slugs (
+    slug VARCHAR(100) PRIMARY KEY,
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing posts get a slug made of their id, which no title slug takes from them as it is recorded in post_slugs,
+-- and their current title and content as the first revision
+UPDATE blog_posts SET slug = 'post-' || id WHERE slug IS NULL;
+ALTER TABLE blog_posts ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS blog_posts_slug_key ON blog_posts(slug);
+
+INSERT INTO post_slugs (slug, blog_post_id)
+SELECT slug, id FROM blog_posts
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO post_revisions (blog_post_id, revision, title, content, created_at)
+SELECT b.id, 1, b.title, b.content, COALESCE(b.updated_at, b.created_at)
+FROM blog_posts b
+WHERE NOT EXISTS (SELECT 1 FROM post_revisions r WHERE r.blog_post_id = b.id);
+
+-- Comments are listed by creation time with the id breaking ties
+DROP INDEX IF EXISTS idx_comments_post_id_created_at;
+CREATE INDEX idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_blog_posts_created_at_id ON blog_posts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_blog_posts_scheduled_publish_at ON blog_posts(publish_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_comments_root_comment_id ON comments(root_comment_id);
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id);
+CREATE INDEX IF NOT EXISTS idx_post_slugs_blog_post_id ON post_slugs(blog_post_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
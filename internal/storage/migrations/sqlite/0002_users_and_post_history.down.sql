@@ -0,0 +1,37 @@
+-- SQLite cannot drop columns that reference other tables, so posts and comments are copied into tables
+-- of the initial shape which then take their place. The comments copy references the posts copy,
+-- which is renamed along with it, so dropping the current tables cascades to neither copy
+CREATE TABLE blog_posts_initial (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE comments_initial (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts_initial(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+INSERT INTO blog_posts_initial (id, title, content, created_at, updated_at)
+SELECT id, title, content, created_at, updated_at FROM blog_posts;
+
+INSERT INTO comments_initial (id, blog_post_id, content, created_at)
+SELECT id, blog_post_id, content, created_at FROM comments;
+
+DROP TABLE post_slugs;
+DROP TABLE post_tags;
+DROP TABLE tags;
+DROP TABLE post_revisions;
+DROP TABLE comments;
+DROP TABLE blog_posts;
+DROP TABLE api_keys;
+DROP TABLE users;
+
+ALTER TABLE blog_posts_initial RENAME TO blog_posts;
+ALTER TABLE comments_initial RENAME TO comments;
+
+CREATE INDEX idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC);
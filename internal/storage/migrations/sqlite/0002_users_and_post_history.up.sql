@@ -0,0 +1,94 @@
+-- The SQLite counterpart of the Postgres migration. SQLite cannot add a unique column nor make one not null
+-- afterwards, so the slug defaults to empty until it is filled in and is then made unique by an index
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'commenter'
+        CHECK (role IN ('admin', 'editor', 'author', 'commenter')),
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+-- Keys for machine clients acting on behalf of a user, only the SHA-256 hash of each key is stored
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP NULL,
+    last_used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+-- Posts written before there were states stay published
+ALTER TABLE blog_posts ADD COLUMN slug VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE blog_posts ADD COLUMN author_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE blog_posts ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'scheduled', 'archived'));
+ALTER TABLE blog_posts ADD COLUMN publish_at TIMESTAMP NULL;
+
+ALTER TABLE comments ADD COLUMN parent_comment_id INTEGER NULL REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN root_comment_id INTEGER NULL REFERENCES comments(id) ON DELETE CASCADE;
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN author_id INTEGER NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN updated_at TIMESTAMP NULL;
+ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP NULL;
+
+-- Append-only history of the title and content of every post
+CREATE TABLE post_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    restored_from_revision INTEGER NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    CONSTRAINT uq_post_revisions_post_revision UNIQUE (blog_post_id, revision)
+);
+
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE post_tags (
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (blog_post_id, tag_id)
+);
+
+-- Every slug a post ever had, so old permalinks keep resolving to the post
+CREATE TABLE post_slugs (
+    slug VARCHAR(100) PRIMARY KEY,
+    blog_post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+-- Existing posts get a slug made of their id, which no title slug takes from them as it is recorded in post_slugs,
+-- and their current title and content as the first revision
+UPDATE blog_posts SET slug = 'post-' || id WHERE slug = '';
+CREATE UNIQUE INDEX blog_posts_slug_key ON blog_posts(slug);
+
+INSERT INTO post_slugs (slug, blog_post_id)
+SELECT slug, id FROM blog_posts;
+
+INSERT INTO post_revisions (blog_post_id, revision, title, content, created_at)
+SELECT id, 1, title, content, COALESCE(updated_at, created_at)
+FROM blog_posts;
+
+-- Comments are listed by creation time with the id breaking ties
+DROP INDEX idx_comments_post_id_created_at;
+CREATE INDEX idx_comments_post_id_created_at ON comments(blog_post_id, created_at DESC, id DESC);
+
+CREATE INDEX idx_blog_posts_created_at_id ON blog_posts(created_at DESC, id DESC);
+CREATE INDEX idx_blog_posts_scheduled_publish_at ON blog_posts(publish_at) WHERE status = 'scheduled';
+CREATE INDEX idx_comments_root_comment_id ON comments(root_comment_id);
+CREATE INDEX idx_comments_parent_comment_id ON comments(parent_comment_id);
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
+CREATE INDEX idx_post_slugs_blog_post_id ON post_slugs(blog_post_id);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
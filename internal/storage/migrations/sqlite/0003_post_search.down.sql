@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS comments_search_update;
+DROP TRIGGER IF EXISTS comments_search_delete;
+DROP TRIGGER IF EXISTS comments_search_insert;
+DROP TRIGGER IF EXISTS blog_posts_search_update;
+DROP TRIGGER IF EXISTS blog_posts_search_delete;
+DROP TRIGGER IF EXISTS blog_posts_search_insert;
+
+DROP TABLE IF EXISTS comments_search;
+DROP TABLE IF EXISTS blog_posts_search;
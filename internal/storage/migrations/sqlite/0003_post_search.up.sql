@@ -0,0 +1,38 @@
+-- Full-text search over posts and comments with FTS5. The indexes read their text from the tables
+-- and triggers keep them in sync, titles are weighed more than content when ranking
+CREATE VIRTUAL TABLE blog_posts_search USING fts5(
+    title, content, content='blog_posts', content_rowid='id', tokenize='porter unicode61'
+);
+
+CREATE VIRTUAL TABLE comments_search USING fts5(
+    content, content='comments', content_rowid='id', tokenize='porter unicode61'
+);
+
+CREATE TRIGGER blog_posts_search_insert AFTER INSERT ON blog_posts BEGIN
+    INSERT INTO blog_posts_search (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER blog_posts_search_delete AFTER DELETE ON blog_posts BEGIN
+    INSERT INTO blog_posts_search (blog_posts_search, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+END;
+
+CREATE TRIGGER blog_posts_search_update AFTER UPDATE OF title, content ON blog_posts BEGIN
+    INSERT INTO blog_posts_search (blog_posts_search, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+    INSERT INTO blog_posts_search (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER comments_search_insert AFTER INSERT ON comments BEGIN
+    INSERT INTO comments_search (rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER comments_search_delete AFTER DELETE ON comments BEGIN
+    INSERT INTO comments_search (comments_search, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER comments_search_update AFTER UPDATE OF content ON comments BEGIN
+    INSERT INTO comments_search (comments_search, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO comments_search (rowid, content) VALUES (new.id, new.content);
+END;
+
+INSERT INTO blog_posts_search (blog_posts_search) VALUES ('rebuild');
+INSERT INTO comments_search (comments_search) VALUES ('rebuild');
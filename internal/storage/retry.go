@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"go.uber.org/zap"
+)
+
+// The defaults are used for the backoff durations that are not configured with a positive duration
+const (
+	DefaultConnectBackoff    = 500 * time.Millisecond
+	DefaultConnectMaxBackoff = 30 * time.Second
+)
+
+// Backoff spaces the connection attempts, the wait doubles after every failed attempt up to Max
+type Backoff struct {
+	Initial time.Duration
+	Max     time.Duration
+	// jitter picks a duration in [0, n), replicas restarting together then spread their attempts
+	jitter func(n int64) int64
+}
+
+func NewBackoff(cfg config.DatabaseConfig) Backoff {
+	backoff := Backoff{Initial: cfg.ConnectBackoff, Max: cfg.ConnectMaxBackoff, jitter: rand.Int64N}
+	if backoff.Initial <= 0 {
+		backoff.Initial = DefaultConnectBackoff
+	}
+	if backoff.Max <= 0 {
+		backoff.Max = DefaultConnectMaxBackoff
+	}
+	backoff.Max = max(backoff.Max, backoff.Initial)
+	return backoff
+}
+
+// Delay is how long to wait after the given failed attempt, counted from 1. Half of the wait is fixed
+// and the other half random
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for idx := 1; idx < attempt && delay < b.Max; idx++ {
+		delay *= 2
+	}
+	delay = min(delay, b.Max)
+
+	half := delay / 2
+	return half + time.Duration(b.jitter(int64(delay-half)+1))
+}
+
+// ConnectWithRetry connects like Connect, trying again after a backoff while it fails. It gives up after
+// the given number of attempts, or never when attempts is not positive, and whenever the context is done
+func ConnectWithRetry(ctx context.Context, cfg config.Config, attempts int) (*sql.DB, error) {
+	return retry(ctx, NewBackoff(cfg.DatabaseConfig), attempts, func() (*sql.DB, error) {
+		return Connect(ctx, cfg)
+	})
+}
+
+func retry(ctx context.Context, backoff Backoff, attempts int, connect func() (*sql.DB, error)) (*sql.DB, error) {
+	logger := log.GetLogger()
+	for attempt := 1; ; attempt++ {
+		db, err := connect()
+		if err == nil {
+			return db, nil
+		}
+		if attempts > 0 && attempt >= attempts {
+			return nil, err
+		}
+
+		delay := backoff.Delay(attempt)
+		logger.Info("[DBConnection] failed to connect, retrying", zap.Error(err),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", delay),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	// Without jitter the delay is half of the doubled wait, with the most jitter it is all of it
+	backoff.jitter = func(n int64) int64 { return 0 }
+	assert.Equal(t, 50*time.Millisecond, backoff.Delay(1))
+	assert.Equal(t, 100*time.Millisecond, backoff.Delay(2))
+	assert.Equal(t, 200*time.Millisecond, backoff.Delay(3))
+	assert.Equal(t, 500*time.Millisecond, backoff.Delay(5), "the wait should be capped")
+	assert.Equal(t, 500*time.Millisecond, backoff.Delay(100))
+
+	backoff.jitter = func(n int64) int64 { return n - 1 }
+	assert.Equal(t, 100*time.Millisecond, backoff.Delay(1))
+	assert.Equal(t, time.Second, backoff.Delay(100))
+}
+
+func TestNewBackoff_Defaults(t *testing.T) {
+	backoff := NewBackoff(config.DatabaseConfig{})
+	assert.Equal(t, DefaultConnectBackoff, backoff.Initial)
+	assert.Equal(t, DefaultConnectMaxBackoff, backoff.Max)
+
+	backoff = NewBackoff(config.DatabaseConfig{ConnectBackoff: time.Minute, ConnectMaxBackoff: time.Second})
+	assert.Equal(t, time.Minute, backoff.Max, "the cap should not be under the first wait")
+}
+
+func TestRetry(t *testing.T) {
+	backoff := Backoff{Initial: time.Millisecond, Max: time.Millisecond, jitter: func(n int64) int64 { return 0 }}
+	errDown := errors.New("database is down")
+
+	t.Run("succeeds after failures", func(t *testing.T) {
+		calls := 0
+		db, err := retry(context.Background(), backoff, 5, func() (*sql.DB, error) {
+			calls++
+			if calls < 3 {
+				return nil, errDown
+			}
+			return &sql.DB{}, nil
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, db)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		calls := 0
+		_, err := retry(context.Background(), backoff, 3, func() (*sql.DB, error) {
+			calls++
+			return nil, errDown
+		})
+		assert.True(t, errors.Is(err, errDown), "error should wrap the last connection error")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops with the context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		_, err := retry(ctx, backoff, 0, func() (*sql.DB, error) {
+			calls++
+			if calls == 10 {
+				cancel()
+			}
+			return nil, errDown
+		})
+		assert.True(t, errors.Is(err, context.Canceled), "error should wrap context.Canceled")
+		assert.Equal(t, 10, calls)
+	})
+}
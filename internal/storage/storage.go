@@ -3,19 +3,85 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/aleszilagyi/prosig-blog/config"
 	log "github.com/aleszilagyi/prosig-blog/internal/logger"
+	"github.com/aleszilagyi/prosig-blog/internal/storage/migrations"
 	_ "github.com/lib/pq"
 	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
 )
 
-func Connect(cfg config.Config) (*sql.DB, error) {
+// The drivers db.driver can name, an empty driver means postgres
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Driver is the driver db.driver names, postgres when it is empty
+func Driver(cfg config.Config) string {
+	if cfg.DatabaseConfig.Driver == "" {
+		return DriverPostgres
+	}
+	return cfg.DatabaseConfig.Driver
+}
+
+const (
+	// migrateTimeout bounds how long the service waits at startup for migrations, including for another replica applying them
+	migrateTimeout = 5 * time.Minute
+	// pingTimeout bounds how long a connection attempt waits for the database to answer
+	pingTimeout = 5 * time.Second
+)
+
+// Connect opens the database for the service, applying the pending migrations first when configured to.
+// It gives up as soon as the context is done, including while waiting on another replica migrating
+func Connect(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.DatabaseConfig.MigrateOnStart {
+		return db, nil
+	}
+
+	migrateCtx, cancel := context.WithTimeout(ctx, migrateTimeout)
+	defer cancel()
+
+	applied, err := migrations.Up(migrateCtx, db, Driver(cfg))
+	if err != nil {
+		log.GetLogger().Error("[DBConnection] failed to migrate the database", zap.Error(err),
+			zap.String("db_driver", Driver(cfg)),
+		)
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate db: %w", err)
+	}
+
+	log.GetLogger().Info("[DBConnection] database migrated", zap.Int("applied_migrations", applied))
+	return db, nil
+}
+
+// Open connects to the database, leaving its schema as it is. The pool is closed when the database cannot be reached
+func Open(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	switch driver := Driver(cfg); driver {
+	case DriverPostgres:
+		return openPostgres(ctx, cfg)
+	case DriverSQLite:
+		return openSQLite(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("db driver %q has no database to open", driver)
+	}
+}
+
+func openPostgres(ctx context.Context, cfg config.Config) (*sql.DB, error) {
 	logger := log.GetLogger()
+	// The session runs in UTC, so the timestamps the database defaults to are in UTC like the ones the service sends
 	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
+		"postgres://%s:%s@%s:%d/%s?sslmode=%s&timezone=UTC",
 		cfg.DatabaseConfig.User,
 		cfg.DatabaseConfig.Password,
 		cfg.DatabaseConfig.Host,
@@ -39,15 +105,47 @@ func Connect(cfg config.Config) (*sql.DB, error) {
 	db.SetConnMaxLifetime(30 * time.Minute)
 	db.SetConnMaxIdleTime(1 * time.Minute)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
 	defer cancel()
 
-	if err := db.PingContext(ctx); err != nil {
+	if err := db.PingContext(pingCtx); err != nil {
 		logger.Error("[PGConnection] failed to ping postgres", zap.Error(err),
 			zap.String("db_name", cfg.DatabaseConfig.Name),
 			zap.String("db_host", cfg.DatabaseConfig.Host),
 			zap.Int("db_port", cfg.DatabaseConfig.Port),
 		)
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+
+	return db, nil
+}
+
+// openSQLite opens the database file, creating it when missing. Foreign keys are off by default in SQLite
+// and are turned on, transactions take the write lock as they begin, and a single connection is kept
+// as SQLite only has one writer at a time
+func openSQLite(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	logger := log.GetLogger().With(zap.String("db_path", cfg.DatabaseConfig.Path))
+	if cfg.DatabaseConfig.Path == "" {
+		return nil, errors.New("db path is required by the sqlite driver")
+	}
+
+	dsn := "file:" + cfg.DatabaseConfig.Path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		logger.Error("[SQLiteConnection] failed to open sqlite database", zap.Error(err))
+		return nil, fmt.Errorf("failed to open db: %w", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(pingCtx); err != nil {
+		logger.Error("[SQLiteConnection] failed to ping sqlite", zap.Error(err))
+		db.Close()
 		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
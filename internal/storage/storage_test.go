@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnect_StopsWithTheContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Connect(ctx, config.Config{DatabaseConfig: config.DatabaseConfig{
+		Driver:         DriverSQLite,
+		Path:           filepath.Join(t.TempDir(), "blog.db"),
+		MigrateOnStart: true,
+	}})
+	assert.True(t, errors.Is(err, context.Canceled), "error should wrap context.Canceled, got %v", err)
+}
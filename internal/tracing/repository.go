@@ -0,0 +1,252 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	app_err "github.com/aleszilagyi/prosig-blog/internal/error"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/response"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// start opens the span of a repository operation, the spans of its SQL statements are its children
+func start(ctx context.Context, repo, operation string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, repo+"."+operation, trace.WithAttributes(
+		attribute.String("repository", repo),
+		attribute.String("operation", operation),
+	))
+}
+
+// end closes the span of a repository operation, it is deferred once the span is open. Missing resources
+// are an answer rather than a failure, so they do not mark the span as failed
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, app_err.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type blogRepository struct {
+	next repository.BlogRepository
+}
+
+// TraceBlogRepository opens a span for every operation of the repository
+func TraceBlogRepository(next repository.BlogRepository) repository.BlogRepository {
+	return &blogRepository{next: next}
+}
+
+func (r *blogRepository) GetAllPostsWithCommentCount(ctx context.Context, page model.Page, filter model.TagFilter) (posts []*response.PostWithCommentCountResponse, cursor string, err error) {
+	ctx, span := start(ctx, "blog", "GetAllPostsWithCommentCount")
+	defer func() { end(span, err) }()
+	return r.next.GetAllPostsWithCommentCount(ctx, page, filter)
+}
+
+func (r *blogRepository) GetPostWithComments(ctx context.Context, id int, comments model.Page, view model.CommentView) (post *response.PostWithCommentsResponse, err error) {
+	ctx, span := start(ctx, "blog", "GetPostWithComments")
+	defer func() { end(span, err) }()
+	return r.next.GetPostWithComments(ctx, id, comments, view)
+}
+
+func (r *blogRepository) GetComments(ctx context.Context, blogPostID int, page model.Page, view model.CommentView) (comments []*response.CommentResponse, cursor string, err error) {
+	ctx, span := start(ctx, "blog", "GetComments")
+	defer func() { end(span, err) }()
+	return r.next.GetComments(ctx, blogPostID, page, view)
+}
+
+func (r *blogRepository) GetReplies(ctx context.Context, blogPostID, rootCommentID int, page model.Page) (replies []*response.CommentResponse, cursor string, err error) {
+	ctx, span := start(ctx, "blog", "GetReplies")
+	defer func() { end(span, err) }()
+	return r.next.GetReplies(ctx, blogPostID, rootCommentID, page)
+}
+
+func (r *blogRepository) GetComment(ctx context.Context, blogPostID, commentID int) (comment *response.CommentResponse, err error) {
+	ctx, span := start(ctx, "blog", "GetComment")
+	defer func() { end(span, err) }()
+	return r.next.GetComment(ctx, blogPostID, commentID)
+}
+
+func (r *blogRepository) CreatePost(ctx context.Context, post *model.Post) (id int, err error) {
+	ctx, span := start(ctx, "blog", "CreatePost")
+	defer func() { end(span, err) }()
+	return r.next.CreatePost(ctx, post)
+}
+
+func (r *blogRepository) UpdatePost(ctx context.Context, id int, changes model.PostChanges) (err error) {
+	ctx, span := start(ctx, "blog", "UpdatePost")
+	defer func() { end(span, err) }()
+	return r.next.UpdatePost(ctx, id, changes)
+}
+
+func (r *blogRepository) DeletePost(ctx context.Context, id int) (err error) {
+	ctx, span := start(ctx, "blog", "DeletePost")
+	defer func() { end(span, err) }()
+	return r.next.DeletePost(ctx, id)
+}
+
+func (r *blogRepository) GetPostAuthorID(ctx context.Context, id int) (authorID *int, err error) {
+	ctx, span := start(ctx, "blog", "GetPostAuthorID")
+	defer func() { end(span, err) }()
+	return r.next.GetPostAuthorID(ctx, id)
+}
+
+func (r *blogRepository) PublishScheduledPosts(ctx context.Context, now time.Time) (published int, err error) {
+	ctx, span := start(ctx, "blog", "PublishScheduledPosts")
+	defer func() { end(span, err) }()
+	return r.next.PublishScheduledPosts(ctx, now)
+}
+
+func (r *blogRepository) GetRevisions(ctx context.Context, blogPostID int, includeUnpublished bool) (revisions []*response.RevisionResponse, err error) {
+	ctx, span := start(ctx, "blog", "GetRevisions")
+	defer func() { end(span, err) }()
+	return r.next.GetRevisions(ctx, blogPostID, includeUnpublished)
+}
+
+func (r *blogRepository) GetRevision(ctx context.Context, blogPostID, revision int, includeUnpublished bool) (rev *response.RevisionResponse, err error) {
+	ctx, span := start(ctx, "blog", "GetRevision")
+	defer func() { end(span, err) }()
+	return r.next.GetRevision(ctx, blogPostID, revision, includeUnpublished)
+}
+
+func (r *blogRepository) RestoreRevision(ctx context.Context, blogPostID, revision int) (restored int, err error) {
+	ctx, span := start(ctx, "blog", "RestoreRevision")
+	defer func() { end(span, err) }()
+	return r.next.RestoreRevision(ctx, blogPostID, revision)
+}
+
+func (r *blogRepository) AddComment(ctx context.Context, blogPostID int, parentCommentID *int, authorID int, content string) (id int, err error) {
+	ctx, span := start(ctx, "blog", "AddComment")
+	defer func() { end(span, err) }()
+	return r.next.AddComment(ctx, blogPostID, parentCommentID, authorID, content)
+}
+
+func (r *blogRepository) UpdateComment(ctx context.Context, blogPostID, commentID int, content string) (err error) {
+	ctx, span := start(ctx, "blog", "UpdateComment")
+	defer func() { end(span, err) }()
+	return r.next.UpdateComment(ctx, blogPostID, commentID, content)
+}
+
+func (r *blogRepository) DeleteComment(ctx context.Context, blogPostID, commentID int) (err error) {
+	ctx, span := start(ctx, "blog", "DeleteComment")
+	defer func() { end(span, err) }()
+	return r.next.DeleteComment(ctx, blogPostID, commentID)
+}
+
+func (r *blogRepository) ResolveSlug(ctx context.Context, slug string) (id int, canonical string, err error) {
+	ctx, span := start(ctx, "blog", "ResolveSlug")
+	defer func() { end(span, err) }()
+	return r.next.ResolveSlug(ctx, slug)
+}
+
+func (r *blogRepository) GetTags(ctx context.Context) (tags []*response.TagResponse, err error) {
+	ctx, span := start(ctx, "blog", "GetTags")
+	defer func() { end(span, err) }()
+	return r.next.GetTags(ctx)
+}
+
+func (r *blogRepository) CreateTag(ctx context.Context, name string) (id int, err error) {
+	ctx, span := start(ctx, "blog", "CreateTag")
+	defer func() { end(span, err) }()
+	return r.next.CreateTag(ctx, name)
+}
+
+func (r *blogRepository) RenameTag(ctx context.Context, id int, name string) (err error) {
+	ctx, span := start(ctx, "blog", "RenameTag")
+	defer func() { end(span, err) }()
+	return r.next.RenameTag(ctx, id, name)
+}
+
+func (r *blogRepository) MergeTags(ctx context.Context, sourceID, targetID int) (err error) {
+	ctx, span := start(ctx, "blog", "MergeTags")
+	defer func() { end(span, err) }()
+	return r.next.MergeTags(ctx, sourceID, targetID)
+}
+
+func (r *blogRepository) SearchPosts(ctx context.Context, terms string, page model.SearchPage) (results []*response.SearchResultResponse, cursor string, err error) {
+	ctx, span := start(ctx, "blog", "SearchPosts")
+	defer func() { end(span, err) }()
+	return r.next.SearchPosts(ctx, terms, page)
+}
+
+type userRepository struct {
+	next repository.UserRepository
+}
+
+// TraceUserRepository opens a span for every operation of the repository
+func TraceUserRepository(next repository.UserRepository) repository.UserRepository {
+	return &userRepository{next: next}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *model.User) (created *response.UserResponse, err error) {
+	ctx, span := start(ctx, "users", "CreateUser")
+	defer func() { end(span, err) }()
+	return r.next.CreateUser(ctx, user)
+}
+
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (user *model.User, err error) {
+	ctx, span := start(ctx, "users", "GetUserByUsername")
+	defer func() { end(span, err) }()
+	return r.next.GetUserByUsername(ctx, username)
+}
+
+func (r *userRepository) GetUser(ctx context.Context, id int) (user *model.User, err error) {
+	ctx, span := start(ctx, "users", "GetUser")
+	defer func() { end(span, err) }()
+	return r.next.GetUser(ctx, id)
+}
+
+func (r *userRepository) GetUsers(ctx context.Context) (users []*response.UserResponse, err error) {
+	ctx, span := start(ctx, "users", "GetUsers")
+	defer func() { end(span, err) }()
+	return r.next.GetUsers(ctx)
+}
+
+func (r *userRepository) UpdateUserRole(ctx context.Context, id int, role model.Role) (err error) {
+	ctx, span := start(ctx, "users", "UpdateUserRole")
+	defer func() { end(span, err) }()
+	return r.next.UpdateUserRole(ctx, id, role)
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id int) (err error) {
+	ctx, span := start(ctx, "users", "DeleteUser")
+	defer func() { end(span, err) }()
+	return r.next.DeleteUser(ctx, id)
+}
+
+type apiKeyRepository struct {
+	next repository.APIKeyRepository
+}
+
+// TraceAPIKeyRepository opens a span for every operation of the repository
+func TraceAPIKeyRepository(next repository.APIKeyRepository) repository.APIKeyRepository {
+	return &apiKeyRepository{next: next}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) (created *response.APIKeyResponse, err error) {
+	ctx, span := start(ctx, "api_keys", "CreateAPIKey")
+	defer func() { end(span, err) }()
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r *apiKeyRepository) GetAPIKeys(ctx context.Context) (keys []*response.APIKeyResponse, err error) {
+	ctx, span := start(ctx, "api_keys", "GetAPIKeys")
+	defer func() { end(span, err) }()
+	return r.next.GetAPIKeys(ctx)
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id int) (err error) {
+	ctx, span := start(ctx, "api_keys", "RevokeAPIKey")
+	defer func() { end(span, err) }()
+	return r.next.RevokeAPIKey(ctx, id)
+}
+
+func (r *apiKeyRepository) AuthenticateAPIKey(ctx context.Context, keyHash string, now time.Time) (key *model.APIKey, err error) {
+	ctx, span := start(ctx, "api_keys", "AuthenticateAPIKey")
+	defer func() { end(span, err) }()
+	return r.next.AuthenticateAPIKey(ctx, keyHash, now)
+}
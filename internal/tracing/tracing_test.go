@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aleszilagyi/prosig-blog/config"
+	"github.com/aleszilagyi/prosig-blog/internal/model"
+	"github.com/aleszilagyi/prosig-blog/internal/repository"
+	"github.com/aleszilagyi/prosig-blog/internal/repository/memory"
+	"github.com/aleszilagyi/prosig-blog/internal/storage"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spans records every span of the tests, the tracers of the packages are bound to the first provider set
+var spans = tracetest.NewInMemoryExporter()
+
+func TestMain(m *testing.M) {
+	config.LoadConfig()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	code := m.Run()
+	os.Exit(code)
+}
+
+// byName finds the recorded span with the name
+func byName(t *testing.T, name string) tracetest.SpanStub {
+	for _, span := range spans.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+	t.Fatalf("no span named %q in %v", name, spans.GetSpans())
+	return tracetest.SpanStub{}
+}
+
+func TestMiddleware_ContinuesTraceparent(t *testing.T) {
+	spans.Reset()
+	gin.SetMode(gin.TestMode)
+	blog := TraceBlogRepository(memory.NewBlogRepository(memory.NewStore()))
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/posts/:id/comments/:commentId", func(ctx *gin.Context) {
+		_, err := blog.GetComment(ctx.Request.Context(), 1, 1)
+		assert.Error(t, err)
+		ctx.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/posts/1/comments/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	server := byName(t, "GET /posts/:id/comments/:commentId")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String(), "the caller's span should be the parent")
+	assert.True(t, server.Parent.IsRemote())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Contains(t, server.Attributes, semconv.HTTPRoute("/posts/:id/comments/:commentId"))
+	assert.Contains(t, server.Attributes, semconv.HTTPResponseStatusCode(http.StatusNotFound))
+	assert.Equal(t, codes.Unset, server.Status.Code, "client errors should not fail the span")
+
+	repo := byName(t, "blog.GetComment")
+	assert.Equal(t, server.SpanContext.SpanID(), repo.Parent.SpanID(), "the repository span should be a child of the request")
+	assert.Equal(t, codes.Unset, repo.Status.Code, "a missing comment should not fail the span")
+}
+
+func TestMiddleware_StartsTrace(t *testing.T) {
+	spans.Reset()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/boom", func(ctx *gin.Context) {
+		ctx.Status(http.StatusInternalServerError)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/boom", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nowhere", nil))
+
+	failed := byName(t, "GET /boom")
+	assert.True(t, failed.SpanContext.TraceID().IsValid())
+	assert.False(t, failed.Parent.IsValid(), "the request should start a trace")
+	assert.Equal(t, codes.Error, failed.Status.Code)
+
+	unmatched := byName(t, "GET")
+	assert.Contains(t, unmatched.Attributes, semconv.HTTPResponseStatusCode(http.StatusNotFound))
+}
+
+func TestTraceBlogRepository_SQLStatements(t *testing.T) {
+	db, err := storage.Connect(context.Background(), config.Config{DatabaseConfig: config.DatabaseConfig{
+		Driver:         storage.DriverSQLite,
+		Path:           filepath.Join(t.TempDir(), "blog.db"),
+		MigrateOnStart: true,
+	}})
+	assert.NoError(t, err)
+	defer db.Close()
+	blog := TraceBlogRepository(repository.NewBlogRepository(db, repository.DialectSQLite))
+	spans.Reset()
+
+	_, err = blog.CreatePost(context.Background(), &model.Post{Title: "Traced", Content: "Down to the SQL", Status: model.PostStatusPublished})
+	assert.NoError(t, err)
+
+	operation := byName(t, "blog.CreatePost")
+	insert := byName(t, "INSERT blog_posts")
+	assert.Equal(t, operation.SpanContext.SpanID(), insert.Parent.SpanID(), "the statement span should be a child of the operation")
+	assert.Equal(t, trace.SpanKindClient, insert.SpanKind)
+	assert.Contains(t, insert.Attributes, semconv.DBSystemNameSQLite)
+	assert.Contains(t, insert.Attributes, semconv.DBQuerySummary("INSERT blog_posts"))
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
+
+func TestSampler(t *testing.T) {
+	assert.Equal(t, "ParentBased{root:AlwaysOnSampler,remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		Sampler(0).Description())
+	assert.Contains(t, Sampler(0.25).Description(), "root:TraceIDRatioBased{0.25}")
+}